@@ -0,0 +1,385 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"path/filepath"
+	"storageapi/internal/config"
+	"storageapi/internal/health"
+	"storageapi/internal/test/testdb"
+	"storageapi/pkg/errs"
+	"storageapi/pkg/model"
+	"sync"
+	"testing"
+	"time"
+)
+
+// serve читает настройки из переменных пакета config, а goose хранит
+// диалект глобально, поэтому серверы создаются по одному. запросы к уже
+// запущенным серверам идут параллельно
+var serveMu sync.Mutex
+
+func TestMain(m *testing.M) {
+	config.RequestHandleTimeout = 10 * time.Second
+	config.FixturesPath = testdb.FixturesPath()
+	testdb.Main(m)
+}
+
+// startServer поднимает сервер целиком через serve на случайном порту
+// и возвращает подключенный к нему JSON-RPC клиент. для postgres
+// у каждого сервера своя схема, сервер останавливается по завершении теста
+func startServer(t *testing.T, backend string) *rpc.Client {
+	t.Helper()
+	app, err := func() (*application, error) {
+		serveMu.Lock()
+		defer serveMu.Unlock()
+		config.RepositoryBackend = backend
+		if backend == config.RepositoryPostgres {
+			config.DatabaseURL = testdb.New(t)
+		}
+		return serve(http.NewServeMux(), health.NewChecker(config.HealthCheckTimeout))
+	}()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go app.tcpServer.Serve(listener)
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout)
+		defer cancel()
+		app.shutdown(ctx, &http.Server{})
+	})
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := jsonrpc.NewClient(conn)
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+// сценарии запускаются на обоих хранилищах, каждый со своим сервером
+func TestWithRpcClient(t *testing.T) {
+	scenarios := []struct {
+		name string
+		run  func(t *testing.T, client *rpc.Client)
+	}{
+		{"DefineStorageSchema", testDefineStorageSchema},
+		{"ReserveAcrossStorages", testReserveAcrossStorages},
+		{"OverReserve", testOverReserve},
+		{"UndoReservation", testUndoReservation},
+		{"GetUnreservedStorage", testGetUnreservedStorage},
+		{"LegacyRequests", testLegacyRequests},
+	}
+	for _, backend := range []string{config.RepositoryPostgres, config.RepositoryMemory} {
+		backend := backend
+		t.Run(backend, func(t *testing.T) {
+			t.Parallel()
+			for _, sc := range scenarios {
+				sc := sc
+				t.Run(sc.name, func(t *testing.T) {
+					t.Parallel()
+					sc.run(t, startServer(t, backend))
+				})
+			}
+		})
+	}
+}
+
+// ошибки старта возвращаются из serve, а не завершают процесс
+func TestServeErrors(t *testing.T) {
+	serveMu.Lock()
+	defer serveMu.Unlock()
+	backend, fixtures := config.RepositoryBackend, config.FixturesPath
+	defer func() {
+		config.RepositoryBackend, config.FixturesPath = backend, fixtures
+	}()
+
+	config.RepositoryBackend = "unknown"
+	if _, err := serve(http.NewServeMux(), health.NewChecker(config.HealthCheckTimeout)); err == nil {
+		t.Fatal("serve started with unknown repository backend")
+	}
+	t.Run("Migrations", func(t *testing.T) {
+		config.RepositoryBackend = config.RepositoryPostgres
+		config.DatabaseURL = testdb.New(t)
+		config.FixturesPath = filepath.Join(t.TempDir(), "missing")
+		if _, err := serve(http.NewServeMux(), health.NewChecker(config.HealthCheckTimeout)); err == nil {
+			t.Fatal("serve started without migrations")
+		}
+	})
+}
+
+func call(t *testing.T, client *rpc.Client, method string, req, resp interface{}) {
+	t.Helper()
+	if err := client.Call(method, req, resp); err != nil {
+		t.Fatalf("%s: %v", method, err)
+	}
+}
+
+// код ошибки сервера, которую он передает JSON строкой (см. errs.Marshal)
+func callCode(t *testing.T, client *rpc.Client, method string, req, resp interface{}) errs.Code {
+	t.Helper()
+	err := client.Call(method, req, resp)
+	var serverErr rpc.ServerError
+	if !errors.As(err, &serverErr) {
+		t.Fatalf("%s: expected server error, got %v", method, err)
+	}
+	return errs.CodeOf(errs.Unmarshal(string(serverErr)))
+}
+
+// склад с рубашками (3 шт.) и шапками (2 шт.) и выключенный склад с куртками
+func defineSchema(t *testing.T, client *rpc.Client) model.StorageSchemaResp {
+	t.Helper()
+	var resp model.StorageSchemaResp
+	call(t, client, "Storage.DefineStorageSchema", model.StorageSchemaReq{
+		Storages: []model.StorageSchemaReqItem{
+			{IsAvailable: true, Products: []model.StorageSchemaReqProduct{
+				{Vendor: "shirt-1", Name: "shirt", Size: "m", Amount: 3},
+				{Vendor: "hat-1", Name: "hat", Size: "s", Amount: 2},
+			}},
+			{IsAvailable: false, Products: []model.StorageSchemaReqProduct{
+				{Vendor: "jacket-1", Name: "jacket", Size: "l", Amount: 5},
+			}},
+		},
+	}, &resp)
+	if len(resp) != 2 || len(resp[0].Products) != 2 || len(resp[1].Products) != 1 {
+		t.Fatalf("unexpected schema: %+v", resp)
+	}
+	return resp
+}
+
+func reserve(t *testing.T, client *rpc.Client, productID, amount uint) model.ReservationResp {
+	t.Helper()
+	var resp model.ReservationResp
+	call(t, client, "Reservation.CreateReservation", model.ReserveProductsReq{
+		Products: []model.ReserveProductsReqItem{{ID: productID, Amount: amount}},
+	}, &resp)
+	return resp
+}
+
+// количество товара productID на складе по GetUnreservedStorage
+func unreserved(t *testing.T, client *rpc.Client, storageID, productID uint) uint {
+	t.Helper()
+	var resp model.StorageSchemaRespItem
+	call(t, client, "Storage.GetUnreservedStorage", model.GetUnreservedStorageReq{StorageID: storageID}, &resp)
+	for _, p := range resp.Products {
+		if p.ID == productID {
+			return p.Amount
+		}
+	}
+	t.Fatalf("product %d is not stored at storage %d: %+v", productID, storageID, resp)
+	return 0
+}
+
+func testDefineStorageSchema(t *testing.T, client *rpc.Client) {
+	schema := defineSchema(t, client)
+	if !schema[0].IsAvailable || schema[1].IsAvailable {
+		t.Fatalf("availability is not preserved: %+v", schema)
+	}
+
+	var resp model.GetStorageSchemaResp
+	call(t, client, "Storage.GetStorageSchema", model.GetStorageSchemaReq{IDs: []uint{schema[0].ID, schema[1].ID}}, &resp)
+	if len(resp) != 2 {
+		t.Fatalf("expected both storages, got %+v", resp)
+	}
+	for _, storage := range resp {
+		want := schema[0]
+		if storage.ID == schema[1].ID {
+			want = schema[1]
+		}
+		if storage.IsAvailable != want.IsAvailable || len(storage.Products) != len(want.Products) {
+			t.Fatalf("storage %d: got %+v, want %+v", storage.ID, storage, want)
+		}
+		for _, p := range storage.Products {
+			if p.Reserved != 0 || p.Free != p.Amount {
+				t.Errorf("storage %d: new product must be free: %+v", storage.ID, p)
+			}
+		}
+	}
+
+	// vendor уникален, повтор схемы не создает ничего
+	var dup model.StorageSchemaResp
+	code := callCode(t, client, "Storage.DefineStorageSchema", model.StorageSchemaReq{
+		Storages: []model.StorageSchemaReqItem{{IsAvailable: true, Products: []model.StorageSchemaReqProduct{
+			{Vendor: "shirt-1", Name: "shirt", Size: "m", Amount: 1},
+		}}},
+	}, &dup)
+	if code == "" {
+		t.Fatal("expected duplicate vendor to be rejected")
+	}
+	call(t, client, "Storage.GetStorageSchema", model.GetStorageSchemaReq{}, &resp)
+	if len(resp) != 2 {
+		t.Fatalf("rejected schema must not create storages, got %+v", resp)
+	}
+}
+
+func testReserveAcrossStorages(t *testing.T, client *rpc.Client) {
+	schema := defineSchema(t, client)
+	first, shirt := schema[0].ID, schema[0].Products[0].ID
+	var second model.StorageSchemaResp
+	call(t, client, "Storage.DefineStorageSchema", model.StorageSchemaReq{
+		Storages: []model.StorageSchemaReqItem{{IsAvailable: true, Products: []model.StorageSchemaReqProduct{
+			{Vendor: "scarf-1", Name: "scarf", Size: "m", Amount: 1},
+		}}},
+	}, &second)
+	var stock model.StockResp
+	call(t, client, "Storage.ReceiveStock", model.ReceiveStockReq{
+		StorageID: second[0].ID,
+		Products:  []model.ReceiveStockReqProduct{{ProductID: shirt, Amount: 4}},
+	}, &stock)
+
+	// 3 рубашки на первом складе и 4 на втором, резерв 6 берет с обоих
+	resp := reserve(t, client, shirt, 6)
+	if resp.Status != "active" {
+		t.Fatalf("expected active reservation, got %+v", resp)
+	}
+	byStorage := map[uint]uint{}
+	var total uint
+	for _, item := range resp.Items {
+		if item.ProductID != shirt {
+			t.Fatalf("unexpected product in reservation: %+v", item)
+		}
+		byStorage[item.StorageID] += item.Amount
+		total += item.Amount
+	}
+	if len(byStorage) != 2 || total != 6 || byStorage[first] > 3 || byStorage[second[0].ID] > 4 {
+		t.Fatalf("expected reservation to be split between storages, got %+v", resp.Items)
+	}
+	if got := unreserved(t, client, first, shirt) + unreserved(t, client, second[0].ID, shirt); got != 1 {
+		t.Fatalf("expected 1 shirt left unreserved, got %d", got)
+	}
+
+	var got model.ReservationResp
+	call(t, client, "Reservation.GetReservation", model.GetReservationReq{ID: resp.ID}, &got)
+	if got.ID != resp.ID || got.Status != "active" || len(got.Items) != len(resp.Items) {
+		t.Fatalf("GetReservation: got %+v, want %+v", got, resp)
+	}
+}
+
+func testOverReserve(t *testing.T, client *rpc.Client) {
+	schema := defineSchema(t, client)
+	storage, shirt, hat := schema[0].ID, schema[0].Products[0].ID, schema[0].Products[1].ID
+	jacket := schema[1].Products[0].ID
+
+	var resp model.ReservationResp
+	code := callCode(t, client, "Reservation.CreateReservation", model.ReserveProductsReq{
+		Products: []model.ReserveProductsReqItem{{ID: shirt, Amount: 4}},
+	}, &resp)
+	if code != errs.CodeInsufficientStock {
+		t.Fatalf("expected %s, got %s", errs.CodeInsufficientStock, code)
+	}
+	// резерв атомарный: хватает шапок, но не рубашек - не резервируется ничего
+	code = callCode(t, client, "Reservation.CreateReservation", model.ReserveProductsReq{
+		Products: []model.ReserveProductsReqItem{{ID: hat, Amount: 1}, {ID: shirt, Amount: 4}},
+	}, &resp)
+	if code != errs.CodeInsufficientStock {
+		t.Fatalf("expected %s, got %s", errs.CodeInsufficientStock, code)
+	}
+	if got := unreserved(t, client, storage, hat); got != 2 {
+		t.Fatalf("failed reservation must not hold hats, %d left", got)
+	}
+	// остаток выключенного склада не резервируется
+	code = callCode(t, client, "Reservation.CreateReservation", model.ReserveProductsReq{
+		Products: []model.ReserveProductsReqItem{{ID: jacket, Amount: 1}},
+	}, &resp)
+	if code != errs.CodeInsufficientStock {
+		t.Fatalf("expected %s for unavailable storage, got %s", errs.CodeInsufficientStock, code)
+	}
+
+	// весь остаток резервируется, следующий резерв уже не проходит
+	reserve(t, client, shirt, 3)
+	code = callCode(t, client, "Reservation.CreateReservation", model.ReserveProductsReq{
+		Products: []model.ReserveProductsReqItem{{ID: shirt, Amount: 1}},
+	}, &resp)
+	if code != errs.CodeInsufficientStock {
+		t.Fatalf("expected %s, got %s", errs.CodeInsufficientStock, code)
+	}
+}
+
+func testUndoReservation(t *testing.T, client *rpc.Client) {
+	schema := defineSchema(t, client)
+	storage, shirt := schema[0].ID, schema[0].Products[0].ID
+
+	resp := reserve(t, client, shirt, 2)
+	if got := unreserved(t, client, storage, shirt); got != 1 {
+		t.Fatalf("expected 1 shirt unreserved, got %d", got)
+	}
+	var empty struct{}
+	call(t, client, "Reservation.UndoReservation", model.UndoReservationReq{ID: resp.ID}, &empty)
+	if got := unreserved(t, client, storage, shirt); got != 3 {
+		t.Fatalf("undo must release shirts, %d unreserved", got)
+	}
+	var got model.ReservationResp
+	call(t, client, "Reservation.GetReservation", model.GetReservationReq{ID: resp.ID}, &got)
+	if got.Status != "cancelled" {
+		t.Fatalf("expected cancelled reservation, got %+v", got)
+	}
+
+	if code := callCode(t, client, "Reservation.UndoReservation", model.UndoReservationReq{ID: resp.ID}, &empty); code != errs.CodeConflict {
+		t.Fatalf("expected %s for repeated undo, got %s", errs.CodeConflict, code)
+	}
+	if code := callCode(t, client, "Reservation.UndoReservation", model.UndoReservationReq{ID: resp.ID + 1000}, &empty); code != errs.CodeNotFound {
+		t.Fatalf("expected %s for unknown reservation, got %s", errs.CodeNotFound, code)
+	}
+	// освобожденный остаток снова можно зарезервировать
+	reserve(t, client, shirt, 3)
+}
+
+func testGetUnreservedStorage(t *testing.T, client *rpc.Client) {
+	schema := defineSchema(t, client)
+	storage, shirt, hat := schema[0].ID, schema[0].Products[0].ID, schema[0].Products[1].ID
+
+	reserve(t, client, shirt, 1)
+	reserve(t, client, shirt, 1)
+	reserve(t, client, hat, 2)
+	var resp model.StorageSchemaRespItem
+	call(t, client, "Storage.GetUnreservedStorage", model.GetUnreservedStorageReq{StorageID: storage}, &resp)
+	if resp.ID != storage || len(resp.Products) != 2 {
+		t.Fatalf("unexpected storage: %+v", resp)
+	}
+	want := map[uint]uint{shirt: 1, hat: 0}
+	for _, p := range resp.Products {
+		if p.Amount != want[p.ID] {
+			t.Errorf("product %s: expected %d unreserved, got %d", p.Vendor, want[p.ID], p.Amount)
+		}
+	}
+
+	if code := callCode(t, client, "Storage.GetUnreservedStorage", model.GetUnreservedStorageReq{StorageID: schema[1].ID}, &resp); code != errs.CodeUnavailable {
+		t.Fatalf("expected %s for unavailable storage, got %s", errs.CodeUnavailable, code)
+	}
+	if code := callCode(t, client, "Storage.GetUnreservedStorage", model.GetUnreservedStorageReq{StorageID: schema[1].ID + 1000}, &resp); code != errs.CodeNotFound {
+		t.Fatalf("expected %s for unknown storage, got %s", errs.CodeNotFound, code)
+	}
+}
+
+// клиенты старых версий передают запросы массивом
+func testLegacyRequests(t *testing.T, client *rpc.Client) {
+	var schema model.StorageSchemaResp
+	call(t, client, "Storage.DefineStorageSchema", []model.StorageSchemaReqItem{
+		{IsAvailable: true, Products: []model.StorageSchemaReqProduct{
+			{Vendor: "shirt-1", Name: "shirt", Size: "m", Amount: 3},
+		}},
+	}, &schema)
+	if len(schema) != 1 || len(schema[0].Products) != 1 || schema[0].Products[0].Amount != 3 {
+		t.Fatalf("unexpected schema: %+v", schema)
+	}
+	storage, shirt := schema[0].ID, schema[0].Products[0].ID
+
+	var resp model.ReservationResp
+	call(t, client, "Reservation.CreateReservation", []model.ReserveProductsReqItem{{ID: shirt, Amount: 2}}, &resp)
+	if resp.Status != "active" || len(resp.Items) != 1 || resp.Items[0].Amount != 2 {
+		t.Fatalf("unexpected reservation: %+v", resp)
+	}
+	if got := unreserved(t, client, storage, shirt); got != 1 {
+		t.Fatalf("expected 1 shirt unreserved, got %d", got)
+	}
+}
@@ -1,39 +1,149 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net"
-	"net/rpc/jsonrpc"
+	"net/http"
 	"os"
 	"os/signal"
+	"storageapi/internal/api/tcprpc"
 	"storageapi/internal/config"
+	"storageapi/internal/health"
+	"sync"
+	"syscall"
+	"time"
 )
 
 func main() {
-	server := serve()
-	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", config.ListenerPort))
-	if err != nil {
-		log.Fatal(err)
+	config.Load()
+	// ошибка любого транспорта останавливает весь сервер
+	errC := make(chan error, 3)
+	// JSON-RPC 2.0 (/rpc), REST и /metrics добавляет serve, пробы отвечают
+	// уже пока сервер ждет бд при старте
+	checker := health.NewChecker(config.HealthCheckTimeout)
+	mux := http.NewServeMux()
+	mux.Handle("/healthz", checker.LivenessHandler())
+	mux.Handle("/readyz", checker.ReadinessHandler())
+	httpServer := &http.Server{Handler: mux}
+	if config.HTTPListenerPort != 0 {
+		httpListener, err := listen(config.HTTPListenerPort)
+		if err != nil {
+			log.Fatal(err)
+		}
+		go func() {
+			if err := httpServer.Serve(httpListener); !errors.Is(err, http.ErrServerClosed) {
+				errC <- fmt.Errorf("http: %w", err)
+			}
+		}()
 	}
 
-	sigIntC := make(chan os.Signal, 1)
-	signal.Notify(sigIntC, os.Interrupt)
+	app, err := serve(mux, checker)
+	if err != nil {
+		// пробы уже отвечают, закрываем их и выходим с ошибкой
+		httpServer.Close()
+		log.Fatalf("can't start server: %v", err)
+	}
+	// после serve открыты бд и трейсинг, поэтому ошибки старта
+	// проходят через обычную остановку
+	fail := func(err error) {
+		app.log.Errorw("can't start server", "error", err)
+		ctx, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout)
+		app.shutdown(ctx, httpServer)
+		cancel()
+		os.Exit(1)
+	}
+	if err := app.registerMetrics(); err != nil {
+		fail(err)
+	}
+	tcpListener, err := listen(config.ListenerPort)
+	if err != nil {
+		fail(err)
+	}
 	go func() {
-		for {
-			select {
-			case <-sigIntC:
-				listener.Close()
-				return
-			default:
-				conn, err := listener.Accept()
-				if err != nil {
-					log.Print(err)
-					continue
-				}
-				codec := jsonrpc.NewServerCodec(conn)
-				go server.ServeCodec(codec)
+		if err := app.tcpServer.Serve(tcpListener); !errors.Is(err, tcprpc.ErrServerClosed) {
+			errC <- fmt.Errorf("tcp: %w", err)
+		}
+	}()
+	if config.GRPCListenerPort != 0 {
+		grpcListener, err := listen(config.GRPCListenerPort)
+		if err != nil {
+			fail(err)
+		}
+		go func() {
+			if err := app.grpcServer.Serve(grpcListener); err != nil {
+				errC <- fmt.Errorf("grpc: %w", err)
 			}
+		}()
+	}
+	checker.MarkStarted()
+
+	sigC := make(chan os.Signal, 1)
+	signal.Notify(sigC, syscall.SIGINT, syscall.SIGTERM)
+	select {
+	case sig := <-sigC:
+		app.log.Infow("shutting down", "signal", sig.String())
+	case err := <-errC:
+		app.log.Errorw("listener stopped, shutting down", "error", err)
+	}
+	signal.Stop(sigC)
+
+	// /readyz отвечает 503, балансировщик успевает убрать сервер до закрытия listener'ов
+	app.health.Drain()
+	time.Sleep(config.ShutdownDelay)
+	ctx, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout)
+	defer cancel()
+	app.shutdown(ctx, httpServer)
+}
+
+func listen(port int) (net.Listener, error) {
+	return net.Listen("tcp", fmt.Sprintf(":%d", port))
+}
+
+// shutdown перестает принимать соединения на всех транспортах и ждет
+// начатые запросы не дольше ctx, затем закрывает пул соединений с бд,
+// отправляет оставшиеся спаны и сбрасывает буфер логгера
+func (app *application) shutdown(ctx context.Context, httpServer *http.Server) {
+	var wg sync.WaitGroup
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		if err := app.tcpServer.Shutdown(ctx); err != nil {
+			app.log.Warnw("tcp requests were not drained", "error", err)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		if err := httpServer.Shutdown(ctx); err != nil {
+			app.log.Warnw("http requests were not drained", "error", err)
+			httpServer.Close()
 		}
 	}()
+	go func() {
+		defer wg.Done()
+		stopped := make(chan struct{})
+		go func() {
+			app.grpcServer.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-ctx.Done():
+			app.log.Warnw("grpc requests were not drained", "error", ctx.Err())
+			app.grpcServer.Stop()
+		}
+	}()
+	wg.Wait()
+
+	app.stopReaper()
+	// Close ждет возврата всех взятых из пула соединений
+	closeDB(app.db, app.migrateDB)
+	if err := app.stopTracing(ctx); err != nil {
+		app.log.Warnw("spans were not exported", "error", err)
+	}
+	app.log.Info("server stopped")
+	// Sync для stderr может вернуть ошибку, ее некуда писать
+	_ = app.logger.Sync()
 }
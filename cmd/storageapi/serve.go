@@ -1,59 +1,253 @@
 package main
 
 import (
-	"log"
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
 	"net/rpc"
 	"storageapi/internal/api"
+	"storageapi/internal/api/grpcapi"
+	"storageapi/internal/api/httprpc"
 	"storageapi/internal/api/reservation"
+	"storageapi/internal/api/rest"
 	"storageapi/internal/api/storage"
+	"storageapi/internal/api/tcprpc"
+	"storageapi/internal/api/transfer"
 	"storageapi/internal/config"
 	"storageapi/internal/database"
+	"storageapi/internal/entity"
+	"storageapi/internal/health"
+	"storageapi/internal/metrics"
 	"storageapi/internal/repository"
+	"storageapi/internal/repository/memory"
+	"storageapi/internal/tracing"
+	"storageapi/internal/usecase/idempotency"
 	reservationService "storageapi/internal/usecase/reservation"
 	storageService "storageapi/internal/usecase/storage"
+	transferService "storageapi/internal/usecase/transfer"
+	"storageapi/pkg/algo"
+	"sync"
+	"time"
 
+	"github.com/jackc/pgx"
 	_ "github.com/lib/pq"
 	"github.com/pressly/goose"
+	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc"
 )
 
-func serve() *rpc.Server {
-	db, err := database.NewDBWithPgx(config.DatabaseURL)
+type application struct {
+	rpcServer *rpc.Server
+	// JSON-RPC поверх TCP на LISTENER_PORT
+	tcpServer *tcprpc.Server
+	// пробы /healthz и /readyz на HTTP_LISTENER_PORT
+	health *health.Checker
+	// storageapi.v1 на GRPC_LISTENER_PORT
+	grpcServer *grpc.Server
+	// метрики пула соединений и остатков, регистрирует registerMetrics
+	collectors []prometheus.Collector
+	// nil для REPOSITORY_BACKEND=memory
+	db *database.DB
+	// database/sql для goose, проверка версии миграций
+	migrateDB *sql.DB
+	logger    *zap.Logger
+	log       *zap.SugaredLogger
+	// останавливает очистку истекших резервов и ключей идемпотентности и ждет ее завершения
+	stopReaper func()
+	// отправляет оставшиеся спаны экспортеру
+	stopTracing func(context.Context) error
+}
+
+// serve открывает хранилище и создает транспорты. HTTP обработчики регистрируются
+// в mux, на котором уже отвечают пробы checker. при ошибке открытые ресурсы
+// закрываются, а ошибка возвращается в main
+func serve(mux *http.ServeMux, checker *health.Checker) (*application, error) {
+	logger, err := zap.NewDevelopment(zap.AddStacktrace(zapcore.FatalLevel))
 	if err != nil {
-		log.Fatal(err)
+		return nil, fmt.Errorf("can't initialize zap logger: %w", err)
 	}
-	migrateDB, err := goose.OpenDBWithDriver(config.MigrationDialect, config.DatabaseURL)
-	if err != nil {
-		log.Fatal(err)
+	sugar := logger.Sugar()
+
+	reservationConf := reservationService.ServiceConf{
+		DefaultStrategy: config.DefaultAllocationStrategy,
+		StoragePriority: algo.Map(config.AllocationStoragePriority, func(id uint, _ int) entity.PK {
+			return entity.PK(id)
+		}),
 	}
-	if err := goose.Run("up", migrateDB, config.FixturesPath); err != nil {
-		log.Fatal(err)
+	if _, err := reservationService.NewAllocator(reservationConf.DefaultStrategy, reservationConf.StoragePriority); err != nil {
+		return nil, err
 	}
-	logger, err := zap.NewDevelopment(zap.AddStacktrace(zapcore.FatalLevel))
+	stopTracing, err := tracing.Setup(context.Background(), config.TracesExporter, config.TracesFile)
 	if err != nil {
-		log.Fatalf("Can't initialize zap logger: %v", err)
+		return nil, err
+	}
+	repo, db, migrateDB, err := openRepository(sugar, checker)
+	if err != nil {
+		_ = stopTracing(context.Background())
+		return nil, err
 	}
-	sugar := logger.Sugar()
-
-	repo := repository.NewRepository(db, sugar)
 
-	storageService := storageService.NewService(repo, sugar)
-	reservationService := reservationService.NewService(repo, sugar)
+	reservationSvc := reservationService.NewService(repo, sugar, reservationConf)
+	// резервы выключаемого склада переносит сервис резервов
+	storageService := storageService.NewService(repo, sugar, reservationSvc)
+	reaper := reservationService.NewReaper(
+		reservationSvc,
+		sugar,
+		config.ReservationReapInterval,
+		config.ReservationReapBatchSize,
+	)
+	keyReaper := idempotency.NewReaper(
+		repo,
+		sugar,
+		config.IdempotencyKeyReapInterval,
+		config.IdempotencyKeyTTL,
+		config.IdempotencyKeyReapBatchSize,
+	)
 
 	apiConf := api.ApiConf{
 		RequestHandleTimeout: config.RequestHandleTimeout,
 	}
 	storageApi := storage.NewAPI(sugar, storageService, apiConf)
-	reservationApi := reservation.NewAPI(sugar, reservationService, apiConf)
-	server := newServer(storageApi, reservationApi)
-	return server
+	reservationApi := reservation.NewAPI(sugar, reservationSvc, apiConf)
+	transferApi := transfer.NewAPI(sugar, transferService.NewService(repo, sugar), apiConf)
+	server, err := newServer(map[string]interface{}{
+		"Storage":     storageApi,
+		"Reservation": reservationApi,
+		"Transfer":    transferApi,
+	})
+	if err != nil {
+		closeDB(db, migrateDB)
+		_ = stopTracing(context.Background())
+		return nil, err
+	}
+
+	mux.Handle("/rpc", httprpc.NewHandler(server, sugar))
+	restApi := rest.NewAPI(sugar, storageService, reservationSvc, apiConf)
+	for _, prefix := range []string{"/storages", "/storages/", "/reservations", "/reservations/"} {
+		mux.Handle(prefix, restApi)
+	}
+	mux.Handle("/metrics", metrics.Handler())
+	collectors := []prometheus.Collector{metrics.NewStockCollector(repo, config.RequestHandleTimeout)}
+	if db != nil {
+		collectors = append(collectors, metrics.NewDBCollector(db))
+	}
+
+	// очистка запускается последней, после нее serve уже не возвращает ошибок
+	reaperCtx, cancelReaper := context.WithCancel(context.Background())
+	var reapers sync.WaitGroup
+	for _, run := range []func(context.Context){reaper.Run, keyReaper.Run} {
+		run := run
+		reapers.Add(1)
+		go func() {
+			defer reapers.Done()
+			run(reaperCtx)
+		}()
+	}
+	return &application{
+		rpcServer:  server,
+		tcpServer:  tcprpc.NewServer(server, sugar),
+		health:     checker,
+		grpcServer: grpcapi.NewServer(sugar, storageService, reservationSvc, apiConf),
+		collectors: collectors,
+		db:         db,
+		migrateDB:  migrateDB,
+		logger:     logger,
+		log:        sugar,
+		stopReaper: func() {
+			cancelReaper()
+			reapers.Wait()
+		},
+		stopTracing: stopTracing,
+	}, nil
+}
+
+// registerMetrics добавляет метрики приложения в общий реестр /metrics.
+// реестр один на процесс, поэтому serve их не регистрирует: тесты запускают
+// несколько серверов в одном процессе
+func (app *application) registerMetrics() error {
+	for _, c := range app.collectors {
+		if err := metrics.Register(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// openRepository создает хранилище по REPOSITORY_BACKEND. для postgres
+// подключается к бд, накатывает миграции и добавляет их проверки в checker,
+// хранилищу в памяти бд не нужна, db и migrateDB тогда nil
+func openRepository(log *zap.SugaredLogger, checker *health.Checker) (repository.IRepository, *database.DB, *sql.DB, error) {
+	switch config.RepositoryBackend {
+	case config.RepositoryPostgres:
+	case config.RepositoryMemory:
+		log.Warn("using in-memory repository, data is lost on shutdown")
+		return memory.NewRepository(), nil, nil, nil
+	default:
+		return nil, nil, nil, fmt.Errorf("unknown repository backend %q", config.RepositoryBackend)
+	}
+
+	db, err := connectDB(log)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	migrateDB, err := goose.OpenDBWithDriver(config.MigrationDialect, config.DatabaseURL)
+	if err != nil {
+		db.Close()
+		return nil, nil, nil, err
+	}
+	if err := goose.Run("up", migrateDB, config.FixturesPath); err != nil {
+		closeDB(db, migrateDB)
+		return nil, nil, nil, fmt.Errorf("migrations: %w", err)
+	}
+	migrationsCheck, err := health.Migrations(migrateDB, config.FixturesPath)
+	if err != nil {
+		closeDB(db, migrateDB)
+		return nil, nil, nil, fmt.Errorf("migrations: %w", err)
+	}
+	checker.Add("database", db.Ping)
+	checker.Add("migrations", migrationsCheck)
+	return repository.NewRepository(db, log), db, migrateDB, nil
+}
+
+// закрывает соединения openRepository, для memory оба nil
+func closeDB(db *database.DB, migrateDB *sql.DB) {
+	if db != nil {
+		db.Close()
+		migrateDB.Close()
+	}
+}
+
+// pgx открывает соединение при создании пула, поэтому при старте пул
+// создается заново с растущей паузой, пока бд не ответит
+func connectDB(log *zap.SugaredLogger) (*database.DB, error) {
+	// неверный DATABASE_URL повтор не исправит
+	if _, err := pgx.ParseURI(config.DatabaseURL); err != nil {
+		return nil, err
+	}
+	backoff := config.DatabaseConnectMinBackoff
+	for attempt := 1; ; attempt++ {
+		db, err := database.NewDBWithPgx(config.DatabaseURL)
+		if err == nil {
+			return db, nil
+		}
+		log.Warnw("database is unavailable, retrying", "attempt", attempt, "backoff", backoff, "error", err)
+		time.Sleep(backoff)
+		if backoff *= 2; backoff > config.DatabaseConnectMaxBackoff {
+			backoff = config.DatabaseConnectMaxBackoff
+		}
+	}
 }
 
-func newServer(api ...interface{}) *rpc.Server {
+// все api называются API, поэтому регистрируем под явными именами
+func newServer(apis map[string]interface{}) (*rpc.Server, error) {
 	server := rpc.NewServer()
-	for _, a := range api {
-		server.Register(a)
+	for name, a := range apis {
+		if err := server.RegisterName(name, a); err != nil {
+			return nil, err
+		}
 	}
-	return server
+	return server, nil
 }
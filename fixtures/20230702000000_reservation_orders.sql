@@ -0,0 +1,58 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- заказ клиента, product_reservations остается агрегатом по всем активным заказам
+CREATE TABLE reservation_orders (
+    id BIGSERIAL PRIMARY KEY,
+    status VARCHAR(20) NOT NULL DEFAULT 'active',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NULL -- NULL - бессрочный резерв
+);
+
+-- по индексу фоновый процесс ищет истекшие активные заказы
+CREATE INDEX reservation_orders_expires_at_idx ON reservation_orders(expires_at) WHERE status = 'active';
+
+CREATE TABLE reservation_order_items (
+    id BIGSERIAL PRIMARY KEY,
+    reservation_id BIGINT NOT NULL,
+    storage_id BIGINT NOT NULL,
+    product_id BIGINT NOT NULL,
+    amount INT NOT NULL,
+
+    UNIQUE (reservation_id, storage_id, product_id),
+
+    FOREIGN KEY (reservation_id) REFERENCES reservation_orders(id) ON DELETE CASCADE,
+    FOREIGN KEY (storage_id) REFERENCES storages(id) ON DELETE CASCADE,
+    FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE
+);
+
+-- резервы, созданные до заказов, переносятся в заказы, иначе их количество
+-- в product_reservations нельзя снять или найти по id.
+-- каждая строка product_reservations - бессрочный заказ с одной позицией
+CREATE TEMP TABLE legacy_orders AS
+SELECT
+    nextval(pg_get_serial_sequence('reservation_orders', 'id')) AS order_id,
+    storage_id,
+    product_id,
+    amount
+FROM product_reservations
+WHERE amount > 0;
+
+INSERT INTO reservation_orders (id, status)
+SELECT order_id, 'active' FROM legacy_orders;
+
+INSERT INTO reservation_order_items (reservation_id, storage_id, product_id, amount)
+SELECT order_id, storage_id, product_id, amount FROM legacy_orders;
+
+DROP TABLE legacy_orders;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS reservation_order_items;
+DROP INDEX IF EXISTS reservation_orders_expires_at_idx;
+DROP TABLE IF EXISTS reservation_orders;
+
+-- +goose StatementEnd
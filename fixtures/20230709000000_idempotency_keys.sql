@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- результаты мутирующих запросов для повторов с тем же ключом идемпотентности
+CREATE TABLE idempotency_keys (
+    method VARCHAR(100) NOT NULL,
+    key VARCHAR(255) NOT NULL,
+    request_hash VARCHAR(64) NOT NULL, -- sha256 тела запроса
+    response JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (method, key)
+);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS idempotency_keys;
+
+-- +goose StatementEnd
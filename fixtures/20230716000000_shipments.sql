@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- отгрузка подтвержденного заказа, списанные со складов остатки
+CREATE TABLE shipments (
+    id BIGSERIAL PRIMARY KEY,
+    reservation_id BIGINT NOT NULL UNIQUE, -- заказ отгружается один раз
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    FOREIGN KEY (reservation_id) REFERENCES reservation_orders(id) ON DELETE CASCADE
+);
+
+CREATE TABLE shipment_items (
+    id BIGSERIAL PRIMARY KEY,
+    shipment_id BIGINT NOT NULL,
+    storage_id BIGINT NOT NULL,
+    product_id BIGINT NOT NULL,
+    amount INT NOT NULL,
+
+    FOREIGN KEY (shipment_id) REFERENCES shipments(id) ON DELETE CASCADE,
+    FOREIGN KEY (storage_id) REFERENCES storages(id) ON DELETE CASCADE,
+    FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE
+);
+
+CREATE INDEX shipment_items_shipment_id_idx ON shipment_items(shipment_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS shipment_items_shipment_id_idx;
+DROP TABLE IF EXISTS shipment_items;
+DROP TABLE IF EXISTS shipments;
+
+-- +goose StatementEnd
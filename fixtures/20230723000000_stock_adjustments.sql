@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- журнал изменений остатков: поступления, списания, пересчеты
+CREATE TABLE stock_adjustments (
+    id BIGSERIAL PRIMARY KEY,
+    storage_id BIGINT NOT NULL,
+    product_id BIGINT NOT NULL,
+    delta INT NOT NULL, -- отрицательное при списании
+    reason VARCHAR(20) NOT NULL,
+    comment TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    FOREIGN KEY (storage_id) REFERENCES storages(id) ON DELETE CASCADE,
+    FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE
+);
+
+CREATE INDEX stock_adjustments_storage_product_idx ON stock_adjustments(storage_id, product_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS stock_adjustments_storage_product_idx;
+DROP TABLE IF EXISTS stock_adjustments;
+
+-- +goose StatementEnd
@@ -0,0 +1,40 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- перемещение товаров между складами, пока status = 'in_transit'
+-- товары списаны со склада-источника и еще не поступили на склад-получатель
+CREATE TABLE transfers (
+    id BIGSERIAL PRIMARY KEY,
+    source_storage_id BIGINT NOT NULL,
+    destination_storage_id BIGINT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'in_transit',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CHECK (source_storage_id <> destination_storage_id),
+
+    FOREIGN KEY (source_storage_id) REFERENCES storages(id) ON DELETE CASCADE,
+    FOREIGN KEY (destination_storage_id) REFERENCES storages(id) ON DELETE CASCADE
+);
+
+CREATE TABLE transfer_items (
+    id BIGSERIAL PRIMARY KEY,
+    transfer_id BIGINT NOT NULL,
+    product_id BIGINT NOT NULL,
+    amount INT NOT NULL,
+
+    UNIQUE (transfer_id, product_id),
+
+    FOREIGN KEY (transfer_id) REFERENCES transfers(id) ON DELETE CASCADE,
+    FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE
+);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS transfer_items;
+DROP TABLE IF EXISTS transfers;
+
+-- +goose StatementEnd
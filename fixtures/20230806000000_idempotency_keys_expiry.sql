@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- по индексу фоновый процесс удаляет ключи старше IDEMPOTENCY_KEY_TTL_MS
+CREATE INDEX idempotency_keys_created_at_idx ON idempotency_keys(created_at);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idempotency_keys_created_at_idx;
+
+-- +goose StatementEnd
@@ -0,0 +1,44 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/rpc"
+	"storageapi/pkg/errs"
+
+	"github.com/jackc/pgx"
+)
+
+// Error приводит ошибку use case к *errs.Error, по коду транспорты выбирают
+// свой статус. Ошибки с кодом возвращаются как есть, нарушение уникальности
+// в бд - conflict, остальное - internal
+func Error(err error) *errs.Error {
+	if err == nil {
+		return nil
+	}
+	var (
+		e     *errs.Error
+		pgErr pgx.PgError
+	)
+	switch {
+	case errors.As(err, &e):
+		return e
+	case errors.Is(err, context.DeadlineExceeded):
+		return errs.Wrap(errs.CodeInternal, err, "request timed out")
+	case errors.Is(err, context.Canceled):
+		return errs.Wrap(errs.CodeInternal, err, "request canceled")
+	case errors.As(err, &pgErr) && pgErr.Code == "23505": // unique_violation
+		return errs.Wrap(errs.CodeConflict, err, "already exists").
+			WithDetail("constraint", pgErr.ConstraintName)
+	}
+	return errs.From(err)
+}
+
+// RPCError - ошибка для net/rpc: он передает клиенту только текст,
+// поэтому текстом служит JSON *errs.Error (клиент разбирает его errs.Unmarshal)
+func RPCError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return rpc.ServerError(errs.Marshal(Error(err)))
+}
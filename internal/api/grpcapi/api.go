@@ -0,0 +1,116 @@
+package grpcapi
+
+import (
+	"context"
+	"storageapi/internal/api"
+	"storageapi/internal/api/grpcapi/pb"
+	"storageapi/internal/api/reservation"
+	"storageapi/internal/api/storage"
+	"storageapi/internal/entity"
+	reservationUC "storageapi/internal/usecase/reservation"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// NewServer создает grpc сервер с сервисами складов и резервов
+func NewServer(log *zap.SugaredLogger, s storage.UseCase, r reservation.UseCase, conf api.ApiConf) *grpc.Server {
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(traceCall, observe))
+	pb.RegisterStorageServiceServer(server, NewStorageAPI(log, s, conf))
+	pb.RegisterReservationServiceServer(server, NewReservationAPI(log, r, conf))
+	return server
+}
+
+type StorageAPI struct {
+	pb.UnimplementedStorageServiceServer
+
+	log            *zap.SugaredLogger
+	service        storage.UseCase
+	requestTimeout time.Duration
+}
+
+var _ pb.StorageServiceServer = (*StorageAPI)(nil)
+
+func NewStorageAPI(log *zap.SugaredLogger, s storage.UseCase, conf api.ApiConf) *StorageAPI {
+	return &StorageAPI{
+		log:            log,
+		service:        s,
+		requestTimeout: conf.RequestHandleTimeout,
+	}
+}
+
+func (a *StorageAPI) DefineStorageSchema(ctx context.Context, request *pb.StorageSchemaReq) (*pb.StorageSchemaResp, error) {
+	req := storageSchemaReqFromPb(request)
+	if err := req.Validate(); err != nil {
+		return nil, statusError(err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, a.requestTimeout)
+	defer cancel()
+	resp, err := a.service.DefineStorageSchema(ctx, req)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return storageSchemaRespToPb(resp), nil
+}
+
+func (a *StorageAPI) GetUnreservedStorage(ctx context.Context, request *pb.GetUnreservedStorageReq) (*pb.StorageSchemaRespItem, error) {
+	req := storage.GetUnreservedStorageReq{StorageID: uint(request.GetStorageId())}
+	if err := req.Validate(); err != nil {
+		return nil, statusError(err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, a.requestTimeout)
+	defer cancel()
+	resp, err := a.service.GetUnreservedStorage(ctx, entity.PK(req.StorageID))
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return storageSchemaRespItemToPb(resp), nil
+}
+
+type ReservationAPI struct {
+	pb.UnimplementedReservationServiceServer
+
+	log            *zap.SugaredLogger
+	service        reservation.UseCase
+	requestTimeout time.Duration
+}
+
+var _ pb.ReservationServiceServer = (*ReservationAPI)(nil)
+
+func NewReservationAPI(log *zap.SugaredLogger, r reservation.UseCase, conf api.ApiConf) *ReservationAPI {
+	return &ReservationAPI{
+		log:            log,
+		service:        r,
+		requestTimeout: conf.RequestHandleTimeout,
+	}
+}
+
+func (a *ReservationAPI) CreateReservation(ctx context.Context, request *pb.ReserveProductsReq) (*pb.ReservationResp, error) {
+	req := reserveProductsReqFromPb(request)
+	if err := req.Validate(); err != nil {
+		return nil, statusError(err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, a.requestTimeout)
+	defer cancel()
+	resp, err := a.service.ReserveProducts(ctx, req)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return reservationRespToPb(resp), nil
+}
+
+func (a *ReservationAPI) UndoReservation(ctx context.Context, request *pb.UndoReservationReq) (*emptypb.Empty, error) {
+	req := reservationUC.UndoReservationReq{ID: uint(request.GetId())}
+	if err := req.Validate(); err != nil {
+		return nil, statusError(err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, a.requestTimeout)
+	defer cancel()
+	err := a.service.UndoReserve(ctx, req)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
@@ -0,0 +1,168 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"net"
+	"storageapi/internal/api"
+	"storageapi/internal/api/grpcapi/pb"
+	"storageapi/internal/entity"
+	"storageapi/internal/usecase/reservation"
+	"storageapi/internal/usecase/storage"
+	"storageapi/pkg/errs"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type fakeStorage struct{}
+
+func (fakeStorage) DefineStorageSchema(ctx context.Context, req storage.StorageSchemaReq) (storage.StorageSchemaResp, error) {
+	return storage.StorageSchemaResp{{
+		ID:          1,
+		IsAvailable: req.Storages[0].IsAvailable,
+		Products: []storage.StorageSchemaRespProduct{{
+			ID:     1,
+			Vendor: req.Storages[0].Products[0].Vendor,
+			Amount: req.Storages[0].Products[0].Amount,
+		}},
+	}}, nil
+}
+
+func (fakeStorage) GetStorageSchema(ctx context.Context, req storage.GetStorageSchemaReq) (storage.GetStorageSchemaResp, error) {
+	return nil, errors.New("not used")
+}
+
+func (fakeStorage) GetUnreservedStorage(ctx context.Context, storageID entity.PK) (*storage.StorageSchemaRespItem, error) {
+	return nil, errs.New(errs.CodeNotFound, "cannot find storage by id").WithDetail("storage_id", storageID)
+}
+
+func (fakeStorage) ReceiveStock(ctx context.Context, req storage.ReceiveStockReq) (*storage.StockResp, error) {
+	return nil, errors.New("not used")
+}
+
+func (fakeStorage) AdjustStock(ctx context.Context, req storage.AdjustStockReq) (*storage.StockResp, error) {
+	return nil, errors.New("not used")
+}
+
+func (fakeStorage) SetStorageAvailability(ctx context.Context, req storage.SetStorageAvailabilityReq) (*storage.SetStorageAvailabilityResp, error) {
+	return nil, errors.New("not used")
+}
+
+type fakeReservation struct{}
+
+func (fakeReservation) ReserveProducts(ctx context.Context, req reservation.ReserveProductsReq) (*reservation.ReservationResp, error) {
+	return nil, errs.New(errs.CodeInsufficientStock, "cannot reserve more than 0 for product with id 1 (tried to reserve 1)").
+		WithDetail("product_id", 1).
+		WithDetail("available", 0)
+}
+
+func (fakeReservation) GetReservation(ctx context.Context, req reservation.GetReservationReq) (*reservation.ReservationResp, error) {
+	return nil, errors.New("not used")
+}
+
+func (fakeReservation) UndoReserve(ctx context.Context, req reservation.UndoReservationReq) error {
+	return nil
+}
+
+func (fakeReservation) ConfirmReservation(ctx context.Context, req reservation.ConfirmReservationReq) (*reservation.ShipmentResp, error) {
+	return nil, errors.New("not used")
+}
+
+func dial(t *testing.T) *grpc.ClientConn {
+	listener := bufconn.Listen(1 << 20)
+	server := NewServer(zap.NewNop().Sugar(), fakeStorage{}, fakeReservation{}, api.ApiConf{RequestHandleTimeout: time.Second})
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.Dial(
+		"bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestServer(t *testing.T) {
+	conn := dial(t)
+	ctx := context.Background()
+	storages := pb.NewStorageServiceClient(conn)
+	reservations := pb.NewReservationServiceClient(conn)
+
+	resp, err := storages.DefineStorageSchema(ctx, &pb.StorageSchemaReq{
+		Storages: []*pb.StorageSchemaReqItem{{
+			IsAvailable: true,
+			Products:    []*pb.StorageSchemaReqProduct{{Vendor: "v-1", Amount: 5}},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Storages) != 1 || !resp.Storages[0].IsAvailable || resp.Storages[0].Products[0].Amount != 5 {
+		t.Errorf("unexpected response %v", resp)
+	}
+
+	cases := []struct {
+		name string
+		call func() error
+		code codes.Code
+	}{
+		{"validation", func() error {
+			_, err := storages.DefineStorageSchema(ctx, &pb.StorageSchemaReq{
+				Storages: []*pb.StorageSchemaReqItem{{Products: []*pb.StorageSchemaReqProduct{{Vendor: "v-1"}}}},
+			})
+			return err
+		}, codes.InvalidArgument},
+		{"not found", func() error {
+			_, err := storages.GetUnreservedStorage(ctx, &pb.GetUnreservedStorageReq{StorageId: 1})
+			return err
+		}, codes.NotFound},
+		{"insufficient stock", func() error {
+			_, err := reservations.CreateReservation(ctx, &pb.ReserveProductsReq{
+				Products: []*pb.ReserveProductsReqItem{{Id: 1, Amount: 1}},
+			})
+			return err
+		}, codes.FailedPrecondition},
+		{"undo", func() error {
+			_, err := reservations.UndoReservation(ctx, &pb.UndoReservationReq{Id: 1})
+			return err
+		}, codes.OK},
+	}
+	for _, c := range cases {
+		if code := status.Code(c.call()); code != c.code {
+			t.Errorf("%s: code %v, want %v", c.name, code, c.code)
+		}
+	}
+}
+
+func TestErrorInfo(t *testing.T) {
+	conn := dial(t)
+	_, err := pb.NewReservationServiceClient(conn).CreateReservation(context.Background(), &pb.ReserveProductsReq{
+		Products: []*pb.ReserveProductsReqItem{{Id: 1, Amount: 1}},
+	})
+	var info *errdetails.ErrorInfo
+	for _, d := range status.Convert(err).Details() {
+		if i, ok := d.(*errdetails.ErrorInfo); ok {
+			info = i
+		}
+	}
+	if info == nil {
+		t.Fatalf("no ErrorInfo in %v", err)
+	}
+	if info.Reason != string(errs.CodeInsufficientStock) || info.Metadata["product_id"] != "1" || info.Metadata["available"] != "0" {
+		t.Fatalf("unexpected ErrorInfo %v", info)
+	}
+}
@@ -0,0 +1,91 @@
+package grpcapi
+
+import (
+	"storageapi/internal/api/grpcapi/pb"
+	"storageapi/internal/usecase/reservation"
+	"storageapi/internal/usecase/storage"
+	"storageapi/pkg/algo"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// преобразования между сообщениями protobuf и типами use case
+
+func storageSchemaReqFromPb(req *pb.StorageSchemaReq) storage.StorageSchemaReq {
+	return storage.StorageSchemaReq{
+		IdempotencyKey: req.GetIdempotencyKey(),
+		Storages: algo.Map(req.GetStorages(), func(s *pb.StorageSchemaReqItem, _ int) storage.StorageSchemaReqItem {
+			return storage.StorageSchemaReqItem{
+				IsAvailable: s.GetIsAvailable(),
+				Products: algo.Map(s.GetProducts(), func(p *pb.StorageSchemaReqProduct, _ int) storage.StorageSchemaReqProduct {
+					return storage.StorageSchemaReqProduct{
+						Vendor: p.GetVendor(),
+						Name:   p.GetName(),
+						Size:   p.GetSize(),
+						Amount: uint(p.GetAmount()),
+					}
+				}),
+			}
+		}),
+	}
+}
+
+func storageSchemaRespToPb(resp storage.StorageSchemaResp) *pb.StorageSchemaResp {
+	return &pb.StorageSchemaResp{
+		Storages: algo.Map(resp, func(s storage.StorageSchemaRespItem, _ int) *pb.StorageSchemaRespItem {
+			return storageSchemaRespItemToPb(&s)
+		}),
+	}
+}
+
+func storageSchemaRespItemToPb(s *storage.StorageSchemaRespItem) *pb.StorageSchemaRespItem {
+	return &pb.StorageSchemaRespItem{
+		Id:          uint64(s.ID),
+		IsAvailable: s.IsAvailable,
+		Products: algo.Map(s.Products, func(p storage.StorageSchemaRespProduct, _ int) *pb.StorageSchemaRespProduct {
+			return &pb.StorageSchemaRespProduct{
+				Id:     uint64(p.ID),
+				Name:   p.Name,
+				Vendor: p.Vendor,
+				Size:   p.Size,
+				Amount: uint64(p.Amount),
+			}
+		}),
+	}
+}
+
+func reserveProductsReqFromPb(req *pb.ReserveProductsReq) reservation.ReserveProductsReq {
+	return reservation.ReserveProductsReq{
+		IdempotencyKey: req.GetIdempotencyKey(),
+		Products: algo.Map(req.GetProducts(), func(p *pb.ReserveProductsReqItem, _ int) reservation.ReserveProductsReqItem {
+			return reservation.ReserveProductsReqItem{
+				ID:     uint(p.GetId()),
+				Amount: uint(p.GetAmount()),
+			}
+		}),
+		TTLSeconds: uint(req.GetTtlSeconds()),
+		Strategy:   req.GetStrategy(),
+		StoragePriority: algo.Map(req.GetStoragePriority(), func(id uint64, _ int) uint {
+			return uint(id)
+		}),
+	}
+}
+
+func reservationRespToPb(resp *reservation.ReservationResp) *pb.ReservationResp {
+	result := &pb.ReservationResp{
+		Id:        uint64(resp.ID),
+		Status:    resp.Status,
+		CreatedAt: timestamppb.New(resp.CreatedAt),
+		Items: algo.Map(resp.Items, func(i reservation.ReservationRespItem, _ int) *pb.ReservationRespItem {
+			return &pb.ReservationRespItem{
+				StorageId: uint64(i.StorageID),
+				ProductId: uint64(i.ProductID),
+				Amount:    uint64(i.Amount),
+			}
+		}),
+	}
+	if resp.ExpiresAt != nil {
+		result.ExpiresAt = timestamppb.New(*resp.ExpiresAt)
+	}
+	return result
+}
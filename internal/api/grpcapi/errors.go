@@ -0,0 +1,60 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"storageapi/internal/api"
+	"storageapi/pkg/errs"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// домен в ErrorInfo, Reason - код errs
+const errorDomain = "storageapi"
+
+func statusError(err error) error {
+	e := api.Error(err)
+	code := codes.Internal
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		code = codes.DeadlineExceeded
+	case errors.Is(err, context.Canceled):
+		code = codes.Canceled
+	case e.Code == errs.CodeValidation:
+		code = codes.InvalidArgument
+	case e.Code == errs.CodeNotFound:
+		code = codes.NotFound
+	case e.Code == errs.CodeInsufficientStock, e.Code == errs.CodeUnavailable:
+		code = codes.FailedPrecondition
+	case e.Code == errs.CodeConflict:
+		code = codes.Aborted
+	}
+	st := status.New(code, err.Error())
+	info := &errdetails.ErrorInfo{
+		Reason:   string(e.Code),
+		Domain:   errorDomain,
+		Metadata: make(map[string]string, len(e.Details)),
+	}
+	for k, v := range e.Details {
+		info.Metadata[k] = fmt.Sprint(v)
+	}
+	if withDetails, detailsErr := st.WithDetails(info); detailsErr == nil {
+		st = withDetails
+	}
+	if len(e.Fields) != 0 {
+		badRequest := &errdetails.BadRequest{}
+		for _, f := range e.Fields {
+			badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       f.Path,
+				Description: f.Message,
+			})
+		}
+		if withDetails, detailsErr := st.WithDetails(badRequest); detailsErr == nil {
+			st = withDetails
+		}
+	}
+	return st.Err()
+}
@@ -0,0 +1,4 @@
+package grpcapi
+
+// код в pb генерируется из api/proto (нужны buf, protoc-gen-go и protoc-gen-go-grpc)
+//go:generate sh -c "cd ../../../api/proto && buf generate"
@@ -0,0 +1,33 @@
+package grpcapi
+
+import (
+	"context"
+	"storageapi/internal/metrics"
+	"storageapi/pkg/errs"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// observe учитывает каждый unary вызов в метриках rpc
+func observe(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	metrics.ObserveRPC(metrics.TransportGRPC, info.FullMethod, errorCode(err), time.Since(start))
+	return resp, err
+}
+
+// код errs берется из ErrorInfo, который добавляет statusError
+func errorCode(err error) string {
+	if err == nil {
+		return metrics.CodeOK
+	}
+	for _, d := range status.Convert(err).Details() {
+		if info, ok := d.(*errdetails.ErrorInfo); ok && info.Domain == errorDomain {
+			return info.Reason
+		}
+	}
+	return string(errs.CodeInternal)
+}
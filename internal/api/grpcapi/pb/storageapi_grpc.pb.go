@@ -0,0 +1,274 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: storageapi/v1/storageapi.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	StorageService_DefineStorageSchema_FullMethodName  = "/storageapi.v1.StorageService/DefineStorageSchema"
+	StorageService_GetUnreservedStorage_FullMethodName = "/storageapi.v1.StorageService/GetUnreservedStorage"
+)
+
+// StorageServiceClient is the client API for StorageService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type StorageServiceClient interface {
+	DefineStorageSchema(ctx context.Context, in *StorageSchemaReq, opts ...grpc.CallOption) (*StorageSchemaResp, error)
+	GetUnreservedStorage(ctx context.Context, in *GetUnreservedStorageReq, opts ...grpc.CallOption) (*StorageSchemaRespItem, error)
+}
+
+type storageServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewStorageServiceClient(cc grpc.ClientConnInterface) StorageServiceClient {
+	return &storageServiceClient{cc}
+}
+
+func (c *storageServiceClient) DefineStorageSchema(ctx context.Context, in *StorageSchemaReq, opts ...grpc.CallOption) (*StorageSchemaResp, error) {
+	out := new(StorageSchemaResp)
+	err := c.cc.Invoke(ctx, StorageService_DefineStorageSchema_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageServiceClient) GetUnreservedStorage(ctx context.Context, in *GetUnreservedStorageReq, opts ...grpc.CallOption) (*StorageSchemaRespItem, error) {
+	out := new(StorageSchemaRespItem)
+	err := c.cc.Invoke(ctx, StorageService_GetUnreservedStorage_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// StorageServiceServer is the server API for StorageService service.
+// All implementations must embed UnimplementedStorageServiceServer
+// for forward compatibility
+type StorageServiceServer interface {
+	DefineStorageSchema(context.Context, *StorageSchemaReq) (*StorageSchemaResp, error)
+	GetUnreservedStorage(context.Context, *GetUnreservedStorageReq) (*StorageSchemaRespItem, error)
+	mustEmbedUnimplementedStorageServiceServer()
+}
+
+// UnimplementedStorageServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedStorageServiceServer struct {
+}
+
+func (UnimplementedStorageServiceServer) DefineStorageSchema(context.Context, *StorageSchemaReq) (*StorageSchemaResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DefineStorageSchema not implemented")
+}
+func (UnimplementedStorageServiceServer) GetUnreservedStorage(context.Context, *GetUnreservedStorageReq) (*StorageSchemaRespItem, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUnreservedStorage not implemented")
+}
+func (UnimplementedStorageServiceServer) mustEmbedUnimplementedStorageServiceServer() {}
+
+// UnsafeStorageServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to StorageServiceServer will
+// result in compilation errors.
+type UnsafeStorageServiceServer interface {
+	mustEmbedUnimplementedStorageServiceServer()
+}
+
+func RegisterStorageServiceServer(s grpc.ServiceRegistrar, srv StorageServiceServer) {
+	s.RegisterService(&StorageService_ServiceDesc, srv)
+}
+
+func _StorageService_DefineStorageSchema_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StorageSchemaReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageServiceServer).DefineStorageSchema(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StorageService_DefineStorageSchema_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageServiceServer).DefineStorageSchema(ctx, req.(*StorageSchemaReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StorageService_GetUnreservedStorage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetUnreservedStorageReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageServiceServer).GetUnreservedStorage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StorageService_GetUnreservedStorage_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageServiceServer).GetUnreservedStorage(ctx, req.(*GetUnreservedStorageReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// StorageService_ServiceDesc is the grpc.ServiceDesc for StorageService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var StorageService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "storageapi.v1.StorageService",
+	HandlerType: (*StorageServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "DefineStorageSchema",
+			Handler:    _StorageService_DefineStorageSchema_Handler,
+		},
+		{
+			MethodName: "GetUnreservedStorage",
+			Handler:    _StorageService_GetUnreservedStorage_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "storageapi/v1/storageapi.proto",
+}
+
+const (
+	ReservationService_CreateReservation_FullMethodName = "/storageapi.v1.ReservationService/CreateReservation"
+	ReservationService_UndoReservation_FullMethodName   = "/storageapi.v1.ReservationService/UndoReservation"
+)
+
+// ReservationServiceClient is the client API for ReservationService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ReservationServiceClient interface {
+	CreateReservation(ctx context.Context, in *ReserveProductsReq, opts ...grpc.CallOption) (*ReservationResp, error)
+	UndoReservation(ctx context.Context, in *UndoReservationReq, opts ...grpc.CallOption) (*emptypb.Empty, error)
+}
+
+type reservationServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewReservationServiceClient(cc grpc.ClientConnInterface) ReservationServiceClient {
+	return &reservationServiceClient{cc}
+}
+
+func (c *reservationServiceClient) CreateReservation(ctx context.Context, in *ReserveProductsReq, opts ...grpc.CallOption) (*ReservationResp, error) {
+	out := new(ReservationResp)
+	err := c.cc.Invoke(ctx, ReservationService_CreateReservation_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *reservationServiceClient) UndoReservation(ctx context.Context, in *UndoReservationReq, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, ReservationService_UndoReservation_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ReservationServiceServer is the server API for ReservationService service.
+// All implementations must embed UnimplementedReservationServiceServer
+// for forward compatibility
+type ReservationServiceServer interface {
+	CreateReservation(context.Context, *ReserveProductsReq) (*ReservationResp, error)
+	UndoReservation(context.Context, *UndoReservationReq) (*emptypb.Empty, error)
+	mustEmbedUnimplementedReservationServiceServer()
+}
+
+// UnimplementedReservationServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedReservationServiceServer struct {
+}
+
+func (UnimplementedReservationServiceServer) CreateReservation(context.Context, *ReserveProductsReq) (*ReservationResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateReservation not implemented")
+}
+func (UnimplementedReservationServiceServer) UndoReservation(context.Context, *UndoReservationReq) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UndoReservation not implemented")
+}
+func (UnimplementedReservationServiceServer) mustEmbedUnimplementedReservationServiceServer() {}
+
+// UnsafeReservationServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ReservationServiceServer will
+// result in compilation errors.
+type UnsafeReservationServiceServer interface {
+	mustEmbedUnimplementedReservationServiceServer()
+}
+
+func RegisterReservationServiceServer(s grpc.ServiceRegistrar, srv ReservationServiceServer) {
+	s.RegisterService(&ReservationService_ServiceDesc, srv)
+}
+
+func _ReservationService_CreateReservation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReserveProductsReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReservationServiceServer).CreateReservation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ReservationService_CreateReservation_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReservationServiceServer).CreateReservation(ctx, req.(*ReserveProductsReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ReservationService_UndoReservation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UndoReservationReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReservationServiceServer).UndoReservation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ReservationService_UndoReservation_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReservationServiceServer).UndoReservation(ctx, req.(*UndoReservationReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ReservationService_ServiceDesc is the grpc.ServiceDesc for ReservationService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ReservationService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "storageapi.v1.ReservationService",
+	HandlerType: (*ReservationServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateReservation",
+			Handler:    _ReservationService_CreateReservation_Handler,
+		},
+		{
+			MethodName: "UndoReservation",
+			Handler:    _ReservationService_UndoReservation_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "storageapi/v1/storageapi.proto",
+}
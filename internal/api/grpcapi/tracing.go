@@ -0,0 +1,42 @@
+package grpcapi
+
+import (
+	"context"
+	"storageapi/internal/tracing"
+
+	"go.opentelemetry.io/otel"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// traceCall открывает спан вызова, трасса клиента продолжается из traceparent в metadata
+func traceCall(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (_ interface{}, err error) {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		ctx = otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md))
+	}
+	ctx, span := tracing.Start(ctx, info.FullMethod)
+	defer tracing.End(span, &err)
+	return handler(ctx, req)
+}
+
+// metadataCarrier - metadata.MD для propagation, ключи metadata в нижнем регистре
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	if v := metadata.MD(c).Get(key); len(v) != 0 {
+		return v[0]
+	}
+	return ""
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
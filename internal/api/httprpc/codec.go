@@ -0,0 +1,93 @@
+package httprpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/rpc"
+)
+
+// rpc.ServerCodec для одного запроса JSON-RPC 2.0: net/rpc сам находит метод
+// и вызывает его, а кодек запоминает результат или ошибку
+type requestCodec struct {
+	req  *Request
+	read bool
+
+	result json.RawMessage
+	err    *Error
+}
+
+var _ rpc.ServerCodec = (*requestCodec)(nil)
+
+func newRequestCodec(req *Request) *requestCodec {
+	return &requestCodec{req: req}
+}
+
+func (c *requestCodec) ReadRequestHeader(r *rpc.Request) error {
+	if c.read {
+		return io.EOF
+	}
+	c.read = true
+	r.ServiceMethod = c.req.Method
+	r.Seq = 0
+	return nil
+}
+
+func (c *requestCodec) ReadRequestBody(body interface{}) error {
+	// net/rpc передает nil, когда отбрасывает тело запроса к неизвестному методу
+	if body == nil {
+		c.err = &Error{Code: CodeMethodNotFound, Message: "method not found"}
+		return nil
+	}
+	if err := decodeParams(c.req.Params, body); err != nil {
+		c.err = &Error{Code: CodeInvalidParams, Message: "invalid params", Data: err.Error()}
+		return err
+	}
+	return nil
+}
+
+func (c *requestCodec) WriteResponse(r *rpc.Response, body interface{}) error {
+	if c.err != nil {
+		// ошибка уже определена при чтении запроса
+		return nil
+	}
+	if r.Error != "" {
+		c.err = newServerError(r.Error)
+		return nil
+	}
+	result, err := json.Marshal(body)
+	if err != nil {
+		c.err = &Error{Code: CodeInternalError, Message: "internal error", Data: err.Error()}
+		return nil
+	}
+	c.result = result
+	return nil
+}
+
+func (c *requestCodec) Close() error {
+	return nil
+}
+
+// методы принимают один аргумент: params - либо сам объект (by-name),
+// либо массив из одного элемента (by-position, как в JSON-RPC 1.0)
+func decodeParams(params json.RawMessage, body interface{}) error {
+	params = bytes.TrimSpace(params)
+	if len(params) == 0 || bytes.Equal(params, []byte("null")) {
+		return nil
+	}
+	switch params[0] {
+	case '{':
+		return json.Unmarshal(params, body)
+	case '[':
+		var positional []json.RawMessage
+		if err := json.Unmarshal(params, &positional); err != nil {
+			return err
+		}
+		if len(positional) != 1 {
+			return errors.New("params array must contain exactly one element")
+		}
+		return json.Unmarshal(positional[0], body)
+	}
+	return errors.New("params must be an object or an array")
+}
@@ -0,0 +1,142 @@
+package httprpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/rpc"
+	"storageapi/internal/api"
+	"storageapi/internal/metrics"
+
+	"go.uber.org/zap"
+)
+
+// максимальный размер тела запроса
+const maxBodySize = 1 << 20
+
+// Handler обслуживает JSON-RPC 2.0 поверх HTTP POST теми же методами
+// Storage.*, Reservation.* и т.д., что зарегистрированы в rpc.Server для TCP
+type Handler struct {
+	server *rpc.Server
+	log    *zap.SugaredLogger
+}
+
+var _ http.Handler = (*Handler)(nil)
+
+func NewHandler(server *rpc.Server, log *zap.SugaredLogger) *Handler {
+	return &Handler{
+		server: server,
+		log:    log,
+	}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '[' {
+		h.serveBatch(w, body)
+		return
+	}
+	resp := h.handle(body)
+	if resp == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	h.write(w, resp)
+}
+
+func (h *Handler) serveBatch(w http.ResponseWriter, body []byte) {
+	var batch []json.RawMessage
+	if err := json.Unmarshal(body, &batch); err != nil {
+		h.write(w, errorResponse(nil, &Error{Code: CodeParseError, Message: "parse error"}))
+		return
+	}
+	if len(batch) == 0 {
+		h.write(w, errorResponse(nil, &Error{Code: CodeInvalidRequest, Message: "empty batch"}))
+		return
+	}
+	responses := make([]*Response, 0, len(batch))
+	for _, raw := range batch {
+		if resp := h.handle(raw); resp != nil {
+			responses = append(responses, resp)
+		}
+	}
+	// батч из одних уведомлений
+	if len(responses) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	h.write(w, responses)
+}
+
+// обрабатывает один запрос, для уведомлений возвращает nil
+func (h *Handler) handle(raw json.RawMessage) *Response {
+	var req Request
+	if err := json.Unmarshal(raw, &req); err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) || len(raw) == 0 {
+			return errorResponse(nil, &Error{Code: CodeParseError, Message: "parse error"})
+		}
+		return errorResponse(nil, &Error{Code: CodeInvalidRequest, Message: "invalid request", Data: err.Error()})
+	}
+	if !validID(req.ID) {
+		return errorResponse(nil, &Error{Code: CodeInvalidRequest, Message: "id must be a string, a number or null"})
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		return errorResponse(req.ID, &Error{Code: CodeInvalidRequest, Message: `invalid request: jsonrpc must be "2.0" and method is required`})
+	}
+
+	codec := newRequestCodec(&req)
+	if err := h.server.ServeRequest(api.ValidatingCodec(metrics.RPCCodec(metrics.TransportHTTP, codec))); err != nil && codec.err == nil {
+		codec.err = &Error{Code: CodeInternalError, Message: "internal error", Data: err.Error()}
+	}
+	if len(req.ID) == 0 {
+		return nil
+	}
+	if codec.err != nil {
+		return errorResponse(req.ID, codec.err)
+	}
+	return &Response{
+		JSONRPC: "2.0",
+		Result:  codec.result,
+		ID:      req.ID,
+	}
+}
+
+func (h *Handler) write(w http.ResponseWriter, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		h.log.Errorw("cannot write json-rpc response", "error", err)
+	}
+}
+
+func errorResponse(id json.RawMessage, err *Error) *Response {
+	return &Response{
+		JSONRPC: "2.0",
+		Error:   err,
+		ID:      id,
+	}
+}
+
+func validID(id json.RawMessage) bool {
+	if len(id) == 0 {
+		return true
+	}
+	switch id[0] {
+	case '"', 'n', '-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		return true
+	}
+	return false
+}
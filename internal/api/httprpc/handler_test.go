@@ -0,0 +1,179 @@
+package httprpc
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/rpc"
+	"storageapi/internal/api"
+	"storageapi/pkg/errs"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+type EchoReq struct {
+	Value int `json:"value"`
+}
+
+// значения больше 100 не проходят проверку до вызова метода
+func (req EchoReq) Validate() error {
+	if req.Value > 100 {
+		e := errs.New(errs.CodeValidation, "value: too big")
+		e.Fields = []errs.FieldError{{Path: "value", Message: "too big"}}
+		return e
+	}
+	return nil
+}
+
+type EchoResp struct {
+	Value int `json:"value"`
+}
+
+type Echo struct{}
+
+func (Echo) Echo(request *EchoReq, response *EchoResp) error {
+	switch {
+	case request.Value == -1:
+		return errors.New("negative value")
+	case request.Value < 0:
+		return api.RPCError(errs.New(errs.CodeInsufficientStock, "not enough").WithDetail("available", 0))
+	}
+	response.Value = request.Value
+	return nil
+}
+
+func newTestHandler(t *testing.T) *Handler {
+	server := rpc.NewServer()
+	if err := server.RegisterName("Test", Echo{}); err != nil {
+		t.Fatal(err)
+	}
+	return NewHandler(server, zap.NewNop().Sugar())
+}
+
+func post(t *testing.T, h http.Handler, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(body)))
+	return w
+}
+
+func TestHandlerSingle(t *testing.T) {
+	h := newTestHandler(t)
+	cases := []struct {
+		name   string
+		body   string
+		result string
+		code   int
+	}{
+		{"by name", `{"jsonrpc":"2.0","method":"Test.Echo","params":{"value":7},"id":1}`, `{"value":7}`, 0},
+		{"by position", `{"jsonrpc":"2.0","method":"Test.Echo","params":[{"value":3}],"id":"a"}`, `{"value":3}`, 0},
+		{"no params", `{"jsonrpc":"2.0","method":"Test.Echo","id":1}`, `{"value":0}`, 0},
+		{"parse error", `{"jsonrpc":"2.0",`, "", CodeParseError},
+		{"wrong version", `{"jsonrpc":"1.0","method":"Test.Echo","id":1}`, "", CodeInvalidRequest},
+		{"unknown method", `{"jsonrpc":"2.0","method":"Test.Nope","id":1}`, "", CodeMethodNotFound},
+		{"invalid params", `{"jsonrpc":"2.0","method":"Test.Echo","params":{"value":"x"},"id":1}`, "", CodeInvalidParams},
+		{"method error", `{"jsonrpc":"2.0","method":"Test.Echo","params":{"value":-1},"id":1}`, "", CodeInternalError},
+		{"validation", `{"jsonrpc":"2.0","method":"Test.Echo","params":{"value":101},"id":1}`, "", CodeInvalidParams},
+		{"coded error", `{"jsonrpc":"2.0","method":"Test.Echo","params":{"value":-2},"id":1}`, "", CodeInsufficientStock},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			w := post(t, h, c.body)
+			var resp Response
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("%v: %s", err, w.Body.String())
+			}
+			if resp.JSONRPC != "2.0" {
+				t.Errorf("jsonrpc = %q", resp.JSONRPC)
+			}
+			if c.code != 0 {
+				if resp.Error == nil || resp.Error.Code != c.code {
+					t.Errorf("error = %+v, want code %d", resp.Error, c.code)
+				}
+				return
+			}
+			if resp.Error != nil {
+				t.Fatalf("unexpected error %+v", resp.Error)
+			}
+			if string(resp.Result) != c.result {
+				t.Errorf("result = %s, want %s", resp.Result, c.result)
+			}
+		})
+	}
+}
+
+func TestHandlerBatchAndNotifications(t *testing.T) {
+	h := newTestHandler(t)
+
+	w := post(t, h, `[
+		{"jsonrpc":"2.0","method":"Test.Echo","params":{"value":1},"id":1},
+		{"jsonrpc":"2.0","method":"Test.Echo","params":{"value":2}},
+		{"jsonrpc":"2.0","method":"Test.Nope","id":3},
+		1
+	]`)
+	var batch []Response
+	if err := json.Unmarshal(w.Body.Bytes(), &batch); err != nil {
+		t.Fatalf("%v: %s", err, w.Body.String())
+	}
+	// уведомление не получает ответа
+	if len(batch) != 3 {
+		t.Fatalf("got %d responses, want 3: %s", len(batch), w.Body.String())
+	}
+	if string(batch[0].Result) != `{"value":1}` {
+		t.Errorf("first result = %s", batch[0].Result)
+	}
+	if batch[1].Error == nil || batch[1].Error.Code != CodeMethodNotFound {
+		t.Errorf("second error = %+v", batch[1].Error)
+	}
+	if batch[2].Error == nil || batch[2].Error.Code != CodeInvalidRequest {
+		t.Errorf("third error = %+v", batch[2].Error)
+	}
+
+	w = post(t, h, `[{"jsonrpc":"2.0","method":"Test.Echo","params":{"value":1}}]`)
+	if w.Code != http.StatusNoContent || w.Body.Len() != 0 {
+		t.Errorf("notification batch: status %d, body %q", w.Code, w.Body.String())
+	}
+
+	w = post(t, h, `[]`)
+	var resp Response
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Error == nil || resp.Error.Code != CodeInvalidRequest {
+		t.Errorf("empty batch error = %+v", resp.Error)
+	}
+}
+
+func TestHandlerErrorData(t *testing.T) {
+	w := post(t, newTestHandler(t), `{"jsonrpc":"2.0","method":"Test.Echo","params":{"value":-2},"id":1}`)
+	var resp struct {
+		Error struct {
+			Code int       `json:"code"`
+			Data ErrorData `json:"data"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Error.Data.Code != errs.CodeInsufficientStock || resp.Error.Data.Details["available"] != float64(0) {
+		t.Fatalf("unexpected error %s", w.Body.String())
+	}
+}
+
+func TestHandlerValidationFields(t *testing.T) {
+	w := post(t, newTestHandler(t), `{"jsonrpc":"2.0","method":"Test.Echo","params":{"value":101},"id":1}`)
+	var resp struct {
+		Error struct {
+			Data ErrorData `json:"data"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	fields := resp.Error.Data.Fields
+	if resp.Error.Data.Code != errs.CodeValidation || len(fields) != 1 || fields[0].Path != "value" {
+		t.Fatalf("unexpected error %s", w.Body.String())
+	}
+}
@@ -0,0 +1,83 @@
+package httprpc
+
+import (
+	"encoding/json"
+	"storageapi/pkg/errs"
+)
+
+// коды ошибок из спецификации JSON-RPC 2.0
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+	// ошибка, которую вернул сам метод (use case) без кода errs
+	CodeServerError = -32000
+)
+
+// коды ошибок use case в диапазоне, отведенном спецификацией под ошибки
+// сервера. validation отдается как CodeInvalidParams, internal - как CodeInternalError
+const (
+	CodeNotFound          = -32001
+	CodeInsufficientStock = -32002
+	CodeUnavailable       = -32003
+	CodeConflict          = -32004
+)
+
+// Data ошибки use case
+type ErrorData struct {
+	Code    errs.Code              `json:"code"`
+	Details map[string]interface{} `json:"details,omitempty"`
+	Fields  []errs.FieldError      `json:"fields,omitempty"`
+}
+
+type Request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	// отсутствует у уведомлений (notification), null - допустимый id
+	ID json.RawMessage `json:"id,omitempty"`
+}
+
+type Response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+	ID      json.RawMessage `json:"id"`
+}
+
+type Error struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// ошибка use case, net/rpc передает ее JSON строкой (см. api.RPCError)
+func newServerError(s string) *Error {
+	e := errs.Unmarshal(s)
+	code := CodeServerError
+	switch e.Code {
+	case errs.CodeValidation:
+		code = CodeInvalidParams
+	case errs.CodeNotFound:
+		code = CodeNotFound
+	case errs.CodeInsufficientStock:
+		code = CodeInsufficientStock
+	case errs.CodeUnavailable:
+		code = CodeUnavailable
+	case errs.CodeConflict:
+		code = CodeConflict
+	case errs.CodeInternal:
+		code = CodeInternalError
+	}
+	return &Error{
+		Code:    code,
+		Message: e.Message,
+		Data:    ErrorData{Code: e.Code, Details: e.Details, Fields: e.Fields},
+	}
+}
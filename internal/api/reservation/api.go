@@ -3,6 +3,7 @@ package reservation
 import (
 	"context"
 	"storageapi/internal/api"
+	"storageapi/internal/tracing"
 	"storageapi/internal/usecase/reservation"
 	"time"
 
@@ -23,22 +24,53 @@ func NewAPI(log *zap.SugaredLogger, s UseCase, conf api.ApiConf) *API {
 	}
 }
 
-func (a API) CreateReservation(request *reservation.ReserveProductsReq, response *api.Empty) error {
-	ctx, cancel := context.WithTimeout(context.Background(), a.requestTimeout)
+func (a API) CreateReservation(request *reservation.ReserveProductsReq, response *reservation.ReservationResp) (err error) {
+	ctx, span := tracing.Start(context.Background(), "Reservation.CreateReservation")
+	defer tracing.End(span, &err)
+	ctx, cancel := context.WithTimeout(ctx, a.requestTimeout)
 	defer cancel()
-	if err := a.service.ReserveProducts(ctx, *request); err != nil {
-		return err
+	resp, err := a.service.ReserveProducts(ctx, *request)
+	if err != nil {
+		return api.RPCError(err)
 	}
-	*response = api.Empty{}
+	*response = *resp
+	return nil
+}
+
+func (a API) GetReservation(request *reservation.GetReservationReq, response *reservation.ReservationResp) (err error) {
+	ctx, span := tracing.Start(context.Background(), "Reservation.GetReservation")
+	defer tracing.End(span, &err)
+	ctx, cancel := context.WithTimeout(ctx, a.requestTimeout)
+	defer cancel()
+	resp, err := a.service.GetReservation(ctx, *request)
+	if err != nil {
+		return api.RPCError(err)
+	}
+	*response = *resp
 	return nil
 }
 
-func (a API) UndoReservation(request *reservation.UndoReservationReq, response *api.Empty) error {
-	ctx, cancel := context.WithTimeout(context.Background(), a.requestTimeout)
+func (a API) UndoReservation(request *reservation.UndoReservationReq, response *api.Empty) (err error) {
+	ctx, span := tracing.Start(context.Background(), "Reservation.UndoReservation")
+	defer tracing.End(span, &err)
+	ctx, cancel := context.WithTimeout(ctx, a.requestTimeout)
 	defer cancel()
 	if err := a.service.UndoReserve(ctx, *request); err != nil {
-		return err
+		return api.RPCError(err)
 	}
 	*response = api.Empty{}
 	return nil
 }
+
+func (a API) ConfirmReservation(request *reservation.ConfirmReservationReq, response *reservation.ShipmentResp) (err error) {
+	ctx, span := tracing.Start(context.Background(), "Reservation.ConfirmReservation")
+	defer tracing.End(span, &err)
+	ctx, cancel := context.WithTimeout(ctx, a.requestTimeout)
+	defer cancel()
+	resp, err := a.service.ConfirmReservation(ctx, *request)
+	if err != nil {
+		return api.RPCError(err)
+	}
+	*response = *resp
+	return nil
+}
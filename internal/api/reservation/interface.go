@@ -6,8 +6,10 @@ import (
 )
 
 type UseCase interface {
-	ReserveProducts(ctx context.Context, req reservation.ReserveProductsReq) error
+	ReserveProducts(ctx context.Context, req reservation.ReserveProductsReq) (*reservation.ReservationResp, error)
+	GetReservation(ctx context.Context, req reservation.GetReservationReq) (*reservation.ReservationResp, error)
 	UndoReserve(ctx context.Context, req reservation.UndoReservationReq) error
+	ConfirmReservation(ctx context.Context, req reservation.ConfirmReservationReq) (*reservation.ShipmentResp, error)
 }
 
 var _ UseCase = (*reservation.Service)(nil)
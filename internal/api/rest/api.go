@@ -0,0 +1,163 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"storageapi/internal/api"
+	"storageapi/internal/api/reservation"
+	"storageapi/internal/api/storage"
+	"storageapi/internal/metrics"
+	"storageapi/internal/tracing"
+	"storageapi/pkg/errs"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// максимальный размер тела запроса
+const maxBodySize = 1 << 20
+
+// ключ идемпотентности можно передать заголовком вместо поля тела
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// API - ресурсное REST/JSON api поверх тех же use case, что и JSON-RPC
+type API struct {
+	log            *zap.SugaredLogger
+	storage        storage.UseCase
+	reservation    reservation.UseCase
+	requestTimeout time.Duration
+}
+
+var _ http.Handler = (*API)(nil)
+
+func NewAPI(log *zap.SugaredLogger, s storage.UseCase, r reservation.UseCase, conf api.ApiConf) *API {
+	return &API{
+		log:            log,
+		storage:        s,
+		reservation:    r,
+		requestTimeout: conf.RequestHandleTimeout,
+	}
+}
+
+// маршруты:
+//
+//	GET    /storages                  ?is_available=&limit=&offset=
+//	GET    /storages/{id}
+//	GET    /storages/{id}/unreserved
+//	POST   /storages/schema
+//	POST   /reservations
+//	GET    /reservations/{id}
+//	DELETE /reservations              тело {"id": ...}
+//	DELETE /reservations/{id}
+func (a *API) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// имя спана станет известно после выбора маршрута
+	ctx, span := tracing.Start(tracing.FromHeader(r.Context(), r.Header), "rest")
+	ctx, cancel := context.WithTimeout(ctx, a.requestTimeout)
+	defer cancel()
+	r = r.WithContext(ctx)
+
+	start := time.Now()
+	rec := &statusRecorder{ResponseWriter: w, code: metrics.CodeOK}
+	// метка метода - маршрут, а не путь запроса, иначе число меток не ограничено
+	method := "unknown"
+	defer func() {
+		metrics.ObserveRPC(metrics.TransportREST, method, rec.code, time.Since(start))
+		span.SetName(method)
+		tracing.End(span, &rec.err)
+	}()
+
+	path := splitPath(r)
+	switch {
+	case match(path, "storages"):
+		method = a.route(rec, r, "/storages", map[string]http.HandlerFunc{http.MethodGet: a.listStorages})
+	case match(path, "storages", "schema"):
+		method = a.route(rec, r, "/storages/schema", map[string]http.HandlerFunc{http.MethodPost: a.defineStorageSchema})
+	case match(path, "storages", "*"):
+		method = a.route(rec, r, "/storages/{id}", map[string]http.HandlerFunc{http.MethodGet: a.getStorage})
+	case match(path, "storages", "*", "unreserved"):
+		method = a.route(rec, r, "/storages/{id}/unreserved", map[string]http.HandlerFunc{http.MethodGet: a.getUnreservedStorage})
+	case match(path, "reservations"):
+		method = a.route(rec, r, "/reservations", map[string]http.HandlerFunc{
+			http.MethodPost:   a.createReservation,
+			http.MethodDelete: a.undoReservation,
+		})
+	case match(path, "reservations", "*"):
+		method = a.route(rec, r, "/reservations/{id}", map[string]http.HandlerFunc{
+			http.MethodGet:    a.getReservation,
+			http.MethodDelete: a.undoReservation,
+		})
+	default:
+		a.writeError(rec, http.StatusNotFound, errs.New(errs.CodeNotFound, "route not found"))
+	}
+}
+
+// возвращает метку метода для метрик, "unknown" - метод не поддерживается
+func (a *API) route(w http.ResponseWriter, r *http.Request, pattern string, handlers map[string]http.HandlerFunc) string {
+	h, ok := handlers[r.Method]
+	if !ok {
+		allowed := make([]string, 0, len(handlers))
+		for m := range handlers {
+			allowed = append(allowed, m)
+		}
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		a.writeError(w, http.StatusMethodNotAllowed, errs.New(errs.CodeValidation, "method not allowed"))
+		return "unknown"
+	}
+	h(w, r)
+	return r.Method + " " + pattern
+}
+
+// "*" совпадает с любым сегментом пути
+func match(path []string, pattern ...string) bool {
+	if len(path) != len(pattern) {
+		return false
+	}
+	for i, p := range pattern {
+		if p != "*" && p != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func splitPath(r *http.Request) []string {
+	return strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+}
+
+// id из сегмента пути с номером i
+func pathID(r *http.Request, i int) (uint, error) {
+	path := splitPath(r)
+	if i >= len(path) {
+		return 0, errors.New("id is missing in path")
+	}
+	id, err := strconv.ParseUint(path[i], 10, 64)
+	if err != nil || id == 0 {
+		return 0, errors.New("id in path must be a positive integer")
+	}
+	return uint(id), nil
+}
+
+// тело больше maxBodySize не обрезается, а дает *http.MaxBytesError (см. bodyStatus)
+func decodeBody(w http.ResponseWriter, r *http.Request, v interface{}) error {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		return err
+	}
+	if len(body) == 0 {
+		return errors.New("request body is required")
+	}
+	return json.Unmarshal(body, v)
+}
+
+func (a *API) write(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		a.log.Errorw("cannot write rest response", "error", err)
+	}
+}
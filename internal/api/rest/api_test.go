@@ -0,0 +1,169 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"storageapi/internal/api"
+	"storageapi/internal/entity"
+	"storageapi/internal/usecase/reservation"
+	"storageapi/internal/usecase/storage"
+	"storageapi/pkg/errs"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type fakeStorage struct{}
+
+func (fakeStorage) DefineStorageSchema(ctx context.Context, req storage.StorageSchemaReq) (storage.StorageSchemaResp, error) {
+	return storage.StorageSchemaResp{{ID: 1}}, nil
+}
+
+func (fakeStorage) GetStorageSchema(ctx context.Context, req storage.GetStorageSchemaReq) (storage.GetStorageSchemaResp, error) {
+	if len(req.IDs) == 1 && req.IDs[0] != 1 {
+		return storage.GetStorageSchemaResp{}, nil
+	}
+	return storage.GetStorageSchemaResp{{ID: 1, IsAvailable: true}}, nil
+}
+
+func (fakeStorage) GetUnreservedStorage(ctx context.Context, storageID entity.PK) (*storage.StorageSchemaRespItem, error) {
+	if storageID != 1 {
+		return nil, errs.New(errs.CodeNotFound, "cannot find storage by id")
+	}
+	return &storage.StorageSchemaRespItem{ID: 1}, nil
+}
+
+func (fakeStorage) ReceiveStock(ctx context.Context, req storage.ReceiveStockReq) (*storage.StockResp, error) {
+	return nil, errors.New("not used")
+}
+
+func (fakeStorage) AdjustStock(ctx context.Context, req storage.AdjustStockReq) (*storage.StockResp, error) {
+	return nil, errors.New("not used")
+}
+
+func (fakeStorage) SetStorageAvailability(ctx context.Context, req storage.SetStorageAvailabilityReq) (*storage.SetStorageAvailabilityResp, error) {
+	return nil, errors.New("not used")
+}
+
+type fakeReservation struct{}
+
+func (fakeReservation) ReserveProducts(ctx context.Context, req reservation.ReserveProductsReq) (*reservation.ReservationResp, error) {
+	if req.Products[0].Amount > 10 {
+		return nil, errs.Newf(errs.CodeInsufficientStock, "cannot reserve more than 10 for product with id %d", req.Products[0].ID).
+			WithDetail("product_id", req.Products[0].ID).
+			WithDetail("available", 10)
+	}
+	return &reservation.ReservationResp{ID: 1, Status: entity.ReservationStatusActive}, nil
+}
+
+func (fakeReservation) GetReservation(ctx context.Context, req reservation.GetReservationReq) (*reservation.ReservationResp, error) {
+	return &reservation.ReservationResp{ID: req.ID}, nil
+}
+
+func (fakeReservation) UndoReserve(ctx context.Context, req reservation.UndoReservationReq) error {
+	if req.ID != 1 {
+		return errs.New(errs.CodeNotFound, "cannot find reservation by id")
+	}
+	return nil
+}
+
+func (fakeReservation) ConfirmReservation(ctx context.Context, req reservation.ConfirmReservationReq) (*reservation.ShipmentResp, error) {
+	return nil, errors.New("not used")
+}
+
+func TestRoutes(t *testing.T) {
+	a := NewAPI(zap.NewNop().Sugar(), fakeStorage{}, fakeReservation{}, api.ApiConf{RequestHandleTimeout: time.Second})
+	cases := []struct {
+		method, path, body string
+		status             int
+	}{
+		{http.MethodGet, "/storages", "", http.StatusOK},
+		{http.MethodGet, "/storages?is_available=yes", "", http.StatusBadRequest},
+		{http.MethodGet, "/storages/1", "", http.StatusOK},
+		{http.MethodGet, "/storages/2", "", http.StatusNotFound},
+		{http.MethodGet, "/storages/abc", "", http.StatusBadRequest},
+		{http.MethodGet, "/storages/1/unreserved", "", http.StatusOK},
+		{http.MethodGet, "/storages/2/unreserved", "", http.StatusNotFound},
+		{http.MethodPost, "/storages/1", "", http.StatusMethodNotAllowed},
+		{http.MethodPost, "/storages/schema", `{"storages":[]}`, http.StatusCreated},
+		{http.MethodPost, "/storages/schema", `{`, http.StatusBadRequest},
+		{http.MethodPost, "/reservations", `{"products":[{"id":1,"amount":1}]}`, http.StatusCreated},
+		{http.MethodPost, "/reservations", `{"products":[{"id":1,"amount":0}]}`, http.StatusBadRequest},
+		{http.MethodPost, "/reservations", `{"products":[{"id":1,"amount":11}]}`, http.StatusConflict},
+		{http.MethodGet, "/reservations/5", "", http.StatusOK},
+		{http.MethodDelete, "/reservations", `{"id":1}`, http.StatusNoContent},
+		{http.MethodDelete, "/reservations/1", "", http.StatusNoContent},
+		{http.MethodDelete, "/reservations/2", "", http.StatusNotFound},
+		{http.MethodDelete, "/reservations", `{}`, http.StatusBadRequest},
+		{http.MethodGet, "/unknown", "", http.StatusNotFound},
+	}
+	for _, c := range cases {
+		w := httptest.NewRecorder()
+		a.ServeHTTP(w, httptest.NewRequest(c.method, c.path, strings.NewReader(c.body)))
+		if w.Code != c.status {
+			t.Errorf("%s %s: status %d, want %d (%s)", c.method, c.path, w.Code, c.status, w.Body.String())
+		}
+	}
+}
+
+func TestErrorBody(t *testing.T) {
+	a := NewAPI(zap.NewNop().Sugar(), fakeStorage{}, fakeReservation{}, api.ApiConf{RequestHandleTimeout: time.Second})
+	w := httptest.NewRecorder()
+	a.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/reservations", strings.NewReader(`{"products":[{"id":3,"amount":11}]}`)))
+	var resp struct {
+		Error errs.Error `json:"error"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Error.Code != errs.CodeInsufficientStock {
+		t.Fatalf("code %q, want %q", resp.Error.Code, errs.CodeInsufficientStock)
+	}
+	if resp.Error.Details["product_id"] != float64(3) || resp.Error.Details["available"] != float64(10) {
+		t.Fatalf("unexpected details %v", resp.Error.Details)
+	}
+}
+
+func TestStatusOf(t *testing.T) {
+	for _, tc := range []struct {
+		err    error
+		status int
+	}{
+		{context.DeadlineExceeded, http.StatusGatewayTimeout},
+		{fmt.Errorf("reserve: %w", context.Canceled), http.StatusServiceUnavailable},
+		{errs.New(errs.CodeValidation, "bad"), http.StatusBadRequest},
+		{errs.New(errs.CodeNotFound, "missing"), http.StatusNotFound},
+		{errs.New(errs.CodeInsufficientStock, "not enough"), http.StatusConflict},
+		{errors.New("boom"), http.StatusInternalServerError},
+	} {
+		if got := statusOf(tc.err); got != tc.status {
+			t.Errorf("statusOf(%v) = %d, want %d", tc.err, got, tc.status)
+		}
+	}
+}
+
+// тело больше maxBodySize не обрезается до невалидного JSON, а отклоняется с 413
+func TestBodyTooLarge(t *testing.T) {
+	a := NewAPI(zap.NewNop().Sugar(), fakeStorage{}, fakeReservation{}, api.ApiConf{RequestHandleTimeout: time.Second})
+	body := `{"products":[{"id":3,"amount":1}]}` + strings.Repeat(" ", maxBodySize)
+	w := httptest.NewRecorder()
+	a.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/reservations", strings.NewReader(body)))
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("status %d, want %d: %s", w.Code, http.StatusRequestEntityTooLarge, w.Body.String())
+	}
+	var resp struct {
+		Error errs.Error `json:"error"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Error.Code != errs.CodeValidation {
+		t.Fatalf("code %q, want %q", resp.Error.Code, errs.CodeValidation)
+	}
+}
@@ -0,0 +1,77 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"storageapi/internal/api"
+	"storageapi/pkg/errs"
+)
+
+func statusOf(err error) int {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	case errors.Is(err, context.Canceled):
+		// запрос отменен: клиент закрыл соединение (ответ он уже не получит)
+		// или сервер останавливается. нестандартный 499 прокси не понимают
+		return http.StatusServiceUnavailable
+	}
+	switch api.Error(err).Code {
+	case errs.CodeValidation:
+		return http.StatusBadRequest
+	case errs.CodeNotFound:
+		return http.StatusNotFound
+	case errs.CodeConflict, errs.CodeInsufficientStock, errs.CodeUnavailable:
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+// статус ошибки decodeBody: слишком большое тело - 413, как в httprpc
+func bodyStatus(err error) int {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
+
+// ошибка разбора пути, query или тела запроса. ошибки Validate
+// уже содержат код и возвращаются как есть
+func invalidRequest(err error) error {
+	var e *errs.Error
+	if errors.As(err, &e) {
+		return err
+	}
+	return errs.Wrap(errs.CodeValidation, err, "invalid request")
+}
+
+type errorResp struct {
+	Error *errs.Error `json:"error"`
+}
+
+func (a *API) writeError(w http.ResponseWriter, status int, err error) {
+	if status >= http.StatusInternalServerError {
+		a.log.Errorw("rest request failed", "error", err)
+	}
+	e := api.Error(err)
+	if rec, ok := w.(*statusRecorder); ok {
+		rec.code = string(e.Code)
+		rec.err = err
+	}
+	// в сообщение попадает весь текст ошибки вместе с причиной
+	a.write(w, status, errorResp{Error: &errs.Error{
+		Code:    e.Code,
+		Message: err.Error(),
+		Details: e.Details,
+		Fields:  e.Fields,
+	}})
+}
+
+// statusRecorder запоминает ошибку ответа для метрик и спана запроса
+type statusRecorder struct {
+	http.ResponseWriter
+	code string
+	err  error
+}
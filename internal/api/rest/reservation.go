@@ -0,0 +1,66 @@
+package rest
+
+import (
+	"net/http"
+	"storageapi/internal/usecase/reservation"
+)
+
+func (a *API) createReservation(w http.ResponseWriter, r *http.Request) {
+	var req reservation.ReserveProductsReq
+	if err := decodeBody(w, r, &req); err != nil {
+		a.writeError(w, bodyStatus(err), invalidRequest(err))
+		return
+	}
+	if req.IdempotencyKey == "" {
+		req.IdempotencyKey = r.Header.Get(idempotencyKeyHeader)
+	}
+	if err := req.Validate(); err != nil {
+		a.writeError(w, http.StatusBadRequest, invalidRequest(err))
+		return
+	}
+	resp, err := a.reservation.ReserveProducts(r.Context(), req)
+	if err != nil {
+		a.writeError(w, statusOf(err), err)
+		return
+	}
+	a.write(w, http.StatusCreated, resp)
+}
+
+func (a *API) getReservation(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, 1)
+	if err != nil {
+		a.writeError(w, http.StatusBadRequest, invalidRequest(err))
+		return
+	}
+	resp, err := a.reservation.GetReservation(r.Context(), reservation.GetReservationReq{ID: id})
+	if err != nil {
+		a.writeError(w, statusOf(err), err)
+		return
+	}
+	a.write(w, http.StatusOK, resp)
+}
+
+// id резерва берется из пути (/reservations/{id}) или из тела (/reservations)
+func (a *API) undoReservation(w http.ResponseWriter, r *http.Request) {
+	var req reservation.UndoReservationReq
+	if len(splitPath(r)) > 1 {
+		id, err := pathID(r, 1)
+		if err != nil {
+			a.writeError(w, http.StatusBadRequest, invalidRequest(err))
+			return
+		}
+		req.ID = id
+	} else if err := decodeBody(w, r, &req); err != nil {
+		a.writeError(w, bodyStatus(err), invalidRequest(err))
+		return
+	}
+	if err := req.Validate(); err != nil {
+		a.writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := a.reservation.UndoReserve(r.Context(), req); err != nil {
+		a.writeError(w, statusOf(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
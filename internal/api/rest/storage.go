@@ -0,0 +1,91 @@
+package rest
+
+import (
+	"net/http"
+	"storageapi/internal/entity"
+	"storageapi/internal/usecase/storage"
+	"storageapi/pkg/errs"
+	"strconv"
+)
+
+func (a *API) listStorages(w http.ResponseWriter, r *http.Request) {
+	req := storage.GetStorageSchemaReq{}
+	query := r.URL.Query()
+	if v := query.Get("is_available"); v != "" {
+		isAvailable, err := strconv.ParseBool(v)
+		if err != nil {
+			a.writeError(w, http.StatusBadRequest, invalidRequest(err))
+			return
+		}
+		req.IsAvailable = &isAvailable
+	}
+	for name, dst := range map[string]*uint{"limit": &req.Limit, "offset": &req.Offset} {
+		if v := query.Get(name); v != "" {
+			n, err := strconv.ParseUint(v, 10, 64)
+			if err != nil {
+				a.writeError(w, http.StatusBadRequest, invalidRequest(err))
+				return
+			}
+			*dst = uint(n)
+		}
+	}
+	resp, err := a.storage.GetStorageSchema(r.Context(), req)
+	if err != nil {
+		a.writeError(w, statusOf(err), err)
+		return
+	}
+	a.write(w, http.StatusOK, resp)
+}
+
+func (a *API) getStorage(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, 1)
+	if err != nil {
+		a.writeError(w, http.StatusBadRequest, invalidRequest(err))
+		return
+	}
+	resp, err := a.storage.GetStorageSchema(r.Context(), storage.GetStorageSchemaReq{IDs: []uint{id}})
+	if err != nil {
+		a.writeError(w, statusOf(err), err)
+		return
+	}
+	if len(resp) == 0 {
+		a.writeError(w, http.StatusNotFound, errs.New(errs.CodeNotFound, "cannot find storage by id").WithDetail("storage_id", id))
+		return
+	}
+	a.write(w, http.StatusOK, resp[0])
+}
+
+func (a *API) getUnreservedStorage(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, 1)
+	if err != nil {
+		a.writeError(w, http.StatusBadRequest, invalidRequest(err))
+		return
+	}
+	resp, err := a.storage.GetUnreservedStorage(r.Context(), entity.PK(id))
+	if err != nil {
+		a.writeError(w, statusOf(err), err)
+		return
+	}
+	a.write(w, http.StatusOK, resp)
+}
+
+func (a *API) defineStorageSchema(w http.ResponseWriter, r *http.Request) {
+	var req storage.StorageSchemaReq
+	if err := decodeBody(w, r, &req); err != nil {
+		a.writeError(w, bodyStatus(err), invalidRequest(err))
+		return
+	}
+	if req.IdempotencyKey == "" {
+		req.IdempotencyKey = r.Header.Get(idempotencyKeyHeader)
+	}
+	if err := req.Validate(); err != nil {
+		a.writeError(w, http.StatusBadRequest, invalidRequest(err))
+		return
+	}
+	resp, err := a.storage.DefineStorageSchema(r.Context(), req)
+	if err != nil {
+		a.writeError(w, statusOf(err), err)
+		return
+	}
+	a.write(w, http.StatusCreated, resp)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"storageapi/internal/api"
 	"storageapi/internal/entity"
+	"storageapi/internal/tracing"
 	"storageapi/internal/usecase/storage"
 	"time"
 
@@ -24,23 +25,79 @@ func NewAPI(log *zap.SugaredLogger, s UseCase, conf api.ApiConf) *API {
 	}
 }
 
-func (a API) DefineStorageSchema(request *storage.StorageSchemaReq, response *storage.StorageSchemaResp) error {
-	ctx, cancel := context.WithTimeout(context.Background(), a.requestTimeout)
+func (a API) DefineStorageSchema(request *storage.StorageSchemaReq, response *storage.StorageSchemaResp) (err error) {
+	ctx, span := tracing.Start(context.Background(), "Storage.DefineStorageSchema")
+	defer tracing.End(span, &err)
+	ctx, cancel := context.WithTimeout(ctx, a.requestTimeout)
 	defer cancel()
 	resp, err := a.service.DefineStorageSchema(ctx, *request)
 	if err != nil {
-		return err
+		return api.RPCError(err)
 	}
 	*response = resp
 	return nil
 }
 
-func (a API) GetUnreservedStorage(request *GetUnreservedStorageReq, response *storage.StorageSchemaRespItem) error {
-	ctx, cancel := context.WithTimeout(context.Background(), a.requestTimeout)
+func (a API) GetStorageSchema(request *storage.GetStorageSchemaReq, response *storage.GetStorageSchemaResp) (err error) {
+	ctx, span := tracing.Start(context.Background(), "Storage.GetStorageSchema")
+	defer tracing.End(span, &err)
+	ctx, cancel := context.WithTimeout(ctx, a.requestTimeout)
+	defer cancel()
+	resp, err := a.service.GetStorageSchema(ctx, *request)
+	if err != nil {
+		return api.RPCError(err)
+	}
+	*response = resp
+	return nil
+}
+
+func (a API) GetUnreservedStorage(request *GetUnreservedStorageReq, response *storage.StorageSchemaRespItem) (err error) {
+	ctx, span := tracing.Start(context.Background(), "Storage.GetUnreservedStorage")
+	defer tracing.End(span, &err)
+	ctx, cancel := context.WithTimeout(ctx, a.requestTimeout)
 	defer cancel()
 	resp, err := a.service.GetUnreservedStorage(ctx, entity.PK(request.StorageID))
 	if err != nil {
-		return err
+		return api.RPCError(err)
+	}
+	*response = *resp
+	return nil
+}
+
+func (a API) ReceiveStock(request *storage.ReceiveStockReq, response *storage.StockResp) (err error) {
+	ctx, span := tracing.Start(context.Background(), "Storage.ReceiveStock")
+	defer tracing.End(span, &err)
+	ctx, cancel := context.WithTimeout(ctx, a.requestTimeout)
+	defer cancel()
+	resp, err := a.service.ReceiveStock(ctx, *request)
+	if err != nil {
+		return api.RPCError(err)
+	}
+	*response = *resp
+	return nil
+}
+
+func (a API) AdjustStock(request *storage.AdjustStockReq, response *storage.StockResp) (err error) {
+	ctx, span := tracing.Start(context.Background(), "Storage.AdjustStock")
+	defer tracing.End(span, &err)
+	ctx, cancel := context.WithTimeout(ctx, a.requestTimeout)
+	defer cancel()
+	resp, err := a.service.AdjustStock(ctx, *request)
+	if err != nil {
+		return api.RPCError(err)
+	}
+	*response = *resp
+	return nil
+}
+
+func (a API) SetStorageAvailability(request *storage.SetStorageAvailabilityReq, response *storage.SetStorageAvailabilityResp) (err error) {
+	ctx, span := tracing.Start(context.Background(), "Storage.SetStorageAvailability")
+	defer tracing.End(span, &err)
+	ctx, cancel := context.WithTimeout(ctx, a.requestTimeout)
+	defer cancel()
+	resp, err := a.service.SetStorageAvailability(ctx, *request)
+	if err != nil {
+		return api.RPCError(err)
 	}
 	*response = *resp
 	return nil
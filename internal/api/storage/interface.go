@@ -8,8 +8,11 @@ import (
 
 type UseCase interface {
 	DefineStorageSchema(ctx context.Context, req storage.StorageSchemaReq) (storage.StorageSchemaResp, error)
-	GetStorageSchema(ctx context.Context) (storage.StorageSchemaResp, error)
+	GetStorageSchema(ctx context.Context, req storage.GetStorageSchemaReq) (storage.GetStorageSchemaResp, error)
 	GetUnreservedStorage(ctx context.Context, storageID entity.PK) (*storage.StorageSchemaRespItem, error)
+	ReceiveStock(ctx context.Context, req storage.ReceiveStockReq) (*storage.StockResp, error)
+	AdjustStock(ctx context.Context, req storage.AdjustStockReq) (*storage.StockResp, error)
+	SetStorageAvailability(ctx context.Context, req storage.SetStorageAvailabilityReq) (*storage.SetStorageAvailabilityResp, error)
 }
 
 var _ UseCase = (*storage.Service)(nil)
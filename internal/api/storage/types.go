@@ -1,5 +1,5 @@
 package storage
 
-type GetUnreservedStorageReq struct {
-	StorageID uint `json:"storage_id"`
-}
+import "storageapi/pkg/model"
+
+type GetUnreservedStorageReq = model.GetUnreservedStorageReq
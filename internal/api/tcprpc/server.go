@@ -0,0 +1,225 @@
+// Package tcprpc - JSON-RPC (net/rpc/jsonrpc) сервер поверх TCP с плавной остановкой
+package tcprpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"storageapi/internal/api"
+	"storageapi/internal/metrics"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Serve возвращает ErrServerClosed после Shutdown
+var ErrServerClosed = errors.New("tcprpc: server closed")
+
+// Server принимает соединения и обслуживает их методами rpc.Server.
+// Shutdown перестает принимать соединения, закрывает простаивающие
+// и ждет, пока ответят на уже начатые запросы
+type Server struct {
+	server *rpc.Server
+	log    *zap.SugaredLogger
+
+	mu        sync.Mutex
+	listeners map[net.Listener]struct{}
+	conns     map[*connCodec]struct{}
+	draining  bool
+	// закрывается, когда при остановке не остается соединений
+	drained chan struct{}
+}
+
+func NewServer(server *rpc.Server, log *zap.SugaredLogger) *Server {
+	return &Server{
+		server:    server,
+		log:       log,
+		listeners: map[net.Listener]struct{}{},
+		conns:     map[*connCodec]struct{}{},
+		drained:   make(chan struct{}),
+	}
+}
+
+func (s *Server) Serve(listener net.Listener) error {
+	if !s.trackListener(listener) {
+		return ErrServerClosed
+	}
+	defer s.untrackListener(listener)
+
+	var backoff time.Duration
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if s.isDraining() {
+				return ErrServerClosed
+			}
+			// временная ошибка (например, кончились дескрипторы) - пауза и повтор,
+			// остальные ошибки, в том числе закрытый listener, завершают цикл
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				backoff = nextBackoff(backoff)
+				s.log.Warnw("accept failed, retrying", "error", err, "backoff", backoff)
+				time.Sleep(backoff)
+				continue
+			}
+			return err
+		}
+		backoff = 0
+		codec := &connCodec{ServerCodec: jsonrpc.NewServerCodec(conn), server: s}
+		if !s.trackConn(codec) {
+			conn.Close()
+			return ErrServerClosed
+		}
+		go func() {
+			s.server.ServeCodec(api.ValidatingCodec(metrics.RPCCodec(metrics.TransportTCP, codec)))
+			s.untrackConn(codec)
+		}()
+	}
+}
+
+func nextBackoff(d time.Duration) time.Duration {
+	if d == 0 {
+		return 5 * time.Millisecond
+	}
+	if d *= 2; d > time.Second {
+		return time.Second
+	}
+	return d
+}
+
+// Shutdown закрывает listener'ы и простаивающие соединения, затем ждет
+// завершения начатых запросов. по истечении ctx оставшиеся соединения
+// закрываются принудительно и возвращается ошибка ctx
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	if !s.draining {
+		s.draining = true
+		for l := range s.listeners {
+			l.Close()
+		}
+		for c := range s.conns {
+			c.closeIfIdle()
+		}
+		s.checkDrained()
+	}
+	s.mu.Unlock()
+
+	select {
+	case <-s.drained:
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		for c := range s.conns {
+			c.Close()
+		}
+		s.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+func (s *Server) isDraining() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.draining
+}
+
+func (s *Server) trackListener(l net.Listener) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.draining {
+		return false
+	}
+	s.listeners[l] = struct{}{}
+	return true
+}
+
+func (s *Server) untrackListener(l net.Listener) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.listeners, l)
+}
+
+func (s *Server) trackConn(c *connCodec) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.draining {
+		return false
+	}
+	s.conns[c] = struct{}{}
+	return true
+}
+
+func (s *Server) untrackConn(c *connCodec) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.conns, c)
+	if s.draining {
+		s.checkDrained()
+	}
+}
+
+// вызывается под s.mu
+func (s *Server) checkDrained() {
+	if len(s.conns) != 0 {
+		return
+	}
+	select {
+	case <-s.drained:
+	default:
+		close(s.drained)
+	}
+}
+
+// connCodec считает запросы соединения, на которые еще не ответили
+type connCodec struct {
+	rpc.ServerCodec
+	server *Server
+
+	mu     sync.Mutex
+	active int
+	closed bool
+}
+
+func (c *connCodec) ReadRequestHeader(r *rpc.Request) error {
+	err := c.ServerCodec.ReadRequestHeader(r)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	// соединение закрыли как простаивающее, пока читался заголовок: ответ
+	// отправить уже некуда, поэтому запрос не выполняется вовсе
+	if c.closed {
+		return io.EOF
+	}
+	// на каждый прочитанный заголовок net/rpc отвечает ровно один раз.
+	// счетчик растет под той же блокировкой, что проверяет closeIfIdle
+	c.active++
+	return nil
+}
+
+func (c *connCodec) WriteResponse(r *rpc.Response, body interface{}) error {
+	err := c.ServerCodec.WriteResponse(r, body)
+	c.mu.Lock()
+	c.active--
+	c.mu.Unlock()
+	if c.server.isDraining() {
+		c.closeIfIdle()
+	}
+	return err
+}
+
+// закрытие соединения завершает ServeCodec, он дожидается ответов
+// и удаляет соединение из сервера
+func (c *connCodec) closeIfIdle() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.active == 0 && !c.closed {
+		c.closed = true
+		c.ServerCodec.Close()
+	}
+}
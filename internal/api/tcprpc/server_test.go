@@ -0,0 +1,161 @@
+package tcprpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type SleepReq struct {
+	MS int `json:"ms"`
+}
+
+type SleepResp struct {
+	Done bool `json:"done"`
+}
+
+type Sleeper struct {
+	started chan struct{}
+}
+
+func (s Sleeper) Sleep(request *SleepReq, response *SleepResp) error {
+	s.started <- struct{}{}
+	time.Sleep(time.Duration(request.MS) * time.Millisecond)
+	response.Done = true
+	return nil
+}
+
+func start(t *testing.T) (*Server, string, chan struct{}, chan error) {
+	started := make(chan struct{}, 1)
+	rpcServer := rpc.NewServer()
+	if err := rpcServer.RegisterName("Test", Sleeper{started: started}); err != nil {
+		t.Fatal(err)
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewServer(rpcServer, zap.NewNop().Sugar())
+	served := make(chan error, 1)
+	go func() {
+		served <- s.Serve(listener)
+	}()
+	return s, listener.Addr().String(), started, served
+}
+
+func dial(t *testing.T, addr string) *rpc.Client {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := jsonrpc.NewClient(conn)
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestShutdownWaitsForActiveRequests(t *testing.T) {
+	s, addr, started, served := start(t)
+	busy, idle := dial(t, addr), dial(t, addr)
+
+	call := busy.Go("Test.Sleep", &SleepReq{MS: 200}, &SleepResp{}, nil)
+	<-started
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	// начатый запрос завершился до возврата Shutdown
+	select {
+	case <-call.Done:
+	case <-time.After(time.Second):
+		t.Fatal("call is not finished after shutdown")
+	}
+	if call.Error != nil || !call.Reply.(*SleepResp).Done {
+		t.Fatalf("call failed: %v", call.Error)
+	}
+	if err := <-served; !errors.Is(err, ErrServerClosed) {
+		t.Fatalf("Serve returned %v", err)
+	}
+	// простаивающее соединение закрыто, новые не принимаются
+	if err := idle.Call("Test.Sleep", &SleepReq{}, &SleepResp{}); err == nil {
+		t.Fatal("idle connection is still served")
+	}
+	if _, err := net.Dial("tcp", addr); err == nil {
+		t.Fatal("listener is still open")
+	}
+}
+
+func TestShutdownDeadline(t *testing.T) {
+	s, addr, started, _ := start(t)
+	call := dial(t, addr).Go("Test.Sleep", &SleepReq{MS: 1000}, &SleepResp{}, nil)
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := s.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Shutdown returned %v", err)
+	}
+	// соединение закрыто принудительно, клиент получает ошибку
+	<-call.Done
+	if call.Error == nil {
+		t.Fatal("call succeeded after forced close")
+	}
+}
+
+// кодек, который отдает заголовок запроса только по сигналу
+type blockingCodec struct {
+	rpc.ServerCodec
+	read   chan struct{}
+	closed chan struct{}
+}
+
+func (c *blockingCodec) ReadRequestHeader(r *rpc.Request) error {
+	<-c.read
+	r.ServiceMethod = "Test.Sleep"
+	return nil
+}
+
+func (c *blockingCodec) Close() error {
+	close(c.closed)
+	return nil
+}
+
+// заголовок дочитан, когда соединение закрывают как простаивающее:
+// запрос не должен выполниться, а занятое соединение - закрыться
+func TestHeaderReadDuringShutdown(t *testing.T) {
+	s := NewServer(rpc.NewServer(), zap.NewNop().Sugar())
+	inner := &blockingCodec{read: make(chan struct{}), closed: make(chan struct{})}
+	codec := &connCodec{ServerCodec: inner, server: s}
+	read := make(chan error, 1)
+	go func() {
+		read <- codec.ReadRequestHeader(&rpc.Request{})
+	}()
+
+	codec.closeIfIdle()
+	close(inner.read)
+	if err := <-read; !errors.Is(err, io.EOF) {
+		t.Fatalf("header read on closed connection returned %v", err)
+	}
+	if codec.active != 0 {
+		t.Fatalf("request on closed connection is counted as active: %d", codec.active)
+	}
+	// повторное закрытие не трогает уже закрытое соединение
+	codec.closeIfIdle()
+
+	// прочитанный заголовок помечает соединение занятым до ответа
+	busy := &connCodec{ServerCodec: &blockingCodec{read: inner.read, closed: make(chan struct{})}, server: s}
+	if err := busy.ReadRequestHeader(&rpc.Request{}); err != nil {
+		t.Fatal(err)
+	}
+	busy.closeIfIdle()
+	select {
+	case <-busy.ServerCodec.(*blockingCodec).closed:
+		t.Fatal("connection with an active request is closed")
+	default:
+	}
+}
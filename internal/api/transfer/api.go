@@ -0,0 +1,77 @@
+package transfer
+
+import (
+	"context"
+	"storageapi/internal/api"
+	"storageapi/internal/tracing"
+	"storageapi/internal/usecase/transfer"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type API struct {
+	log            *zap.SugaredLogger
+	service        UseCase
+	requestTimeout time.Duration
+}
+
+func NewAPI(log *zap.SugaredLogger, s UseCase, conf api.ApiConf) *API {
+	return &API{
+		log:            log,
+		service:        s,
+		requestTimeout: conf.RequestHandleTimeout,
+	}
+}
+
+func (a API) CreateTransfer(request *transfer.CreateTransferReq, response *transfer.TransferResp) (err error) {
+	ctx, span := tracing.Start(context.Background(), "Transfer.CreateTransfer")
+	defer tracing.End(span, &err)
+	ctx, cancel := context.WithTimeout(ctx, a.requestTimeout)
+	defer cancel()
+	resp, err := a.service.CreateTransfer(ctx, *request)
+	if err != nil {
+		return api.RPCError(err)
+	}
+	*response = *resp
+	return nil
+}
+
+func (a API) GetTransfer(request *transfer.GetTransferReq, response *transfer.TransferResp) (err error) {
+	ctx, span := tracing.Start(context.Background(), "Transfer.GetTransfer")
+	defer tracing.End(span, &err)
+	ctx, cancel := context.WithTimeout(ctx, a.requestTimeout)
+	defer cancel()
+	resp, err := a.service.GetTransfer(ctx, *request)
+	if err != nil {
+		return api.RPCError(err)
+	}
+	*response = *resp
+	return nil
+}
+
+func (a API) ReceiveTransfer(request *transfer.ReceiveTransferReq, response *transfer.TransferResp) (err error) {
+	ctx, span := tracing.Start(context.Background(), "Transfer.ReceiveTransfer")
+	defer tracing.End(span, &err)
+	ctx, cancel := context.WithTimeout(ctx, a.requestTimeout)
+	defer cancel()
+	resp, err := a.service.ReceiveTransfer(ctx, *request)
+	if err != nil {
+		return api.RPCError(err)
+	}
+	*response = *resp
+	return nil
+}
+
+func (a API) CancelTransfer(request *transfer.CancelTransferReq, response *transfer.TransferResp) (err error) {
+	ctx, span := tracing.Start(context.Background(), "Transfer.CancelTransfer")
+	defer tracing.End(span, &err)
+	ctx, cancel := context.WithTimeout(ctx, a.requestTimeout)
+	defer cancel()
+	resp, err := a.service.CancelTransfer(ctx, *request)
+	if err != nil {
+		return api.RPCError(err)
+	}
+	*response = *resp
+	return nil
+}
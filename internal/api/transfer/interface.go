@@ -0,0 +1,15 @@
+package transfer
+
+import (
+	"context"
+	"storageapi/internal/usecase/transfer"
+)
+
+type UseCase interface {
+	CreateTransfer(ctx context.Context, req transfer.CreateTransferReq) (*transfer.TransferResp, error)
+	GetTransfer(ctx context.Context, req transfer.GetTransferReq) (*transfer.TransferResp, error)
+	ReceiveTransfer(ctx context.Context, req transfer.ReceiveTransferReq) (*transfer.TransferResp, error)
+	CancelTransfer(ctx context.Context, req transfer.CancelTransferReq) (*transfer.TransferResp, error)
+}
+
+var _ UseCase = (*transfer.Service)(nil)
@@ -0,0 +1,38 @@
+package api
+
+import "net/rpc"
+
+// запрос, который умеет проверить свои поля (типы pkg/model)
+type Validator interface {
+	Validate() error
+}
+
+// Validate возвращает ошибку со всеми неверными полями req, если он их проверяет
+func Validate(req interface{}) error {
+	if v, ok := req.(Validator); ok {
+		return v.Validate()
+	}
+	return nil
+}
+
+// validatingCodec проверяет аргументы каждого вызова net/rpc до того,
+// как они попадут в метод api. ошибку net/rpc отдает клиенту вместо вызова
+type validatingCodec struct {
+	rpc.ServerCodec
+}
+
+// ValidatingCodec оборачивает codec транспорта (TCP JSON-RPC, HTTP JSON-RPC)
+func ValidatingCodec(codec rpc.ServerCodec) rpc.ServerCodec {
+	return validatingCodec{codec}
+}
+
+func (c validatingCodec) ReadRequestBody(body interface{}) error {
+	if err := c.ServerCodec.ReadRequestBody(body); err != nil {
+		return err
+	}
+	// body == nil - метод не найден, тело пропускается
+	if body == nil {
+		return nil
+	}
+	return RPCError(Validate(body))
+}
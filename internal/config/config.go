@@ -4,23 +4,152 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
 var DatabaseURL = os.Getenv("DATABASE_URL")
 var ListenerPort int
+var HTTPListenerPort int // 0 - http транспорт выключен
+var GRPCListenerPort int // 0 - grpc транспорт выключен
 var RequestHandleTimeout time.Duration
+var ShutdownTimeout = 10 * time.Second // сколько ждать начатые запросы при остановке
+// пауза между отказом /readyz и закрытием listener'ов, чтобы балансировщик успел убрать сервер
+var ShutdownDelay time.Duration
+var HealthCheckTimeout = 2 * time.Second // таймаут проверок /readyz
+// пауза между попытками подключиться к бд при старте
+var DatabaseConnectMinBackoff = 500 * time.Millisecond
+var DatabaseConnectMaxBackoff = 10 * time.Second
+
+// хранилище данных: postgres или memory (в памяти процесса, без бд, для тестов и демо)
+var RepositoryBackend = RepositoryPostgres
 var FixturesPath = "./fixtures"
 var MigrationDialect = "postgres"
+var ReservationReapInterval = 5 * time.Second
+var ReservationReapBatchSize uint = 100
+
+// сколько хранится ключ идемпотентности, повтор после этого выполняется как новый запрос
+var IdempotencyKeyTTL = 24 * time.Hour
+var IdempotencyKeyReapInterval = time.Minute
+var IdempotencyKeyReapBatchSize uint = 1000
+var DefaultAllocationStrategy = "largest_first"
+var AllocationStoragePriority []uint
+
+// экспортер спанов: пусто - выключено, otlp (адрес в OTEL_EXPORTER_OTLP_ENDPOINT), stdout, file
+var TracesExporter = os.Getenv("TRACES_EXPORTER")
+var TracesFile = os.Getenv("TRACES_FILE") // файл для TRACES_EXPORTER=file
+
+const (
+	RepositoryPostgres = "postgres"
+	RepositoryMemory   = "memory"
+)
 
-func init() {
+// Load читает настройки из переменных окружения, вызывается в начале main.
+// тесты, которые запускают сервер в своем процессе, задают переменные пакета сами
+func Load() {
 	var err error
 	if ListenerPort, err = strconv.Atoi(os.Getenv("LISTENER_PORT")); err != nil {
 		log.Fatal(err)
 	}
+	if v := os.Getenv("HTTP_LISTENER_PORT"); v != "" {
+		if HTTPListenerPort, err = strconv.Atoi(v); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if v := os.Getenv("GRPC_LISTENER_PORT"); v != "" {
+		if GRPCListenerPort, err = strconv.Atoi(v); err != nil {
+			log.Fatal(err)
+		}
+	}
 	reqHandleTimeoutMS, err := strconv.Atoi(os.Getenv("REQUEST_HANDLE_TIMEOUT_MS"))
 	if err != nil {
 		log.Fatal(err)
 	}
 	RequestHandleTimeout = time.Millisecond * time.Duration(reqHandleTimeoutMS)
+	if v := os.Getenv("SHUTDOWN_TIMEOUT_MS"); v != "" {
+		shutdownTimeoutMS, err := strconv.Atoi(v)
+		if err != nil {
+			log.Fatal(err)
+		}
+		ShutdownTimeout = time.Millisecond * time.Duration(shutdownTimeoutMS)
+	}
+	if v := os.Getenv("SHUTDOWN_DELAY_MS"); v != "" {
+		shutdownDelayMS, err := strconv.Atoi(v)
+		if err != nil {
+			log.Fatal(err)
+		}
+		ShutdownDelay = time.Millisecond * time.Duration(shutdownDelayMS)
+	}
+	if v := os.Getenv("HEALTH_CHECK_TIMEOUT_MS"); v != "" {
+		healthCheckTimeoutMS, err := strconv.Atoi(v)
+		if err != nil {
+			log.Fatal(err)
+		}
+		HealthCheckTimeout = time.Millisecond * time.Duration(healthCheckTimeoutMS)
+	}
+	if v := os.Getenv("DATABASE_CONNECT_MIN_BACKOFF_MS"); v != "" {
+		minBackoffMS, err := strconv.Atoi(v)
+		if err != nil {
+			log.Fatal(err)
+		}
+		DatabaseConnectMinBackoff = time.Millisecond * time.Duration(minBackoffMS)
+	}
+	if v := os.Getenv("DATABASE_CONNECT_MAX_BACKOFF_MS"); v != "" {
+		maxBackoffMS, err := strconv.Atoi(v)
+		if err != nil {
+			log.Fatal(err)
+		}
+		DatabaseConnectMaxBackoff = time.Millisecond * time.Duration(maxBackoffMS)
+	}
+	if v := os.Getenv("REPOSITORY_BACKEND"); v != "" {
+		RepositoryBackend = v
+	}
+	if v := os.Getenv("RESERVATION_REAP_INTERVAL_MS"); v != "" {
+		reapIntervalMS, err := strconv.Atoi(v)
+		if err != nil {
+			log.Fatal(err)
+		}
+		ReservationReapInterval = time.Millisecond * time.Duration(reapIntervalMS)
+	}
+	if v := os.Getenv("RESERVATION_REAP_BATCH_SIZE"); v != "" {
+		batchSize, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			log.Fatal(err)
+		}
+		ReservationReapBatchSize = uint(batchSize)
+	}
+	if v := os.Getenv("IDEMPOTENCY_KEY_TTL_MS"); v != "" {
+		ttlMS, err := strconv.Atoi(v)
+		if err != nil {
+			log.Fatal(err)
+		}
+		IdempotencyKeyTTL = time.Millisecond * time.Duration(ttlMS)
+	}
+	if v := os.Getenv("IDEMPOTENCY_KEY_REAP_INTERVAL_MS"); v != "" {
+		reapIntervalMS, err := strconv.Atoi(v)
+		if err != nil {
+			log.Fatal(err)
+		}
+		IdempotencyKeyReapInterval = time.Millisecond * time.Duration(reapIntervalMS)
+	}
+	if v := os.Getenv("IDEMPOTENCY_KEY_REAP_BATCH_SIZE"); v != "" {
+		batchSize, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			log.Fatal(err)
+		}
+		IdempotencyKeyReapBatchSize = uint(batchSize)
+	}
+	if v := os.Getenv("DEFAULT_ALLOCATION_STRATEGY"); v != "" {
+		DefaultAllocationStrategy = v
+	}
+	// список id складов через запятую, например "3,1,2"
+	if v := os.Getenv("ALLOCATION_STORAGE_PRIORITY"); v != "" {
+		for _, idStr := range strings.Split(v, ",") {
+			id, err := strconv.ParseUint(strings.TrimSpace(idStr), 10, 64)
+			if err != nil {
+				log.Fatal(err)
+			}
+			AllocationStoragePriority = append(AllocationStoragePriority, uint(id))
+		}
+	}
 }
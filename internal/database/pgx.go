@@ -3,12 +3,53 @@ package database
 import (
 	"context"
 	"errors"
+	"fmt"
+	"math/rand"
+	"storageapi/internal/tracing"
+	"sync/atomic"
+	"time"
 
 	"github.com/jackc/pgx"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.uber.org/multierr"
 )
 
 type DB struct {
 	*pgx.ConnPool
+
+	poolExhausted atomic.Uint64
+	txRetries     atomic.Uint64
+	txRollbacks   atomic.Uint64
+}
+
+// статистика пула и транзакций для метрик
+type Stats struct {
+	pgx.ConnPoolStat
+	// запросы, начатые, когда все соединения пула были заняты. pgx не считает
+	// ожидания, поэтому это оценка: такой запрос скорее всего ждал соединение
+	PoolExhausted uint64
+	// перезапуски транзакций после serialization failure или дедлока
+	TxRetries   uint64
+	TxRollbacks uint64
+}
+
+func (db *DB) Stats() Stats {
+	return Stats{
+		ConnPoolStat:  db.ConnPool.Stat(),
+		PoolExhausted: db.poolExhausted.Load(),
+		TxRetries:     db.txRetries.Load(),
+		TxRollbacks:   db.txRollbacks.Load(),
+	}
+}
+
+// пул создает соединения до MaxConnections, дальше запрос ждет освобождения.
+// отмечает запрос, если перед ним пул исчерпан
+func (db *DB) notePoolExhausted() {
+	s := db.ConnPool.Stat()
+	if s.AvailableConnections == 0 && s.CurrentConnections >= s.MaxConnections {
+		db.poolExhausted.Add(1)
+	}
 }
 
 func NewDBWithPgx(url string) (*DB, error) {
@@ -27,82 +68,189 @@ func NewDBWithPgx(url string) (*DB, error) {
 	}, nil
 }
 
+// Ping проверяет, что пул выдает живое соединение
+func (db *DB) Ping(ctx context.Context) error {
+	db.notePoolExhausted()
+	conn, err := db.ConnPool.AcquireEx(ctx)
+	if err != nil {
+		return err
+	}
+	defer db.ConnPool.Release(conn)
+	return conn.Ping(ctx)
+}
+
 func (db *DB) Exec(query string, args ...interface{}) (pgx.CommandTag, error) {
-	return db.ConnPool.Exec(query, args...)
+	return db.ExecContext(context.Background(), query, args...)
 }
 
 func (db *DB) ExecContext(ctx context.Context, query string, args ...interface{}) (pgx.CommandTag, error) {
-	return db.ConnPool.ExecEx(ctx, query, nil, args...)
+	db.notePoolExhausted()
+	ctx, span := startSpan(ctx, "db.exec", query)
+	tag, err := db.ConnPool.ExecEx(ctx, query, nil, args...)
+	endExec(span, tag, err)
+	return tag, err
 }
 
-func (db *DB) Query(query string, args ...interface{}) (*pgx.Rows, error) {
-	return db.ConnPool.Query(query, args...)
+func (db *DB) Query(query string, args ...interface{}) (*Rows, error) {
+	return db.QueryContext(context.Background(), query, args...)
 }
 
-func (db *DB) QueryContext(ctx context.Context, query string, args ...interface{}) (*pgx.Rows, error) {
-	return db.ConnPool.QueryEx(ctx, query, nil, args...)
+func (db *DB) QueryContext(ctx context.Context, query string, args ...interface{}) (*Rows, error) {
+	db.notePoolExhausted()
+	ctx, span := startSpan(ctx, "db.query", query)
+	rows, err := db.ConnPool.QueryEx(ctx, query, nil, args...)
+	return tracedRows(span, rows, err)
 }
 
 func (db *DB) QueryRow(query string, args ...interface{}) *pgx.Row {
-	return db.ConnPool.QueryRow(query, args...)
+	return db.QueryRowContext(context.Background(), query, args...)
 }
 
 func (db *DB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *pgx.Row {
-	return db.ConnPool.QueryRowEx(ctx, query, nil, args...)
+	db.notePoolExhausted()
+	ctx, span := startSpan(ctx, "db.query_row", query)
+	return tracedRow(span, db.ConnPool.QueryRowEx(ctx, query, nil, args...))
 }
 
+// сколько раз транзакция перезапускается при ошибке сериализации или дедлоке
+const txMaxAttempts = 5
+
+// RunInTransaction выполняет fn в транзакции. если в ctx уже есть транзакция,
+// fn выполняется во вложенной (savepoint) и при ошибке откатывается только она
 func (db *DB) RunInTransaction(ctx context.Context, fn func(ctx TxContext) error) error {
-	var txCtx TxContext
-	if tx := GetTX(ctx); tx == nil {
-		pgTx, err := db.ConnPool.Begin()
-		if err != nil {
+	if tx := GetTX(ctx); tx != nil {
+		return tx.RunInTransaction(ctx, fn)
+	}
+
+	ctx, span := tracing.Start(ctx, "db.transaction", semconv.DBSystemPostgreSQL)
+	var err error
+	defer tracing.End(span, &err)
+	for attempt := 1; attempt <= txMaxAttempts; attempt++ {
+		span.SetAttributes(attribute.Int("db.tx.attempts", attempt))
+		// после последней попытки ошибка возвращается сразу, без паузы,
+		// и в txRetries попадают только действительные перезапуски
+		if err = db.runInNewTransaction(ctx, fn); !isRetryable(err) || attempt == txMaxAttempts {
+			return err
+		}
+		db.txRetries.Add(1)
+		// небольшая пауза со случайным разбросом, чтобы конкурирующие
+		// транзакции не столкнулись снова
+		backoff := time.Duration(attempt)*10*time.Millisecond + time.Duration(rand.Int63n(int64(10*time.Millisecond)))
+		select {
+		case <-ctx.Done():
 			return err
+		case <-time.After(backoff):
 		}
-		tx = &Tx{pgTx}
-		txCtx = WithTX(ctx, tx)
-	} else {
-		var ok bool
-		txCtx, ok = ctx.(TxContext)
-		if !ok {
-			return errors.New("runtime error: incorrect txCtx type assertion") // такого вообще не должно возникать
+	}
+	return err
+}
+
+// ошибка fn возвращается вместе с ошибкой отката, если откатить не удалось.
+// при панике в fn транзакция откатывается, а паника идет дальше
+func (db *DB) runInNewTransaction(ctx context.Context, fn func(ctx TxContext) error) error {
+	db.notePoolExhausted()
+	pgTx, err := db.ConnPool.BeginEx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	tx := &Tx{Tx: pgTx}
+	finished := false
+	defer func() {
+		if !finished {
+			tx.Rollback()
+			db.txRollbacks.Add(1)
 		}
+	}()
+	err = fn(WithTX(ctx, tx))
+	finished = true
+	if err != nil {
+		db.txRollbacks.Add(1)
+		return multierr.Append(err, tx.Rollback())
 	}
-	if err := fn(txCtx); err != nil {
-		return txCtx.TX().Rollback()
+	// при ошибке commit postgres откатывает транзакцию сам
+	if err := tx.Commit(); err != nil {
+		db.txRollbacks.Add(1)
+		return err
 	}
-	return txCtx.TX().Commit()
+	return nil
+}
+
+// ошибки, после которых транзакцию можно безопасно повторить целиком
+func isRetryable(err error) bool {
+	var pgErr pgx.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+	switch pgErr.Code {
+	case "40001", // serialization_failure
+		"40P01": // deadlock_detected
+		return true
+	}
+	return false
 }
 
 type Tx struct {
 	*pgx.Tx
+	// счетчик для имен savepoint'ов вложенных транзакций
+	savepoints int
 }
 
 func (tx *Tx) Exec(query string, args ...interface{}) (pgx.CommandTag, error) {
-	return tx.Tx.Exec(query)
+	return tx.ExecContext(context.Background(), query, args...)
 }
 
 func (tx *Tx) ExecContext(ctx context.Context, query string, args ...interface{}) (pgx.CommandTag, error) {
-	return tx.Tx.ExecEx(ctx, query, nil, args...)
+	ctx, span := startSpan(ctx, "db.exec", query)
+	tag, err := tx.Tx.ExecEx(ctx, query, nil, args...)
+	endExec(span, tag, err)
+	return tag, err
 }
 
-func (tx *Tx) Query(query string, args ...interface{}) (*pgx.Rows, error) {
-	return tx.Tx.Query(query, args...)
+func (tx *Tx) Query(query string, args ...interface{}) (*Rows, error) {
+	return tx.QueryContext(context.Background(), query, args...)
 }
 
-func (tx *Tx) QueryContext(ctx context.Context, query string, args ...interface{}) (*pgx.Rows, error) {
-	return tx.Tx.QueryEx(ctx, query, nil, args...)
+func (tx *Tx) QueryContext(ctx context.Context, query string, args ...interface{}) (*Rows, error) {
+	ctx, span := startSpan(ctx, "db.query", query)
+	rows, err := tx.Tx.QueryEx(ctx, query, nil, args...)
+	return tracedRows(span, rows, err)
 }
 
 func (tx *Tx) QueryRow(query string, args ...interface{}) *pgx.Row {
-	return tx.Tx.QueryRow(query, args...)
+	return tx.QueryRowContext(context.Background(), query, args...)
 }
 
 func (tx *Tx) QueryRowContext(ctx context.Context, query string, args ...interface{}) *pgx.Row {
-	return tx.Tx.QueryRowEx(ctx, query, nil, args...)
+	ctx, span := startSpan(ctx, "db.query_row", query)
+	return tracedRow(span, tx.Tx.QueryRowEx(ctx, query, nil, args...))
 }
 
-func (tx *Tx) RunInTransaction(ctx context.Context, fn func(ctx TxContext) error) error {
-	return fn(WithTX(ctx, tx))
+// RunInTransaction выполняет fn во вложенной транзакции: ошибка или паника
+// в fn откатывают изменения до savepoint, а внешняя транзакция продолжается
+func (tx *Tx) RunInTransaction(ctx context.Context, fn func(ctx TxContext) error) (err error) {
+	tx.savepoints++
+	name := fmt.Sprintf("sp_%d", tx.savepoints)
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT "+name); err != nil {
+		return err
+	}
+	// откат не зависит от отмены ctx, как и Rollback
+	rollback := func() error {
+		_, err := tx.ExecContext(context.Background(), "ROLLBACK TO SAVEPOINT "+name)
+		return err
+	}
+	finished := false
+	defer func() {
+		if !finished {
+			rollback()
+		}
+	}()
+	err = fn(WithTX(ctx, tx))
+	finished = true
+	if err != nil {
+		return multierr.Append(err, rollback())
+	}
+	_, err = tx.ExecContext(ctx, "RELEASE SAVEPOINT "+name)
+	return err
 }
 
 type ctxValKey string
@@ -0,0 +1,65 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"storageapi/internal/test/testdb"
+	"testing"
+
+	"github.com/jackc/pgx"
+)
+
+func TestMain(m *testing.M) {
+	testdb.Main(m)
+}
+
+// Tx.Exec передает аргументы запроса (раньше они терялись и запрос
+// с параметрами падал)
+func TestTxExecArgs(t *testing.T) {
+	db, err := NewDBWithPgx(testdb.New(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var count int
+	err = db.RunInTransaction(context.Background(), func(ctx TxContext) error {
+		tx := GetTX(ctx)
+		if _, err := tx.Exec("INSERT INTO storages (is_available) VALUES ($1), ($1)", false); err != nil {
+			return err
+		}
+		return tx.QueryRow("SELECT COUNT(*) FROM storages WHERE is_available = $1", false).Scan(&count)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Fatalf("expected 2 unavailable storages, got %d", count)
+	}
+}
+
+// транзакция повторяется не больше txMaxAttempts раз, последняя ошибка
+// возвращается без лишнего перезапуска
+func TestRunInTransactionRetries(t *testing.T) {
+	db, err := NewDBWithPgx(testdb.New(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	attempts := 0
+	serializationFailure := pgx.PgError{Code: "40001"}
+	err = db.RunInTransaction(context.Background(), func(ctx TxContext) error {
+		attempts++
+		return serializationFailure
+	})
+	if !errors.As(err, &pgx.PgError{}) {
+		t.Fatalf("expected serialization failure, got %v", err)
+	}
+	if attempts != txMaxAttempts {
+		t.Fatalf("expected %d attempts, got %d", txMaxAttempts, attempts)
+	}
+	if retries := db.Stats().TxRetries; retries != txMaxAttempts-1 {
+		t.Fatalf("expected %d retries, got %d", txMaxAttempts-1, retries)
+	}
+}
@@ -0,0 +1,71 @@
+package database
+
+import (
+	"context"
+	"storageapi/internal/tracing"
+
+	"github.com/jackc/pgx"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Rows - результат Query. считает прочитанные строки и завершает
+// спан запроса, когда строки закончились или rows закрыт
+type Rows struct {
+	*pgx.Rows
+
+	span  trace.Span
+	count int
+	ended bool
+}
+
+func (r *Rows) Next() bool {
+	if r.Rows.Next() {
+		r.count++
+		return true
+	}
+	// pgx закрывает rows, когда строки кончились
+	r.end()
+	return false
+}
+
+func (r *Rows) Close() {
+	r.Rows.Close()
+	r.end()
+}
+
+func (r *Rows) end() {
+	if r.ended {
+		return
+	}
+	r.ended = true
+	r.span.SetAttributes(attribute.Int("db.rows", r.count))
+	err := r.Rows.Err()
+	tracing.End(r.span, &err)
+}
+
+func startSpan(ctx context.Context, name, query string) (context.Context, trace.Span) {
+	return tracing.Start(ctx, name, semconv.DBSystemPostgreSQL, semconv.DBStatement(query))
+}
+
+func tracedRows(span trace.Span, rows *pgx.Rows, err error) (*Rows, error) {
+	if err != nil {
+		tracing.End(span, &err)
+		return nil, err
+	}
+	return &Rows{Rows: rows, span: span}, nil
+}
+
+func endExec(span trace.Span, tag pgx.CommandTag, err error) {
+	if err == nil {
+		span.SetAttributes(attribute.Int64("db.rows_affected", tag.RowsAffected()))
+	}
+	tracing.End(span, &err)
+}
+
+// строка QueryRow читается в Scan, поэтому спан покрывает только отправку запроса
+func tracedRow(span trace.Span, row *pgx.Row) *pgx.Row {
+	span.End()
+	return row
+}
@@ -1,6 +1,6 @@
 package entity
 
-import "github.com/jackc/pgx"
+import "time"
 
 type PK uint
 
@@ -19,7 +19,7 @@ type Storage struct {
 
 var _ IEntity = (*Storage)(nil)
 
-func (s *Storage) Scan(rows *pgx.Rows) error {
+func (s *Storage) Scan(rows Scanner) error {
 	return rows.Scan(&s.ID, &s.IsAvailable)
 }
 
@@ -34,7 +34,7 @@ type Product struct {
 
 var _ IEntity = (*Product)(nil)
 
-func (p *Product) Scan(rows *pgx.Rows) error {
+func (p *Product) Scan(rows Scanner) error {
 	return rows.Scan(&p.ID, &p.Name, &p.Vendor, &p.Size)
 }
 
@@ -47,7 +47,7 @@ type StoredProduct struct {
 
 var _ IEntity = (*StoredProduct)(nil)
 
-func (sp *StoredProduct) Scan(rows *pgx.Rows) error {
+func (sp *StoredProduct) Scan(rows Scanner) error {
 	return rows.Scan(&sp.ID, &sp.StorageID, &sp.ProductID, &sp.Amount)
 }
 
@@ -60,21 +60,196 @@ type ProductReservation struct {
 
 var _ IEntity = (*ProductReservation)(nil)
 
-func (pr *ProductReservation) Scan(rows *pgx.Rows) error {
+func (pr *ProductReservation) Scan(rows Scanner) error {
 	return rows.Scan(&pr.ID, &pr.StorageID, &pr.ProductID, &pr.Amount)
 }
 
-func ScannedRows[T IEntity](rows *pgx.Rows) (result []T, err error) {
+const (
+	ReservationStatusActive    = "active"
+	ReservationStatusCancelled = "cancelled"
+	ReservationStatusExpired   = "expired"
+	ReservationStatusConfirmed = "confirmed"
+)
+
+// заказ (резерв клиента), объединяет позиции резерва по складам
+type ReservationOrder struct {
+	ID        PK         `db:"id"`
+	Status    string     `db:"status"`
+	CreatedAt time.Time  `db:"created_at"`
+	ExpiresAt *time.Time `db:"expires_at"` // nil - бессрочный резерв
+}
+
+var _ IEntity = (*ReservationOrder)(nil)
+
+func (ro *ReservationOrder) Scan(rows Scanner) error {
+	return rows.Scan(&ro.ID, &ro.Status, &ro.CreatedAt, &ro.ExpiresAt)
+}
+
+type ReservationOrderItem struct {
+	ID            PK   `db:"id"`
+	ReservationID PK   `db:"reservation_id"`
+	StorageID     PK   `db:"storage_id"`
+	ProductID     PK   `db:"product_id"`
+	Amount        uint `db:"amount"`
+}
+
+var _ IEntity = (*ReservationOrderItem)(nil)
+
+func (ri *ReservationOrderItem) Scan(rows Scanner) error {
+	return rows.Scan(&ri.ID, &ri.ReservationID, &ri.StorageID, &ri.ProductID, &ri.Amount)
+}
+
+// отгрузка подтвержденного заказа
+type Shipment struct {
+	ID            PK        `db:"id"`
+	ReservationID PK        `db:"reservation_id"`
+	CreatedAt     time.Time `db:"created_at"`
+}
+
+var _ IEntity = (*Shipment)(nil)
+
+func (s *Shipment) Scan(rows Scanner) error {
+	return rows.Scan(&s.ID, &s.ReservationID, &s.CreatedAt)
+}
+
+type ShipmentItem struct {
+	ID         PK   `db:"id"`
+	ShipmentID PK   `db:"shipment_id"`
+	StorageID  PK   `db:"storage_id"`
+	ProductID  PK   `db:"product_id"`
+	Amount     uint `db:"amount"`
+}
+
+var _ IEntity = (*ShipmentItem)(nil)
+
+func (si *ShipmentItem) Scan(rows Scanner) error {
+	return rows.Scan(&si.ID, &si.ShipmentID, &si.StorageID, &si.ProductID, &si.Amount)
+}
+
+// причины изменения остатков
+const (
+	StockReasonReceipt = "receipt"
+	// причины ручной корректировки, их же принимает AdjustStockReq
+	StockReasonDamage     = "damage"
+	StockReasonLoss       = "loss"
+	StockReasonRecount    = "recount"
+	StockReasonCorrection = "correction"
+
+	StockReasonTransferOut    = "transfer_out"
+	StockReasonTransferIn     = "transfer_in"
+	StockReasonTransferCancel = "transfer_cancel"
+)
+
+type StockAdjustment struct {
+	ID        PK        `db:"id"`
+	StorageID PK        `db:"storage_id"`
+	ProductID PK        `db:"product_id"`
+	Delta     int       `db:"delta"`
+	Reason    string    `db:"reason"`
+	Comment   string    `db:"comment"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+var _ IEntity = (*StockAdjustment)(nil)
+
+func (sa *StockAdjustment) Scan(rows Scanner) error {
+	return rows.Scan(&sa.ID, &sa.StorageID, &sa.ProductID, &sa.Delta, &sa.Reason, &sa.Comment, &sa.CreatedAt)
+}
+
+const (
+	TransferStatusInTransit = "in_transit"
+	TransferStatusReceived  = "received"
+	TransferStatusCancelled = "cancelled"
+)
+
+// перемещение товаров между складами
+type Transfer struct {
+	ID                   PK        `db:"id"`
+	SourceStorageID      PK        `db:"source_storage_id"`
+	DestinationStorageID PK        `db:"destination_storage_id"`
+	Status               string    `db:"status"`
+	CreatedAt            time.Time `db:"created_at"`
+	UpdatedAt            time.Time `db:"updated_at"`
+}
+
+var _ IEntity = (*Transfer)(nil)
+
+func (t *Transfer) Scan(rows Scanner) error {
+	return rows.Scan(&t.ID, &t.SourceStorageID, &t.DestinationStorageID, &t.Status, &t.CreatedAt, &t.UpdatedAt)
+}
+
+type TransferItem struct {
+	ID         PK   `db:"id"`
+	TransferID PK   `db:"transfer_id"`
+	ProductID  PK   `db:"product_id"`
+	Amount     uint `db:"amount"`
+}
+
+var _ IEntity = (*TransferItem)(nil)
+
+func (ti *TransferItem) Scan(rows Scanner) error {
+	return rows.Scan(&ti.ID, &ti.TransferID, &ti.ProductID, &ti.Amount)
+}
+
+// сводка остатков склада, не таблица: считается по stored_products и product_reservations
+type StorageStock struct {
+	StorageID PK   `db:"storage_id"`
+	Stored    uint `db:"stored"`
+	Reserved  uint `db:"reserved"`
+	Free      uint `db:"free"`
+}
+
+var _ IEntity = (*StorageStock)(nil)
+
+func (ss *StorageStock) Scan(rows Scanner) error {
+	return rows.Scan(&ss.StorageID, &ss.Stored, &ss.Reserved, &ss.Free)
+}
+
+// сохраненный результат запроса с ключом идемпотентности
+type IdempotencyKey struct {
+	Method      string    `db:"method"`
+	Key         string    `db:"key"`
+	RequestHash string    `db:"request_hash"`
+	Response    []byte    `db:"response"`
+	CreatedAt   time.Time `db:"created_at"`
+}
+
+var _ IEntity = (*IdempotencyKey)(nil)
+
+func (ik *IdempotencyKey) Scan(rows Scanner) error {
+	return rows.Scan(&ik.Method, &ik.Key, &ik.RequestHash, &ik.Response, &ik.CreatedAt)
+}
+
+// T - тип сущности, PT - указатель на нее, реализующий IEntity
+// (иначе var t T для указателя дает nil и Scan падает)
+func ScannedRows[T any, PT interface {
+	*T
+	IEntity
+}](rows Rows) (result []PT, err error) {
+	defer rows.Close()
 	for rows.Next() {
-		var t T
+		var t PT = new(T)
 		if err = t.Scan(rows); err != nil {
 			return nil, err
 		}
 		result = append(result, t)
 	}
-	return
+	return result, rows.Err()
 }
 
 type IEntity interface {
-	Scan(rows *pgx.Rows) error
+	Scan(rows Scanner) error
+}
+
+// текущая строка результата запроса
+type Scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// результат запроса, реализуется database.Rows
+type Rows interface {
+	Scanner
+	Next() bool
+	Close()
+	Err() error
 }
@@ -0,0 +1,134 @@
+// Package health - пробы /healthz и /readyz для оркестратора
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// состояния в ответе /readyz
+const (
+	StatusOK       = "ok"
+	StatusStarting = "starting"
+	StatusNotReady = "not_ready"
+	StatusDraining = "draining"
+)
+
+// Check проверяет одну зависимость, ошибка - зависимость недоступна
+type Check func(ctx context.Context) error
+
+// Checker отвечает на пробы. сервер готов, когда запущен (MarkStarted),
+// не останавливается (Drain) и все проверки прошли за timeout
+type Checker struct {
+	timeout time.Duration
+
+	mu     sync.RWMutex
+	checks map[string]Check
+
+	started  atomic.Bool
+	draining atomic.Bool
+}
+
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{
+		timeout: timeout,
+		checks:  map[string]Check{},
+	}
+}
+
+func (c *Checker) Add(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks[name] = check
+}
+
+// MarkStarted вызывается, когда зависимости подключены и транспорты созданы
+func (c *Checker) MarkStarted() {
+	c.started.Store(true)
+}
+
+// Drain вызывается в начале остановки, после него сервер больше не готов
+func (c *Checker) Drain() {
+	c.draining.Store(true)
+}
+
+type Report struct {
+	Status string `json:"status"`
+	// результат каждой проверки: ok или текст ошибки
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// Ready запускает проверки параллельно. проверка, не ответившая за timeout, считается упавшей
+func (c *Checker) Ready(ctx context.Context) Report {
+	switch {
+	case c.draining.Load():
+		return Report{Status: StatusDraining}
+	case !c.started.Load():
+		return Report{Status: StatusStarting}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	c.mu.RLock()
+	names := make([]string, 0, len(c.checks))
+	for name := range c.checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	results := make([]chan error, len(names))
+	for i, name := range names {
+		results[i] = make(chan error, 1)
+		go func(check Check, result chan<- error) {
+			result <- check(ctx)
+		}(c.checks[name], results[i])
+	}
+	c.mu.RUnlock()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]string, len(names))}
+	for i, name := range names {
+		var err error
+		// проверка без поддержки ctx (goose) может зависнуть, не ждем ее дольше timeout
+		select {
+		case err = <-results[i]:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+		report.Checks[name] = StatusOK
+		if err != nil {
+			report.Status = StatusNotReady
+			report.Checks[name] = err.Error()
+		}
+	}
+	return report
+}
+
+// LivenessHandler - /healthz, процесс жив и обслуживает HTTP
+func (c *Checker) LivenessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		write(w, http.StatusOK, Report{Status: StatusOK})
+	})
+}
+
+// ReadinessHandler - /readyz, 503 пока сервер не готов принимать запросы
+func (c *Checker) ReadinessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := c.Ready(r.Context())
+		status := http.StatusOK
+		if report.Status != StatusOK {
+			status = http.StatusServiceUnavailable
+		}
+		write(w, status, report)
+	})
+}
+
+func write(w http.ResponseWriter, status int, report Report) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,79 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func probe(t *testing.T, h http.Handler) (int, Report) {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	var report Report
+	if err := json.NewDecoder(rec.Body).Decode(&report); err != nil {
+		t.Fatal(err)
+	}
+	return rec.Code, report
+}
+
+func TestReadiness(t *testing.T) {
+	c := NewChecker(50 * time.Millisecond)
+	dbErr := errors.New("connection refused")
+	var dbDown bool
+	c.Add("database", func(ctx context.Context) error {
+		if dbDown {
+			return dbErr
+		}
+		return nil
+	})
+	h := c.ReadinessHandler()
+
+	if code, report := probe(t, h); code != http.StatusServiceUnavailable || report.Status != StatusStarting {
+		t.Fatalf("before start: %d %+v", code, report)
+	}
+
+	c.MarkStarted()
+	if code, report := probe(t, h); code != http.StatusOK || report.Checks["database"] != StatusOK {
+		t.Fatalf("started: %d %+v", code, report)
+	}
+
+	dbDown = true
+	code, report := probe(t, h)
+	if code != http.StatusServiceUnavailable || report.Status != StatusNotReady || report.Checks["database"] != dbErr.Error() {
+		t.Fatalf("database down: %d %+v", code, report)
+	}
+
+	dbDown = false
+	c.Drain()
+	if code, report := probe(t, h); code != http.StatusServiceUnavailable || report.Status != StatusDraining {
+		t.Fatalf("draining: %d %+v", code, report)
+	}
+	// живость от остановки не зависит
+	if code, _ := probe(t, c.LivenessHandler()); code != http.StatusOK {
+		t.Fatalf("liveness while draining: %d", code)
+	}
+}
+
+func TestReadinessCheckTimeout(t *testing.T) {
+	c := NewChecker(20 * time.Millisecond)
+	c.MarkStarted()
+	// проверка игнорирует ctx, как goose
+	block := make(chan struct{})
+	defer close(block)
+	c.Add("migrations", func(context.Context) error {
+		<-block
+		return nil
+	})
+	start := time.Now()
+	code, report := probe(t, c.ReadinessHandler())
+	if code != http.StatusServiceUnavailable || report.Checks["migrations"] != context.DeadlineExceeded.Error() {
+		t.Fatalf("hung check: %d %+v", code, report)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("probe waited %v for a hung check", elapsed)
+	}
+}
@@ -0,0 +1,33 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/pressly/goose"
+)
+
+// Migrations проверяет, что в бд применена последняя миграция из dir.
+// список миграций читается один раз, при создании проверки
+func Migrations(db *sql.DB, dir string) (Check, error) {
+	migrations, err := goose.CollectMigrations(dir, 0, goose.MaxVersion)
+	if err != nil {
+		return nil, err
+	}
+	last, err := migrations.Last()
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context) error {
+		// goose не принимает ctx, таймаут проверки соблюдает Checker
+		version, err := goose.GetDBVersion(db)
+		if err != nil {
+			return err
+		}
+		if version != last.Version {
+			return fmt.Errorf("migration version %d, latest %d", version, last.Version)
+		}
+		return nil
+	}, nil
+}
@@ -0,0 +1,103 @@
+package metrics
+
+import (
+	"context"
+	"storageapi/internal/database"
+	"storageapi/internal/entity"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type DBStats interface {
+	Stats() database.Stats
+}
+
+// dbCollector читает статистику пула и транзакций при каждом сборе
+type dbCollector struct {
+	db DBStats
+
+	maxConns, conns, acquired, available *prometheus.Desc
+	exhausted, retries, rollbacks        *prometheus.Desc
+}
+
+func NewDBCollector(db DBStats) prometheus.Collector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "db", name), help, nil, nil)
+	}
+	return &dbCollector{
+		db:        db,
+		maxConns:  desc("pool_max_connections", "Maximum connections in the pgx pool."),
+		conns:     desc("pool_connections", "Live connections in the pgx pool."),
+		acquired:  desc("pool_acquired_connections", "Connections currently checked out of the pool."),
+		available: desc("pool_available_connections", "Idle connections in the pool."),
+		exhausted: desc("pool_exhausted_total", "Queries started while all pool connections were busy (likely waited for a connection)."),
+		retries:   desc("tx_retries_total", "Transactions restarted after a serialization failure or deadlock."),
+		rollbacks: desc("tx_rollbacks_total", "Rolled back transactions."),
+	}
+}
+
+func (c *dbCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{c.maxConns, c.conns, c.acquired, c.available, c.exhausted, c.retries, c.rollbacks} {
+		ch <- d
+	}
+}
+
+func (c *dbCollector) Collect(ch chan<- prometheus.Metric) {
+	s := c.db.Stats()
+	ch <- prometheus.MustNewConstMetric(c.maxConns, prometheus.GaugeValue, float64(s.MaxConnections))
+	ch <- prometheus.MustNewConstMetric(c.conns, prometheus.GaugeValue, float64(s.CurrentConnections))
+	ch <- prometheus.MustNewConstMetric(c.acquired, prometheus.GaugeValue, float64(s.CheckedOutConnections()))
+	ch <- prometheus.MustNewConstMetric(c.available, prometheus.GaugeValue, float64(s.AvailableConnections))
+	ch <- prometheus.MustNewConstMetric(c.exhausted, prometheus.CounterValue, float64(s.PoolExhausted))
+	ch <- prometheus.MustNewConstMetric(c.retries, prometheus.CounterValue, float64(s.TxRetries))
+	ch <- prometheus.MustNewConstMetric(c.rollbacks, prometheus.CounterValue, float64(s.TxRollbacks))
+}
+
+type StockSource interface {
+	GetStockByStorage(ctx context.Context) ([]*entity.StorageStock, error)
+}
+
+// stockCollector считает остатки складов запросом в бд при каждом сборе
+type stockCollector struct {
+	source  StockSource
+	timeout time.Duration
+
+	stored, reserved, free *prometheus.Desc
+}
+
+func NewStockCollector(source StockSource, timeout time.Duration) prometheus.Collector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "storage", name), help, []string{"storage_id"}, nil)
+	}
+	return &stockCollector{
+		source:   source,
+		timeout:  timeout,
+		stored:   desc("stored_units", "Units stored in the storage."),
+		reserved: desc("reserved_units", "Units reserved in the storage."),
+		free:     desc("free_units", "Stored units that are not reserved."),
+	}
+}
+
+func (c *stockCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.stored
+	ch <- c.reserved
+	ch <- c.free
+}
+
+func (c *stockCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+	stock, err := c.source.GetStockByStorage(ctx)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(c.stored, err)
+		return
+	}
+	for _, s := range stock {
+		id := strconv.FormatUint(uint64(s.StorageID), 10)
+		ch <- prometheus.MustNewConstMetric(c.stored, prometheus.GaugeValue, float64(s.Stored), id)
+		ch <- prometheus.MustNewConstMetric(c.reserved, prometheus.GaugeValue, float64(s.Reserved), id)
+		ch <- prometheus.MustNewConstMetric(c.free, prometheus.GaugeValue, float64(s.Free), id)
+	}
+}
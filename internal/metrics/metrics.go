@@ -0,0 +1,66 @@
+// Package metrics - метрики prometheus, отдаются по /metrics на HTTP_LISTENER_PORT
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "storageapi"
+
+// код успешного ответа, у ошибок - код errs
+const CodeOK = "ok"
+
+// транспорты, метка transport
+const (
+	TransportTCP  = "tcp"
+	TransportHTTP = "http" // JSON-RPC 2.0 поверх HTTP
+	TransportREST = "rest"
+	TransportGRPC = "grpc"
+)
+
+var registry = prometheus.NewRegistry()
+
+var (
+	rpcRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rpc_requests_total",
+		Help:      "Handled requests by transport, method and result code.",
+	}, []string{"transport", "method", "code"})
+
+	rpcDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "rpc_request_duration_seconds",
+		Help:      "Request handling latency by transport and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"transport", "method"})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		rpcRequests,
+		rpcDuration,
+	)
+}
+
+// Register добавляет коллектор (пул бд, остатки складов) в реестр /metrics
+func Register(c prometheus.Collector) error {
+	return registry.Register(c)
+}
+
+func Handler() http.Handler {
+	// ошибка одного коллектора (например, бд недоступна) не ломает остальные метрики
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{ErrorHandling: promhttp.ContinueOnError})
+}
+
+// ObserveRPC учитывает обработанный запрос, code - CodeOK или код errs
+func ObserveRPC(transport, method, code string, d time.Duration) {
+	rpcRequests.WithLabelValues(transport, method, code).Inc()
+	rpcDuration.WithLabelValues(transport, method).Observe(d.Seconds())
+}
@@ -0,0 +1,112 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/rpc"
+	"storageapi/internal/entity"
+	"storageapi/pkg/errs"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// nopCodec отдает заголовки из очереди и ничего не пишет
+type nopCodec struct {
+	headers []rpc.Request
+}
+
+func (c *nopCodec) ReadRequestHeader(r *rpc.Request) error {
+	*r, c.headers = c.headers[0], c.headers[1:]
+	return nil
+}
+func (c *nopCodec) ReadRequestBody(interface{}) error              { return nil }
+func (c *nopCodec) WriteResponse(*rpc.Response, interface{}) error { return nil }
+func (c *nopCodec) Close() error                                   { return nil }
+
+func gather(t *testing.T, g prometheus.Gatherer, name string) []*dto.Metric {
+	families, err := g.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range families {
+		if f.GetName() == name {
+			return f.GetMetric()
+		}
+	}
+	return nil
+}
+
+func label(m *dto.Metric, name string) string {
+	for _, l := range m.GetLabel() {
+		if l.GetName() == name {
+			return l.GetValue()
+		}
+	}
+	return ""
+}
+
+func TestRPCCodec(t *testing.T) {
+	codec := RPCCodec("test", &nopCodec{headers: []rpc.Request{
+		{ServiceMethod: "Storage.Get", Seq: 1},
+		{ServiceMethod: "Storage.Get", Seq: 2},
+		{ServiceMethod: "Nope.Nope", Seq: 3},
+	}})
+	for i := 0; i < 3; i++ {
+		var r rpc.Request
+		if err := codec.ReadRequestHeader(&r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	notFound := errs.Marshal(errs.New(errs.CodeNotFound, "storage not found"))
+	codec.WriteResponse(&rpc.Response{ServiceMethod: "Storage.Get", Seq: 2, Error: notFound}, nil)
+	codec.WriteResponse(&rpc.Response{ServiceMethod: "Storage.Get", Seq: 1}, nil)
+	codec.WriteResponse(&rpc.Response{ServiceMethod: "Nope.Nope", Seq: 3, Error: "rpc: can't find service Nope.Nope"}, nil)
+
+	want := map[[2]string]float64{
+		{"Storage.Get", CodeOK}:                    1,
+		{"Storage.Get", string(errs.CodeNotFound)}: 1,
+		{"unknown", string(errs.CodeInternal)}:     1,
+	}
+	got := map[[2]string]float64{}
+	for _, m := range gather(t, registry, "storageapi_rpc_requests_total") {
+		if label(m, "transport") == "test" {
+			got[[2]string{label(m, "method"), label(m, "code")}] = m.GetCounter().GetValue()
+		}
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+type stockFunc func() ([]*entity.StorageStock, error)
+
+func (f stockFunc) GetStockByStorage(context.Context) ([]*entity.StorageStock, error) {
+	return f()
+}
+
+func TestStockCollector(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(NewStockCollector(stockFunc(func() ([]*entity.StorageStock, error) {
+		return []*entity.StorageStock{{StorageID: 7, Stored: 10, Reserved: 4, Free: 6}}, nil
+	}), time.Second))
+	free := gather(t, reg, "storageapi_storage_free_units")
+	if len(free) != 1 || label(free[0], "storage_id") != "7" || free[0].GetGauge().GetValue() != 6 {
+		t.Fatalf("unexpected free units: %v", free)
+	}
+
+	reg = prometheus.NewRegistry()
+	reg.MustRegister(NewStockCollector(stockFunc(func() ([]*entity.StorageStock, error) {
+		return nil, errors.New("db is down")
+	}), time.Second))
+	if _, err := reg.Gather(); err == nil {
+		t.Fatal("collector error is not reported")
+	}
+}
@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"net/rpc"
+	"storageapi/pkg/errs"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rpcCodec учитывает каждый вызов net/rpc: метод берется из заголовка
+// запроса, код - из текста ошибки ответа (JSON errs.Error, см. api.RPCError)
+type rpcCodec struct {
+	rpc.ServerCodec
+	transport string
+
+	mu      sync.Mutex
+	started map[uint64]time.Time // по Seq запроса, по одному соединению идут параллельные запросы
+}
+
+func RPCCodec(transport string, codec rpc.ServerCodec) rpc.ServerCodec {
+	return &rpcCodec{
+		ServerCodec: codec,
+		transport:   transport,
+		started:     map[uint64]time.Time{},
+	}
+}
+
+func (c *rpcCodec) ReadRequestHeader(r *rpc.Request) error {
+	if err := c.ServerCodec.ReadRequestHeader(r); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.started[r.Seq] = time.Now()
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *rpcCodec) WriteResponse(r *rpc.Response, body interface{}) error {
+	err := c.ServerCodec.WriteResponse(r, body)
+	c.mu.Lock()
+	start, ok := c.started[r.Seq]
+	delete(c.started, r.Seq)
+	c.mu.Unlock()
+	if !ok {
+		return err
+	}
+	method, code := r.ServiceMethod, CodeOK
+	if r.Error != "" {
+		code = string(errs.Unmarshal(r.Error).Code)
+		// ошибки самого net/rpc (нет такого метода и т.п.) - метод из запроса
+		// клиента не попадает в метки, иначе их число не ограничено
+		if strings.HasPrefix(r.Error, "rpc: ") {
+			method = "unknown"
+		}
+	}
+	ObserveRPC(c.transport, method, code, time.Since(start))
+	return err
+}
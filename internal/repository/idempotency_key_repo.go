@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"storageapi/internal/entity"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type IdempotencyKeyRepository struct {
+	*repoMixin
+}
+
+var _ IIdempotencyKeyRepository = (*IdempotencyKeyRepository)(nil)
+
+func NewIdempotencyKeyRepository(db DBI, log *zap.SugaredLogger) *IdempotencyKeyRepository {
+	return &IdempotencyKeyRepository{
+		repoMixin: &repoMixin{
+			db:  db,
+			log: log,
+		},
+	}
+}
+
+// блокирует ключ до конца транзакции, чтобы параллельные повторы
+// одного запроса выполнялись по очереди. Строки ключа еще может не быть,
+// поэтому используется advisory lock
+func (r *IdempotencyKeyRepository) LockIdempotencyKey(ctx context.Context, method, key string) error {
+	_, err := r.DBI(ctx).ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtext($1::text || ':' || $2::text))", method, key)
+	return err
+}
+
+// возвращает nil, если ключ еще не использовался
+func (r *IdempotencyKeyRepository) FindIdempotencyKey(ctx context.Context, method, key string) (*entity.IdempotencyKey, error) {
+	rows, err := r.DBI(ctx).QueryContext(ctx, "SELECT * FROM idempotency_keys WHERE method = $1 AND key = $2", method, key)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := entity.ScannedRows[entity.IdempotencyKey](rows)
+	if err != nil || len(keys) == 0 {
+		return nil, err
+	}
+	return keys[0], nil
+}
+
+func (r *IdempotencyKeyRepository) CreateIdempotencyKey(ctx context.Context, k *entity.IdempotencyKey) error {
+	_, err := r.DBI(ctx).ExecContext(
+		ctx,
+		"INSERT INTO idempotency_keys (method, key, request_hash, response) VALUES ($1, $2, $3, $4)",
+		k.Method,
+		k.Key,
+		k.RequestHash,
+		k.Response,
+	)
+	return err
+}
+
+// удаляет до limit ключей, созданных не позже before, возвращает количество
+// удаленных. ключи, которые сейчас повторяет другая транзакция, пропускаются
+func (r *IdempotencyKeyRepository) DeleteExpiredIdempotencyKeys(ctx context.Context, before time.Time, limit uint) (int, error) {
+	tag, err := r.DBI(ctx).ExecContext(
+		ctx,
+		`DELETE FROM idempotency_keys WHERE (method, key) IN (
+			SELECT method, key FROM idempotency_keys
+			WHERE created_at <= $1
+			ORDER BY created_at, method, key
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)`,
+		before,
+		limit,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return int(tag.RowsAffected()), nil
+}
+
+type IIdempotencyKeyRepository interface {
+	LockIdempotencyKey(ctx context.Context, method, key string) error
+	FindIdempotencyKey(ctx context.Context, method, key string) (*entity.IdempotencyKey, error)
+	CreateIdempotencyKey(ctx context.Context, k *entity.IdempotencyKey) error
+	DeleteExpiredIdempotencyKeys(ctx context.Context, before time.Time, limit uint) (int, error)
+}
@@ -11,8 +11,8 @@ type DBI interface {
 	Exec(query string, args ...interface{}) (pgx.CommandTag, error)
 	ExecContext(ctx context.Context, query string, args ...interface{}) (pgx.CommandTag, error)
 
-	Query(query string, args ...interface{}) (*pgx.Rows, error)
-	QueryContext(ctx context.Context, query string, args ...interface{}) (*pgx.Rows, error)
+	Query(query string, args ...interface{}) (*database.Rows, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*database.Rows, error)
 
 	QueryRow(query string, args ...interface{}) *pgx.Row
 	QueryRowContext(ctx context.Context, query string, args ...interface{}) *pgx.Row
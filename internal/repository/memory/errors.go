@@ -0,0 +1,68 @@
+package memory
+
+import (
+	"fmt"
+
+	"github.com/jackc/pgx"
+)
+
+// нарушения ограничений возвращаются так же, как их возвращает pgx,
+// чтобы вызывающий код не отличал хранилища
+
+func uniqueViolation(table, constraint string) error {
+	return pgx.PgError{
+		Severity:       "ERROR",
+		Code:           "23505",
+		Message:        fmt.Sprintf("duplicate key value violates unique constraint %q", constraint),
+		TableName:      table,
+		ConstraintName: constraint,
+	}
+}
+
+func foreignKeyViolation(table, constraint string) error {
+	return pgx.PgError{
+		Severity:       "ERROR",
+		Code:           "23503",
+		Message:        fmt.Sprintf("insert or update on table %q violates foreign key constraint %q", table, constraint),
+		TableName:      table,
+		ConstraintName: constraint,
+	}
+}
+
+func checkViolation(table, constraint string) error {
+	return pgx.PgError{
+		Severity:       "ERROR",
+		Code:           "23514",
+		Message:        fmt.Sprintf("new row for relation %q violates check constraint %q", table, constraint),
+		TableName:      table,
+		ConstraintName: constraint,
+	}
+}
+
+// ON CONFLICT DO UPDATE не может обновить одну строку дважды за запрос
+func cardinalityViolation() error {
+	return pgx.PgError{
+		Severity: "ERROR",
+		Code:     "21000",
+		Message:  "ON CONFLICT DO UPDATE command cannot affect row a second time",
+	}
+}
+
+func transactionAborted() error {
+	return pgx.PgError{
+		Severity: "ERROR",
+		Code:     "25P02",
+		Message:  "current transaction is aborted, commands ignored until end of transaction block",
+	}
+}
+
+// TRUNCATE без CASCADE запрещен для таблиц, на которые ссылаются внешние ключи
+func truncateReferenced(table, referencing string) error {
+	return pgx.PgError{
+		Severity:  "ERROR",
+		Code:      "0A000",
+		Message:   "cannot truncate a table referenced in a foreign key constraint",
+		Detail:    fmt.Sprintf("Table %q references %q.", referencing, table),
+		TableName: table,
+	}
+}
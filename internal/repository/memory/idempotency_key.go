@@ -0,0 +1,77 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"storageapi/internal/entity"
+	"time"
+)
+
+type idempotencyKeyPK struct {
+	method, key string
+}
+
+// транзакции и так выполняются по одной, повторы одного запроса не пересекаются
+func (r *Repository) LockIdempotencyKey(ctx context.Context, method, key string) error {
+	return nil
+}
+
+// возвращает nil, если ключ еще не использовался
+func (r *Repository) FindIdempotencyKey(ctx context.Context, method, key string) (*entity.IdempotencyKey, error) {
+	var result *entity.IdempotencyKey
+	err := r.exec(ctx, func(t *tables) error {
+		if k, ok := t.idempotencyKeys[idempotencyKeyPK{method, key}]; ok {
+			result = &k
+		}
+		return nil
+	})
+	return result, err
+}
+
+func (r *Repository) CreateIdempotencyKey(ctx context.Context, k *entity.IdempotencyKey) error {
+	return r.exec(ctx, func(t *tables) error {
+		pk := idempotencyKeyPK{k.Method, k.Key}
+		if _, ok := t.idempotencyKeys[pk]; ok {
+			return uniqueViolation("idempotency_keys", "idempotency_keys_pkey")
+		}
+		set(r, t.idempotencyKeys, pk, entity.IdempotencyKey{
+			Method:      k.Method,
+			Key:         k.Key,
+			RequestHash: k.RequestHash,
+			Response:    append([]byte(nil), k.Response...),
+			CreatedAt:   now(),
+		})
+		return nil
+	})
+}
+
+func (r *Repository) DeleteExpiredIdempotencyKeys(ctx context.Context, before time.Time, limit uint) (int, error) {
+	var deleted int
+	err := r.exec(ctx, func(t *tables) error {
+		var expired []entity.IdempotencyKey
+		for _, k := range t.idempotencyKeys {
+			if !k.CreatedAt.After(before) {
+				expired = append(expired, k)
+			}
+		}
+		sort.Slice(expired, func(i, j int) bool {
+			a, b := expired[i], expired[j]
+			if !a.CreatedAt.Equal(b.CreatedAt) {
+				return a.CreatedAt.Before(b.CreatedAt)
+			}
+			if a.Method != b.Method {
+				return a.Method < b.Method
+			}
+			return a.Key < b.Key
+		})
+		if uint(len(expired)) > limit {
+			expired = expired[:limit]
+		}
+		for _, k := range expired {
+			remove(r, t.idempotencyKeys, idempotencyKeyPK{k.Method, k.Key})
+		}
+		deleted = len(expired)
+		return nil
+	})
+	return deleted, err
+}
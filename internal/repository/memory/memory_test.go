@@ -0,0 +1,284 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"storageapi/internal/database"
+	"storageapi/internal/entity"
+	"storageapi/internal/repository"
+	"storageapi/internal/repository/repotest"
+	"storageapi/internal/usecase/reservation"
+	"storageapi/pkg/errs"
+	"testing"
+
+	"github.com/jackc/pgx"
+	"go.uber.org/zap"
+)
+
+var errRollback = errors.New("rollback")
+
+func TestConformance(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repository.IRepository {
+		return NewRepository()
+	})
+}
+
+func pgCode(err error) string {
+	var pgErr pgx.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code
+	}
+	return ""
+}
+
+// склад с одним товаром в количестве amount
+func seed(t *testing.T, repo *Repository, amount uint) (*entity.Storage, *entity.Product) {
+	t.Helper()
+	ctx := context.Background()
+	storages, err := repo.CreateStorage(ctx, &entity.Storage{IsAvailable: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	products, err := repo.CreateProduct(ctx, &entity.Product{Name: "shirt", Vendor: "shirt-1", Size: "m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.CreateStorageData(ctx, &entity.StoredProduct{
+		StorageID: storages[0].ID,
+		ProductID: products[0].ID,
+		Amount:    amount,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return storages[0], products[0]
+}
+
+func TestRunInTransaction(t *testing.T) {
+	repo := NewRepository()
+	ctx := context.Background()
+	storage, product := seed(t, repo, 5)
+
+	err := repo.RunInTransaction(ctx, func(ctx database.TxContext, repo repository.IRepository) error {
+		if _, err := repo.CreateStorage(ctx, &entity.Storage{}); err != nil {
+			return err
+		}
+		if err := repo.DeleteProduct(ctx, product.ID); err != nil {
+			return err
+		}
+		return errRollback
+	})
+	if !errors.Is(err, errRollback) {
+		t.Fatalf("expected rollback error, got %v", err)
+	}
+	storages, err := repo.ListStorages(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(storages) != 1 || storages[0].ID != storage.ID {
+		t.Fatalf("created storage must be rolled back, got %+v", storages)
+	}
+	stored, err := repo.GetStorageDataByProduct(ctx, product.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stored) != 1 || stored[0].Amount != 5 {
+		t.Fatalf("deleted product must be restored with its stock, got %+v", stored)
+	}
+
+	// вложенная транзакция откатывает только свои изменения
+	err = repo.RunInTransaction(ctx, func(ctx database.TxContext, repo repository.IRepository) error {
+		if _, err := repo.UpdateStorageData(ctx, &entity.StoredProduct{ID: stored[0].ID, Amount: 7}); err != nil {
+			return err
+		}
+		err := repo.RunInTransaction(ctx, func(ctx database.TxContext, repo repository.IRepository) error {
+			if _, err := repo.UpdateStorageData(ctx, &entity.StoredProduct{ID: stored[0].ID, Amount: 9}); err != nil {
+				return err
+			}
+			return errRollback
+		})
+		if !errors.Is(err, errRollback) {
+			t.Errorf("expected rollback error, got %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sp, err := repo.GetStorageData(ctx, stored[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sp.Amount != 7 {
+		t.Fatalf("expected outer update to be kept, got amount %d", sp.Amount)
+	}
+
+	// паника откатывает транзакцию и не оставляет хранилище заблокированным
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("expected panic to be propagated")
+			}
+		}()
+		_ = repo.RunInTransaction(ctx, func(ctx database.TxContext, repo repository.IRepository) error {
+			if err := repo.DeleteStorage(ctx, storage.ID); err != nil {
+				return err
+			}
+			panic("boom")
+		})
+	}()
+	if _, err := repo.GetStorage(ctx, storage.ID); err != nil {
+		t.Fatalf("storage must survive panicked transaction: %v", err)
+	}
+}
+
+func TestConstraints(t *testing.T) {
+	repo := NewRepository()
+	ctx := context.Background()
+	storage, product := seed(t, repo, 5)
+
+	// запрос атомарный: первая строка не сохраняется, если вторая нарушает ограничение
+	_, err := repo.CreateProduct(ctx,
+		&entity.Product{Name: "hat", Vendor: "hat-1", Size: "s"},
+		&entity.Product{Name: "shirt", Vendor: product.Vendor, Size: "l"},
+	)
+	if pgCode(err) != "23505" {
+		t.Fatalf("expected unique violation for vendor, got %v", err)
+	}
+	products, err := repo.ListProducts(ctx, &repository.ListProductFilter{Vendors: []string{"hat-1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(products) != 0 {
+		t.Fatalf("failed insert must not leave rows, got %+v", products)
+	}
+
+	_, err = repo.CreateStorageData(ctx, &entity.StoredProduct{StorageID: storage.ID, ProductID: product.ID, Amount: 1})
+	if pgCode(err) != "23505" {
+		t.Fatalf("expected unique violation for (storage_id, product_id), got %v", err)
+	}
+	_, err = repo.CreateReservation(ctx, &entity.ProductReservation{StorageID: storage.ID + 100, ProductID: product.ID, Amount: 1})
+	if pgCode(err) != "23503" {
+		t.Fatalf("expected foreign key violation, got %v", err)
+	}
+
+	upserted, err := repo.UpsertStorageData(ctx, &entity.StoredProduct{StorageID: storage.ID, ProductID: product.ID, Amount: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(upserted) != 1 || upserted[0].Amount != 7 {
+		t.Fatalf("expected amount to be added to existing row, got %+v", upserted)
+	}
+
+	_, err = repo.GetStorage(ctx, storage.ID+100)
+	if errs.CodeOf(err) != errs.CodeNotFound {
+		t.Fatalf("expected not found, got %v", err)
+	}
+}
+
+func TestCascadeDelete(t *testing.T) {
+	repo := NewRepository()
+	ctx := context.Background()
+	source, product := seed(t, repo, 5)
+	storages, err := repo.CreateStorage(ctx, &entity.Storage{IsAvailable: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	destination := storages[0]
+	if _, err := repo.CreateReservation(ctx, &entity.ProductReservation{StorageID: source.ID, ProductID: product.ID, Amount: 1}); err != nil {
+		t.Fatal(err)
+	}
+	orders, err := repo.CreateReservationOrder(ctx, &entity.ReservationOrder{Status: entity.ReservationStatusActive})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.CreateReservationOrderItem(ctx, &entity.ReservationOrderItem{
+		ReservationID: orders[0].ID,
+		StorageID:     source.ID,
+		ProductID:     product.ID,
+		Amount:        1,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	transfers, err := repo.CreateTransfer(ctx, &entity.Transfer{
+		SourceStorageID:      destination.ID,
+		DestinationStorageID: source.ID,
+		Status:               entity.TransferStatusInTransit,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.CreateTransferItem(ctx, &entity.TransferItem{TransferID: transfers[0].ID, ProductID: product.ID, Amount: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := repo.DeleteStorage(ctx, source.ID); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := repo.GetStorageDataByStorage(ctx, source.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reservations, err := repo.GetReservationByStorage(ctx, source.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	items, err := repo.GetReservationOrderItems(ctx, orders[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transferItems, err := repo.GetTransferItems(ctx, transfers[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stored)+len(reservations)+len(items)+len(transferItems) != 0 {
+		t.Fatalf("rows referencing deleted storage must be deleted: %+v %+v %+v %+v", stored, reservations, items, transferItems)
+	}
+	if _, err := repo.GetTransfer(ctx, transfers[0].ID); errs.CodeOf(err) != errs.CodeNotFound {
+		t.Fatalf("transfer to deleted storage must be deleted, got %v", err)
+	}
+	// заказ ссылается на склад только через позиции
+	if _, err := repo.GetReservationOrder(ctx, orders[0].ID); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// сервис резервов поверх хранилища в памяти: неудачный резерв не меняет данные
+func TestReservationService(t *testing.T) {
+	repo := NewRepository()
+	ctx := context.Background()
+	_, product := seed(t, repo, 5)
+	service := reservation.NewService(repo, zap.NewNop().Sugar(), reservation.ServiceConf{
+		DefaultStrategy: reservation.StrategyLargestFirst,
+	})
+
+	resp, err := service.ReserveProducts(ctx, reservation.ReserveProductsReq{
+		Products: []reservation.ReserveProductsReqItem{{ID: product.ID.ToUint(), Amount: 3}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = service.ReserveProducts(ctx, reservation.ReserveProductsReq{
+		Products: []reservation.ReserveProductsReqItem{{ID: product.ID.ToUint(), Amount: 3}},
+	})
+	if errs.CodeOf(err) != errs.CodeInsufficientStock {
+		t.Fatalf("expected insufficient stock, got %v", err)
+	}
+	reservations, err := repo.GetReservationByProduct(ctx, product.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reservations) != 1 || reservations[0].Amount != 3 {
+		t.Fatalf("expected only the first reservation, got %+v", reservations)
+	}
+
+	if err := service.UndoReserve(ctx, reservation.UndoReservationReq{ID: resp.ID}); err != nil {
+		t.Fatal(err)
+	}
+	stock, err := repo.GetStockByStorage(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stock) != 1 || stock[0].Free != 5 || stock[0].Reserved != 0 {
+		t.Fatalf("expected all stock to be free after undo, got %+v", stock)
+	}
+}
@@ -0,0 +1,131 @@
+package memory
+
+import (
+	"context"
+	"storageapi/internal/entity"
+	"storageapi/internal/repository"
+	"storageapi/pkg/errs"
+)
+
+// как и запрос с LEFT JOIN stored_products, товар повторяется
+// по разу на каждый склад, где он лежит
+func (r *Repository) ListProducts(ctx context.Context, filter *repository.ListProductFilter) ([]*entity.Product, error) {
+	var result []*entity.Product
+	err := r.exec(ctx, func(t *tables) error {
+		vendors := map[string]bool{}
+		if filter != nil {
+			for _, v := range filter.Vendors {
+				vendors[v] = true
+			}
+		}
+		stored := map[entity.PK]int{}
+		for _, sp := range t.storedProducts {
+			stored[sp.ProductID]++
+		}
+		for _, p := range selectRows(t.products, func(p entity.Product) bool {
+			return len(vendors) == 0 || vendors[p.Vendor]
+		}) {
+			result = append(result, p)
+			for i := 1; i < stored[p.ID]; i++ {
+				dup := *p
+				result = append(result, &dup)
+			}
+		}
+		return nil
+	})
+	return result, err
+}
+
+func (r *Repository) GetProduct(ctx context.Context, id entity.PK) (*entity.Product, error) {
+	var product *entity.Product
+	err := r.exec(ctx, func(t *tables) error {
+		p, ok := t.products[id]
+		if !ok {
+			return errs.New(errs.CodeNotFound, "product by id not found").WithDetail("product_id", id)
+		}
+		product = &p
+		return nil
+	})
+	return product, err
+}
+
+func (r *Repository) GetProducts(ctx context.Context, id ...entity.PK) ([]*entity.Product, error) {
+	var result []*entity.Product
+	err := r.exec(ctx, func(t *tables) error {
+		ids := pkSet(id)
+		result = selectRows(t.products, func(p entity.Product) bool { return ids[p.ID] })
+		return nil
+	})
+	return result, err
+}
+
+func (r *Repository) CreateProduct(ctx context.Context, products ...*entity.Product) ([]*entity.Product, error) {
+	var result []*entity.Product
+	err := r.exec(ctx, func(t *tables) error {
+		for _, p := range products {
+			row := entity.Product{Name: p.Name, Vendor: p.Vendor, Size: p.Size}
+			row.ID = r.nextID("products")
+			if err := checkVendor(t, row); err != nil {
+				return err
+			}
+			set(r, t.products, row.ID, row)
+			result = append(result, &row)
+		}
+		return nil
+	})
+	return result, err
+}
+
+func (r *Repository) UpdateProduct(ctx context.Context, products ...*entity.Product) ([]*entity.Product, error) {
+	var result []*entity.Product
+	err := r.exec(ctx, func(t *tables) error {
+		for _, p := range products {
+			row, ok := t.products[p.ID]
+			if !ok {
+				continue
+			}
+			row.Name, row.Vendor, row.Size = p.Name, p.Vendor, p.Size
+			if err := checkVendor(t, row); err != nil {
+				return err
+			}
+			set(r, t.products, row.ID, row)
+			result = append(result, &row)
+		}
+		return nil
+	})
+	return result, err
+}
+
+func (r *Repository) DeleteProduct(ctx context.Context, ids ...entity.PK) error {
+	return r.exec(ctx, func(t *tables) error {
+		r.deleteProducts(t, pkSet(ids))
+		return nil
+	})
+}
+
+func (r *Repository) TruncateProducts(ctx context.Context) error {
+	return r.exec(ctx, func(t *tables) error {
+		return truncateReferenced("products", "stored_products")
+	})
+}
+
+// vendor уникален среди товаров
+func checkVendor(t *tables, product entity.Product) error {
+	for id, p := range t.products {
+		if id != product.ID && p.Vendor == product.Vendor {
+			return uniqueViolation("products", "products_vendor_key")
+		}
+	}
+	return nil
+}
+
+// удаляет товары вместе со всем, что ссылается на них через ON DELETE CASCADE
+func (r *Repository) deleteProducts(t *tables, ids map[entity.PK]bool) {
+	removeWhere(r, t.products, func(p entity.Product) bool { return ids[p.ID] })
+	removeWhere(r, t.storedProducts, func(sp entity.StoredProduct) bool { return ids[sp.ProductID] })
+	removeWhere(r, t.reservations, func(pr entity.ProductReservation) bool { return ids[pr.ProductID] })
+	removeWhere(r, t.orderItems, func(i entity.ReservationOrderItem) bool { return ids[i.ProductID] })
+	removeWhere(r, t.shipmentItems, func(i entity.ShipmentItem) bool { return ids[i.ProductID] })
+	removeWhere(r, t.adjustments, func(a entity.StockAdjustment) bool { return ids[a.ProductID] })
+	removeWhere(r, t.transferItems, func(i entity.TransferItem) bool { return ids[i.ProductID] })
+}
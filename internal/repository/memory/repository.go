@@ -0,0 +1,226 @@
+// Package memory - реализация repository.IRepository в памяти процесса для
+// тестов и демо без PostgreSQL. Повторяет поведение схемы из fixtures:
+// уникальные ключи, внешние ключи с ON DELETE CASCADE и порядок выдачи.
+// Транзакции выполняются по одной, поэтому блокировки строк не нужны
+package memory
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"storageapi/internal/database"
+	"storageapi/internal/entity"
+	"storageapi/internal/repository"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx"
+)
+
+type Repository struct {
+	// держится на время транзакции или одного запроса вне транзакции
+	mu     sync.Mutex
+	tables *tables
+	// последние выданные id, как и sequence в postgres не откатываются
+	seq map[string]entity.PK
+	// журнал отмены изменений с начала транзакции
+	undo []func()
+	// в транзакции была ошибка sql, как и postgres, дальше запросы не выполняются
+	aborted bool
+}
+
+var _ repository.IRepository = (*Repository)(nil)
+
+func NewRepository() *Repository {
+	return &Repository{
+		tables: newTables(),
+		seq:    map[string]entity.PK{},
+	}
+}
+
+type tables struct {
+	storages        map[entity.PK]entity.Storage
+	products        map[entity.PK]entity.Product
+	storedProducts  map[entity.PK]entity.StoredProduct
+	reservations    map[entity.PK]entity.ProductReservation
+	orders          map[entity.PK]entity.ReservationOrder
+	orderItems      map[entity.PK]entity.ReservationOrderItem
+	idempotencyKeys map[idempotencyKeyPK]entity.IdempotencyKey
+	shipments       map[entity.PK]entity.Shipment
+	shipmentItems   map[entity.PK]entity.ShipmentItem
+	adjustments     map[entity.PK]entity.StockAdjustment
+	transfers       map[entity.PK]entity.Transfer
+	transferItems   map[entity.PK]entity.TransferItem
+}
+
+func newTables() *tables {
+	return &tables{
+		storages:        map[entity.PK]entity.Storage{},
+		products:        map[entity.PK]entity.Product{},
+		storedProducts:  map[entity.PK]entity.StoredProduct{},
+		reservations:    map[entity.PK]entity.ProductReservation{},
+		orders:          map[entity.PK]entity.ReservationOrder{},
+		orderItems:      map[entity.PK]entity.ReservationOrderItem{},
+		idempotencyKeys: map[idempotencyKeyPK]entity.IdempotencyKey{},
+		shipments:       map[entity.PK]entity.Shipment{},
+		shipmentItems:   map[entity.PK]entity.ShipmentItem{},
+		adjustments:     map[entity.PK]entity.StockAdjustment{},
+		transfers:       map[entity.PK]entity.Transfer{},
+		transferItems:   map[entity.PK]entity.TransferItem{},
+	}
+}
+
+// SQL запросов нет, use case обращаются к данным только через методы репозитория
+func (r *Repository) DBI(ctx context.Context) repository.DBI {
+	return nil
+}
+
+type txKey struct{}
+
+func (r *Repository) inTx(ctx context.Context) bool {
+	return ctx.Value(txKey{}) == r
+}
+
+// RunInTransaction выполняет fn под блокировкой всего хранилища и при ошибке
+// или панике отменяет все изменения fn. Вложенный вызов работает как savepoint:
+// откатывается только его часть, внешняя транзакция продолжается
+func (r *Repository) RunInTransaction(
+	ctx context.Context,
+	fn func(ctx database.TxContext, repo repository.IRepository) error,
+) (err error) {
+	if r.inTx(ctx) {
+		mark := len(r.undo)
+		defer func() {
+			if p := recover(); p != nil {
+				r.rollbackTo(mark)
+				panic(p)
+			}
+			if err != nil {
+				// откат к savepoint снимает и ошибку транзакции
+				r.rollbackTo(mark)
+				r.aborted = false
+			}
+		}()
+		return fn(&database.TxCtx{Context: ctx}, r)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	defer func() {
+		if p := recover(); p != nil {
+			r.rollbackTo(0)
+			panic(p)
+		}
+		if err == nil && r.aborted {
+			// commit прерванной транзакции в postgres превращается в rollback
+			err = pgx.ErrTxCommitRollback
+		}
+		if err != nil {
+			r.rollbackTo(0)
+		}
+		r.undo, r.aborted = nil, false
+	}()
+	return fn(&database.TxCtx{Context: context.WithValue(ctx, txKey{}, r)}, r)
+}
+
+// exec выполняет один запрос. запрос атомарный, как и в postgres:
+// при ошибке его изменения отменяются, а транзакция, в которой нарушено
+// ограничение, отклоняет следующие запросы до отката
+func (r *Repository) exec(ctx context.Context, fn func(t *tables) error) error {
+	inTx := r.inTx(ctx)
+	if !inTx {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		defer func() { r.undo = nil }()
+	} else if r.aborted {
+		return transactionAborted()
+	}
+	mark := len(r.undo)
+	if err := fn(r.tables); err != nil {
+		r.rollbackTo(mark)
+		var pgErr pgx.PgError
+		if inTx && errors.As(err, &pgErr) {
+			r.aborted = true
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *Repository) rollbackTo(mark int) {
+	for i := len(r.undo) - 1; i >= mark; i-- {
+		r.undo[i]()
+	}
+	r.undo = r.undo[:mark]
+}
+
+func (r *Repository) nextID(table string) entity.PK {
+	r.seq[table]++
+	return r.seq[table]
+}
+
+// set записывает строку и запоминает, как вернуть прежнее значение
+func set[K comparable, V any](r *Repository, table map[K]V, key K, value V) {
+	old, existed := table[key]
+	r.undo = append(r.undo, func() {
+		if existed {
+			table[key] = old
+		} else {
+			delete(table, key)
+		}
+	})
+	table[key] = value
+}
+
+func remove[K comparable, V any](r *Repository, table map[K]V, key K) {
+	old, existed := table[key]
+	if !existed {
+		return
+	}
+	r.undo = append(r.undo, func() { table[key] = old })
+	delete(table, key)
+}
+
+// removeWhere удаляет строки, подходящие под match, и возвращает их id
+func removeWhere[V any](r *Repository, table map[entity.PK]V, match func(v V) bool) []entity.PK {
+	var ids []entity.PK
+	for id, v := range table {
+		if match(v) {
+			ids = append(ids, id)
+		}
+	}
+	for _, id := range ids {
+		remove(r, table, id)
+	}
+	return ids
+}
+
+// selectRows возвращает копии подходящих строк в порядке id
+func selectRows[V any](table map[entity.PK]V, match func(v V) bool) []*V {
+	ids := make([]entity.PK, 0, len(table))
+	for id, v := range table {
+		if match == nil || match(v) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	var result []*V
+	for _, id := range ids {
+		v := table[id]
+		result = append(result, &v)
+	}
+	return result
+}
+
+func pkSet(ids []entity.PK) map[entity.PK]bool {
+	set := make(map[entity.PK]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set
+}
+
+// postgres хранит время с точностью до микросекунд
+func now() time.Time {
+	return time.Now().Truncate(time.Microsecond)
+}
@@ -0,0 +1,174 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"storageapi/internal/entity"
+	"storageapi/pkg/errs"
+	"time"
+)
+
+func (r *Repository) GetReservationOrder(ctx context.Context, id entity.PK) (*entity.ReservationOrder, error) {
+	var result *entity.ReservationOrder
+	err := r.exec(ctx, func(t *tables) error {
+		o, ok := t.orders[id]
+		if !ok {
+			return errs.New(errs.CodeNotFound, "cannot find reservation by id").WithDetail("reservation_id", id)
+		}
+		result = &o
+		return nil
+	})
+	return result, err
+}
+
+func (r *Repository) LockReservationOrder(ctx context.Context, id entity.PK) (*entity.ReservationOrder, error) {
+	return r.GetReservationOrder(ctx, id)
+}
+
+// до limit активных заказов с истекшим сроком, раньше истекшие первыми
+func (r *Repository) LockExpiredReservationOrders(ctx context.Context, now time.Time, limit uint) ([]*entity.ReservationOrder, error) {
+	var result []*entity.ReservationOrder
+	err := r.exec(ctx, func(t *tables) error {
+		result = selectRows(t.orders, func(o entity.ReservationOrder) bool {
+			return o.Status == entity.ReservationStatusActive && o.ExpiresAt != nil && !o.ExpiresAt.After(now)
+		})
+		sort.SliceStable(result, func(i, j int) bool { return result[i].ExpiresAt.Before(*result[j].ExpiresAt) })
+		if uint(len(result)) > limit {
+			result = result[:limit]
+		}
+		return nil
+	})
+	return result, err
+}
+
+// заказы в статусе status, у которых есть позиции на складе storageID
+func (r *Repository) LockReservationOrdersByStorage(ctx context.Context, storageID entity.PK, status string) ([]*entity.ReservationOrder, error) {
+	var result []*entity.ReservationOrder
+	err := r.exec(ctx, func(t *tables) error {
+		orders := map[entity.PK]bool{}
+		for _, i := range t.orderItems {
+			if i.StorageID == storageID {
+				orders[i.ReservationID] = true
+			}
+		}
+		result = selectRows(t.orders, func(o entity.ReservationOrder) bool {
+			return o.Status == status && orders[o.ID]
+		})
+		return nil
+	})
+	return result, err
+}
+
+func (r *Repository) CreateReservationOrder(ctx context.Context, orders ...*entity.ReservationOrder) ([]*entity.ReservationOrder, error) {
+	var result []*entity.ReservationOrder
+	err := r.exec(ctx, func(t *tables) error {
+		for _, o := range orders {
+			row := entity.ReservationOrder{
+				ID:        r.nextID("reservation_orders"),
+				Status:    o.Status,
+				CreatedAt: now(),
+			}
+			if o.ExpiresAt != nil {
+				expiresAt := o.ExpiresAt.Truncate(time.Microsecond)
+				row.ExpiresAt = &expiresAt
+			}
+			set(r, t.orders, row.ID, row)
+			result = append(result, &row)
+		}
+		return nil
+	})
+	return result, err
+}
+
+func (r *Repository) UpdateReservationOrder(ctx context.Context, orders ...*entity.ReservationOrder) ([]*entity.ReservationOrder, error) {
+	var result []*entity.ReservationOrder
+	err := r.exec(ctx, func(t *tables) error {
+		for _, o := range orders {
+			row, ok := t.orders[o.ID]
+			if !ok {
+				continue
+			}
+			row.Status = o.Status
+			set(r, t.orders, row.ID, row)
+			result = append(result, &row)
+		}
+		return nil
+	})
+	return result, err
+}
+
+func (r *Repository) GetReservationOrderItems(ctx context.Context, orderIDs ...entity.PK) ([]*entity.ReservationOrderItem, error) {
+	var result []*entity.ReservationOrderItem
+	err := r.exec(ctx, func(t *tables) error {
+		ids := pkSet(orderIDs)
+		result = selectRows(t.orderItems, func(i entity.ReservationOrderItem) bool { return ids[i.ReservationID] })
+		return nil
+	})
+	return result, err
+}
+
+func (r *Repository) CreateReservationOrderItem(ctx context.Context, items ...*entity.ReservationOrderItem) ([]*entity.ReservationOrderItem, error) {
+	var result []*entity.ReservationOrderItem
+	err := r.exec(ctx, func(t *tables) error {
+		for _, i := range items {
+			row := entity.ReservationOrderItem{
+				ID:            r.nextID("reservation_order_items"),
+				ReservationID: i.ReservationID,
+				StorageID:     i.StorageID,
+				ProductID:     i.ProductID,
+				Amount:        i.Amount,
+			}
+			if err := checkOrderItem(t, row); err != nil {
+				return err
+			}
+			set(r, t.orderItems, row.ID, row)
+			result = append(result, &row)
+		}
+		return nil
+	})
+	return result, err
+}
+
+func (r *Repository) UpdateReservationOrderItem(ctx context.Context, items ...*entity.ReservationOrderItem) ([]*entity.ReservationOrderItem, error) {
+	var result []*entity.ReservationOrderItem
+	err := r.exec(ctx, func(t *tables) error {
+		for _, i := range items {
+			row, ok := t.orderItems[i.ID]
+			if !ok {
+				continue
+			}
+			row.StorageID, row.Amount = i.StorageID, i.Amount
+			if err := checkOrderItem(t, row); err != nil {
+				return err
+			}
+			set(r, t.orderItems, row.ID, row)
+			result = append(result, &row)
+		}
+		return nil
+	})
+	return result, err
+}
+
+func (r *Repository) DeleteReservationOrderItem(ctx context.Context, ids ...entity.PK) error {
+	return r.exec(ctx, func(t *tables) error {
+		selected := pkSet(ids)
+		removeWhere(r, t.orderItems, func(i entity.ReservationOrderItem) bool { return selected[i.ID] })
+		return nil
+	})
+}
+
+// внешние ключи позиции и уникальность (reservation_id, storage_id, product_id)
+func checkOrderItem(t *tables, item entity.ReservationOrderItem) error {
+	if _, ok := t.orders[item.ReservationID]; !ok {
+		return foreignKeyViolation("reservation_order_items", "reservation_order_items_reservation_id_fkey")
+	}
+	if err := checkStorageProduct(t, "reservation_order_items", item.StorageID, item.ProductID); err != nil {
+		return err
+	}
+	for id, i := range t.orderItems {
+		if id != item.ID && i.ReservationID == item.ReservationID && i.StorageID == item.StorageID && i.ProductID == item.ProductID {
+			return uniqueViolation("reservation_order_items", "reservation_order_items_reservation_id_storage_id_product_id_key")
+		}
+	}
+	return nil
+}
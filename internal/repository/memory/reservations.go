@@ -0,0 +1,98 @@
+package memory
+
+import (
+	"context"
+	"storageapi/internal/entity"
+	"storageapi/pkg/errs"
+)
+
+func (r *Repository) GetReservationByProduct(ctx context.Context, productIDs ...entity.PK) ([]*entity.ProductReservation, error) {
+	var result []*entity.ProductReservation
+	err := r.exec(ctx, func(t *tables) error {
+		ids := pkSet(productIDs)
+		result = selectRows(t.reservations, func(pr entity.ProductReservation) bool { return ids[pr.ProductID] })
+		return nil
+	})
+	return result, err
+}
+
+func (r *Repository) GetReservationByStorage(ctx context.Context, storageIDs ...entity.PK) ([]*entity.ProductReservation, error) {
+	var result []*entity.ProductReservation
+	err := r.exec(ctx, func(t *tables) error {
+		ids := pkSet(storageIDs)
+		result = selectRows(t.reservations, func(pr entity.ProductReservation) bool { return ids[pr.StorageID] })
+		return nil
+	})
+	return result, err
+}
+
+func (r *Repository) GetReservation(ctx context.Context, id entity.PK) (*entity.ProductReservation, error) {
+	var result *entity.ProductReservation
+	err := r.exec(ctx, func(t *tables) error {
+		pr, ok := t.reservations[id]
+		if !ok {
+			return errs.New(errs.CodeNotFound, "product reservation by id not found").WithDetail("product_reservation_id", id)
+		}
+		result = &pr
+		return nil
+	})
+	return result, err
+}
+
+func (r *Repository) CreateReservation(ctx context.Context, reservations ...*entity.ProductReservation) ([]*entity.ProductReservation, error) {
+	var result []*entity.ProductReservation
+	err := r.exec(ctx, func(t *tables) error {
+		for _, pr := range reservations {
+			row := entity.ProductReservation{
+				ID:        r.nextID("product_reservations"),
+				StorageID: pr.StorageID,
+				ProductID: pr.ProductID,
+				Amount:    pr.Amount,
+			}
+			if err := checkStorageProduct(t, "product_reservations", row.StorageID, row.ProductID); err != nil {
+				return err
+			}
+			if _, ok := findReservation(t, row.StorageID, row.ProductID); ok {
+				return uniqueViolation("product_reservations", "product_reservations_storage_id_product_id_key")
+			}
+			set(r, t.reservations, row.ID, row)
+			result = append(result, &row)
+		}
+		return nil
+	})
+	return result, err
+}
+
+func (r *Repository) UpdateReservation(ctx context.Context, reservations ...*entity.ProductReservation) ([]*entity.ProductReservation, error) {
+	var result []*entity.ProductReservation
+	err := r.exec(ctx, func(t *tables) error {
+		for _, pr := range reservations {
+			row, ok := t.reservations[pr.ID]
+			if !ok {
+				continue
+			}
+			row.Amount = pr.Amount
+			set(r, t.reservations, row.ID, row)
+			result = append(result, &row)
+		}
+		return nil
+	})
+	return result, err
+}
+
+func (r *Repository) DeleteReservation(ctx context.Context, ids ...entity.PK) error {
+	return r.exec(ctx, func(t *tables) error {
+		selected := pkSet(ids)
+		removeWhere(r, t.reservations, func(pr entity.ProductReservation) bool { return selected[pr.ID] })
+		return nil
+	})
+}
+
+func findReservation(t *tables, storageID, productID entity.PK) (entity.ProductReservation, bool) {
+	for _, pr := range t.reservations {
+		if pr.StorageID == storageID && pr.ProductID == productID {
+			return pr, true
+		}
+	}
+	return entity.ProductReservation{}, false
+}
@@ -0,0 +1,57 @@
+package memory
+
+import (
+	"context"
+	"storageapi/internal/entity"
+)
+
+func (r *Repository) CreateShipment(ctx context.Context, shipments ...*entity.Shipment) ([]*entity.Shipment, error) {
+	var result []*entity.Shipment
+	err := r.exec(ctx, func(t *tables) error {
+		for _, s := range shipments {
+			row := entity.Shipment{
+				ID:            r.nextID("shipments"),
+				ReservationID: s.ReservationID,
+				CreatedAt:     now(),
+			}
+			if _, ok := t.orders[row.ReservationID]; !ok {
+				return foreignKeyViolation("shipments", "shipments_reservation_id_fkey")
+			}
+			// заказ отгружается один раз
+			for _, shipment := range t.shipments {
+				if shipment.ReservationID == row.ReservationID {
+					return uniqueViolation("shipments", "shipments_reservation_id_key")
+				}
+			}
+			set(r, t.shipments, row.ID, row)
+			result = append(result, &row)
+		}
+		return nil
+	})
+	return result, err
+}
+
+func (r *Repository) CreateShipmentItem(ctx context.Context, items ...*entity.ShipmentItem) ([]*entity.ShipmentItem, error) {
+	var result []*entity.ShipmentItem
+	err := r.exec(ctx, func(t *tables) error {
+		for _, i := range items {
+			row := entity.ShipmentItem{
+				ID:         r.nextID("shipment_items"),
+				ShipmentID: i.ShipmentID,
+				StorageID:  i.StorageID,
+				ProductID:  i.ProductID,
+				Amount:     i.Amount,
+			}
+			if _, ok := t.shipments[row.ShipmentID]; !ok {
+				return foreignKeyViolation("shipment_items", "shipment_items_shipment_id_fkey")
+			}
+			if err := checkStorageProduct(t, "shipment_items", row.StorageID, row.ProductID); err != nil {
+				return err
+			}
+			set(r, t.shipmentItems, row.ID, row)
+			result = append(result, &row)
+		}
+		return nil
+	})
+	return result, err
+}
@@ -0,0 +1,40 @@
+package memory
+
+import (
+	"context"
+	"storageapi/internal/entity"
+)
+
+func (r *Repository) CreateStockAdjustment(ctx context.Context, adjustments ...*entity.StockAdjustment) ([]*entity.StockAdjustment, error) {
+	var result []*entity.StockAdjustment
+	err := r.exec(ctx, func(t *tables) error {
+		for _, a := range adjustments {
+			row := entity.StockAdjustment{
+				ID:        r.nextID("stock_adjustments"),
+				StorageID: a.StorageID,
+				ProductID: a.ProductID,
+				Delta:     a.Delta,
+				Reason:    a.Reason,
+				Comment:   a.Comment,
+				CreatedAt: now(),
+			}
+			if err := checkStorageProduct(t, "stock_adjustments", row.StorageID, row.ProductID); err != nil {
+				return err
+			}
+			set(r, t.adjustments, row.ID, row)
+			result = append(result, &row)
+		}
+		return nil
+	})
+	return result, err
+}
+
+func (r *Repository) GetStockAdjustmentsByStorage(ctx context.Context, storageIDs ...entity.PK) ([]*entity.StockAdjustment, error) {
+	var result []*entity.StockAdjustment
+	err := r.exec(ctx, func(t *tables) error {
+		ids := pkSet(storageIDs)
+		result = selectRows(t.adjustments, func(a entity.StockAdjustment) bool { return ids[a.StorageID] })
+		return nil
+	})
+	return result, err
+}
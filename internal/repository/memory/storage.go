@@ -0,0 +1,111 @@
+package memory
+
+import (
+	"context"
+	"storageapi/internal/entity"
+	"storageapi/internal/repository"
+	"storageapi/pkg/errs"
+)
+
+func (r *Repository) GetStorage(ctx context.Context, id entity.PK) (*entity.Storage, error) {
+	var storage *entity.Storage
+	err := r.exec(ctx, func(t *tables) error {
+		s, ok := t.storages[id]
+		if !ok {
+			return errs.New(errs.CodeNotFound, "cannot find storage by id").WithDetail("storage_id", id)
+		}
+		storage = &s
+		return nil
+	})
+	return storage, err
+}
+
+// транзакции выполняются по одной, поэтому блокировка совпадает с чтением
+func (r *Repository) LockStorage(ctx context.Context, id entity.PK) (*entity.Storage, error) {
+	return r.GetStorage(ctx, id)
+}
+
+func (r *Repository) ListStorages(ctx context.Context, filter *repository.ListStorageFilter) ([]*entity.Storage, error) {
+	var result []*entity.Storage
+	err := r.exec(ctx, func(t *tables) error {
+		if filter == nil {
+			result = selectRows(t.storages, nil)
+			return nil
+		}
+		ids := pkSet(filter.IDs)
+		result = selectRows(t.storages, func(s entity.Storage) bool {
+			if len(ids) > 0 && !ids[s.ID] {
+				return false
+			}
+			return filter.IsAvailable == nil || s.IsAvailable == *filter.IsAvailable
+		})
+		if filter.Offset >= uint(len(result)) {
+			result = nil
+			return nil
+		}
+		result = result[filter.Offset:]
+		if filter.Limit > 0 && filter.Limit < uint(len(result)) {
+			result = result[:filter.Limit]
+		}
+		return nil
+	})
+	return result, err
+}
+
+func (r *Repository) CreateStorage(ctx context.Context, storages ...*entity.Storage) ([]*entity.Storage, error) {
+	var result []*entity.Storage
+	err := r.exec(ctx, func(t *tables) error {
+		for _, s := range storages {
+			row := entity.Storage{IsAvailable: s.IsAvailable}
+			row.ID = r.nextID("storages")
+			set(r, t.storages, row.ID, row)
+			result = append(result, &row)
+		}
+		return nil
+	})
+	return result, err
+}
+
+func (r *Repository) UpdateStorage(ctx context.Context, storages ...*entity.Storage) ([]*entity.Storage, error) {
+	var result []*entity.Storage
+	err := r.exec(ctx, func(t *tables) error {
+		for _, s := range storages {
+			row, ok := t.storages[s.ID]
+			if !ok {
+				continue
+			}
+			row.IsAvailable = s.IsAvailable
+			set(r, t.storages, row.ID, row)
+			result = append(result, &row)
+		}
+		return nil
+	})
+	return result, err
+}
+
+func (r *Repository) DeleteStorage(ctx context.Context, ids ...entity.PK) error {
+	return r.exec(ctx, func(t *tables) error {
+		r.deleteStorages(t, pkSet(ids))
+		return nil
+	})
+}
+
+func (r *Repository) TruncateStorages(ctx context.Context) error {
+	return r.exec(ctx, func(t *tables) error {
+		return truncateReferenced("storages", "stored_products")
+	})
+}
+
+// удаляет склады вместе со всем, что ссылается на них через ON DELETE CASCADE
+func (r *Repository) deleteStorages(t *tables, ids map[entity.PK]bool) {
+	removeWhere(r, t.storages, func(s entity.Storage) bool { return ids[s.ID] })
+	removeWhere(r, t.storedProducts, func(sp entity.StoredProduct) bool { return ids[sp.StorageID] })
+	removeWhere(r, t.reservations, func(pr entity.ProductReservation) bool { return ids[pr.StorageID] })
+	removeWhere(r, t.orderItems, func(i entity.ReservationOrderItem) bool { return ids[i.StorageID] })
+	removeWhere(r, t.shipmentItems, func(i entity.ShipmentItem) bool { return ids[i.StorageID] })
+	removeWhere(r, t.adjustments, func(a entity.StockAdjustment) bool { return ids[a.StorageID] })
+	transfers := pkSet(removeWhere(r, t.transfers, func(tr entity.Transfer) bool {
+		return ids[tr.SourceStorageID] || ids[tr.DestinationStorageID]
+	}))
+	removeWhere(r, t.transferItems, func(i entity.TransferItem) bool { return transfers[i.TransferID] })
+}
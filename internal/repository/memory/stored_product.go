@@ -0,0 +1,183 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"storageapi/internal/entity"
+	"storageapi/pkg/errs"
+)
+
+func (r *Repository) GetStorageData(ctx context.Context, id entity.PK) (*entity.StoredProduct, error) {
+	var result *entity.StoredProduct
+	err := r.exec(ctx, func(t *tables) error {
+		sp, ok := t.storedProducts[id]
+		if !ok {
+			return errs.New(errs.CodeNotFound, "stored product by id not found").WithDetail("stored_product_id", id)
+		}
+		result = &sp
+		return nil
+	})
+	return result, err
+}
+
+func (r *Repository) GetStorageDataByProduct(ctx context.Context, productIDs ...entity.PK) ([]*entity.StoredProduct, error) {
+	var result []*entity.StoredProduct
+	err := r.exec(ctx, func(t *tables) error {
+		ids := pkSet(productIDs)
+		result = selectRows(t.storedProducts, func(sp entity.StoredProduct) bool { return ids[sp.ProductID] })
+		return nil
+	})
+	return result, err
+}
+
+func (r *Repository) LockStorageDataByProduct(ctx context.Context, productIDs ...entity.PK) ([]*entity.StoredProduct, error) {
+	return r.GetStorageDataByProduct(ctx, productIDs...)
+}
+
+func (r *Repository) GetStorageDataByStorage(ctx context.Context, storageIDs ...entity.PK) ([]*entity.StoredProduct, error) {
+	var result []*entity.StoredProduct
+	err := r.exec(ctx, func(t *tables) error {
+		ids := pkSet(storageIDs)
+		result = selectRows(t.storedProducts, func(sp entity.StoredProduct) bool { return ids[sp.StorageID] })
+		return nil
+	})
+	return result, err
+}
+
+func (r *Repository) LockStorageDataByStorage(ctx context.Context, storageIDs ...entity.PK) ([]*entity.StoredProduct, error) {
+	return r.GetStorageDataByStorage(ctx, storageIDs...)
+}
+
+// считается так же, как в запросе: свободный остаток по каждому товару не меньше 0
+func (r *Repository) GetStockByStorage(ctx context.Context) ([]*entity.StorageStock, error) {
+	var result []*entity.StorageStock
+	err := r.exec(ctx, func(t *tables) error {
+		stocks := map[entity.PK]*entity.StorageStock{}
+		for _, sp := range t.storedProducts {
+			stock, ok := stocks[sp.StorageID]
+			if !ok {
+				stock = &entity.StorageStock{StorageID: sp.StorageID}
+				stocks[sp.StorageID] = stock
+				result = append(result, stock)
+			}
+			var reserved uint
+			if pr, ok := findReservation(t, sp.StorageID, sp.ProductID); ok {
+				reserved = pr.Amount
+			}
+			stock.Stored += sp.Amount
+			stock.Reserved += reserved
+			if sp.Amount > reserved {
+				stock.Free += sp.Amount - reserved
+			}
+		}
+		sort.Slice(result, func(i, j int) bool { return result[i].StorageID < result[j].StorageID })
+		return nil
+	})
+	return result, err
+}
+
+func (r *Repository) CreateStorageData(ctx context.Context, data ...*entity.StoredProduct) ([]*entity.StoredProduct, error) {
+	var result []*entity.StoredProduct
+	err := r.exec(ctx, func(t *tables) error {
+		for _, sp := range data {
+			row, err := r.insertStorageData(t, sp)
+			if err != nil {
+				return err
+			}
+			result = append(result, row)
+		}
+		return nil
+	})
+	return result, err
+}
+
+// добавляет amount к существующей строке (storage_id, product_id) или создает новую
+func (r *Repository) UpsertStorageData(ctx context.Context, data ...*entity.StoredProduct) ([]*entity.StoredProduct, error) {
+	var result []*entity.StoredProduct
+	err := r.exec(ctx, func(t *tables) error {
+		updated := map[entity.PK]bool{}
+		for _, sp := range data {
+			row, ok := findStoredProduct(t, sp.StorageID, sp.ProductID)
+			if !ok {
+				inserted, err := r.insertStorageData(t, sp)
+				if err != nil {
+					return err
+				}
+				updated[inserted.ID] = true
+				result = append(result, inserted)
+				continue
+			}
+			if updated[row.ID] {
+				return cardinalityViolation()
+			}
+			updated[row.ID] = true
+			row.Amount += sp.Amount
+			set(r, t.storedProducts, row.ID, row)
+			result = append(result, &row)
+		}
+		return nil
+	})
+	return result, err
+}
+
+func (r *Repository) UpdateStorageData(ctx context.Context, data ...*entity.StoredProduct) ([]*entity.StoredProduct, error) {
+	var result []*entity.StoredProduct
+	err := r.exec(ctx, func(t *tables) error {
+		for _, sp := range data {
+			row, ok := t.storedProducts[sp.ID]
+			if !ok {
+				continue
+			}
+			row.Amount = sp.Amount
+			set(r, t.storedProducts, row.ID, row)
+			result = append(result, &row)
+		}
+		return nil
+	})
+	return result, err
+}
+
+func (r *Repository) DeleteStorageData(ctx context.Context, ids ...entity.PK) error {
+	return r.exec(ctx, func(t *tables) error {
+		selected := pkSet(ids)
+		removeWhere(r, t.storedProducts, func(sp entity.StoredProduct) bool { return selected[sp.ID] })
+		return nil
+	})
+}
+
+func (r *Repository) insertStorageData(t *tables, sp *entity.StoredProduct) (*entity.StoredProduct, error) {
+	row := entity.StoredProduct{
+		ID:        r.nextID("stored_products"),
+		StorageID: sp.StorageID,
+		ProductID: sp.ProductID,
+		Amount:    sp.Amount,
+	}
+	if err := checkStorageProduct(t, "stored_products", row.StorageID, row.ProductID); err != nil {
+		return nil, err
+	}
+	if _, ok := findStoredProduct(t, row.StorageID, row.ProductID); ok {
+		return nil, uniqueViolation("stored_products", "stored_products_storage_id_product_id_key")
+	}
+	set(r, t.storedProducts, row.ID, row)
+	return &row, nil
+}
+
+func findStoredProduct(t *tables, storageID, productID entity.PK) (entity.StoredProduct, bool) {
+	for _, sp := range t.storedProducts {
+		if sp.StorageID == storageID && sp.ProductID == productID {
+			return sp, true
+		}
+	}
+	return entity.StoredProduct{}, false
+}
+
+// внешние ключи storage_id и product_id, общие для таблиц остатков и резервов
+func checkStorageProduct(t *tables, table string, storageID, productID entity.PK) error {
+	if _, ok := t.storages[storageID]; !ok {
+		return foreignKeyViolation(table, table+"_storage_id_fkey")
+	}
+	if _, ok := t.products[productID]; !ok {
+		return foreignKeyViolation(table, table+"_product_id_fkey")
+	}
+	return nil
+}
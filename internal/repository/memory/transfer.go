@@ -0,0 +1,110 @@
+package memory
+
+import (
+	"context"
+	"storageapi/internal/entity"
+	"storageapi/pkg/errs"
+)
+
+func (r *Repository) GetTransfer(ctx context.Context, id entity.PK) (*entity.Transfer, error) {
+	var result *entity.Transfer
+	err := r.exec(ctx, func(t *tables) error {
+		tr, ok := t.transfers[id]
+		if !ok {
+			return errs.New(errs.CodeNotFound, "cannot find transfer by id").WithDetail("transfer_id", id)
+		}
+		result = &tr
+		return nil
+	})
+	return result, err
+}
+
+func (r *Repository) LockTransfer(ctx context.Context, id entity.PK) (*entity.Transfer, error) {
+	return r.GetTransfer(ctx, id)
+}
+
+func (r *Repository) CreateTransfer(ctx context.Context, transfers ...*entity.Transfer) ([]*entity.Transfer, error) {
+	var result []*entity.Transfer
+	err := r.exec(ctx, func(t *tables) error {
+		for _, tr := range transfers {
+			createdAt := now()
+			row := entity.Transfer{
+				ID:                   r.nextID("transfers"),
+				SourceStorageID:      tr.SourceStorageID,
+				DestinationStorageID: tr.DestinationStorageID,
+				Status:               tr.Status,
+				CreatedAt:            createdAt,
+				UpdatedAt:            createdAt,
+			}
+			if row.SourceStorageID == row.DestinationStorageID {
+				return checkViolation("transfers", "transfers_check")
+			}
+			if _, ok := t.storages[row.SourceStorageID]; !ok {
+				return foreignKeyViolation("transfers", "transfers_source_storage_id_fkey")
+			}
+			if _, ok := t.storages[row.DestinationStorageID]; !ok {
+				return foreignKeyViolation("transfers", "transfers_destination_storage_id_fkey")
+			}
+			set(r, t.transfers, row.ID, row)
+			result = append(result, &row)
+		}
+		return nil
+	})
+	return result, err
+}
+
+func (r *Repository) UpdateTransfer(ctx context.Context, transfers ...*entity.Transfer) ([]*entity.Transfer, error) {
+	var result []*entity.Transfer
+	err := r.exec(ctx, func(t *tables) error {
+		for _, tr := range transfers {
+			row, ok := t.transfers[tr.ID]
+			if !ok {
+				continue
+			}
+			row.Status, row.UpdatedAt = tr.Status, now()
+			set(r, t.transfers, row.ID, row)
+			result = append(result, &row)
+		}
+		return nil
+	})
+	return result, err
+}
+
+func (r *Repository) GetTransferItems(ctx context.Context, transferIDs ...entity.PK) ([]*entity.TransferItem, error) {
+	var result []*entity.TransferItem
+	err := r.exec(ctx, func(t *tables) error {
+		ids := pkSet(transferIDs)
+		result = selectRows(t.transferItems, func(i entity.TransferItem) bool { return ids[i.TransferID] })
+		return nil
+	})
+	return result, err
+}
+
+func (r *Repository) CreateTransferItem(ctx context.Context, items ...*entity.TransferItem) ([]*entity.TransferItem, error) {
+	var result []*entity.TransferItem
+	err := r.exec(ctx, func(t *tables) error {
+		for _, i := range items {
+			row := entity.TransferItem{
+				ID:         r.nextID("transfer_items"),
+				TransferID: i.TransferID,
+				ProductID:  i.ProductID,
+				Amount:     i.Amount,
+			}
+			if _, ok := t.transfers[row.TransferID]; !ok {
+				return foreignKeyViolation("transfer_items", "transfer_items_transfer_id_fkey")
+			}
+			if _, ok := t.products[row.ProductID]; !ok {
+				return foreignKeyViolation("transfer_items", "transfer_items_product_id_fkey")
+			}
+			for _, item := range t.transferItems {
+				if item.TransferID == row.TransferID && item.ProductID == row.ProductID {
+					return uniqueViolation("transfer_items", "transfer_items_transfer_id_product_id_key")
+				}
+			}
+			set(r, t.transferItems, row.ID, row)
+			result = append(result, &row)
+		}
+		return nil
+	})
+	return result, err
+}
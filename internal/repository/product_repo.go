@@ -2,8 +2,9 @@ package repository
 
 import (
 	"context"
-	"errors"
+	"fmt"
 	"storageapi/internal/entity"
+	"storageapi/pkg/errs"
 	"strings"
 
 	"go.uber.org/zap"
@@ -42,7 +43,7 @@ func (r *ProductRepository) ListProducts(ctx context.Context, filter *ListProduc
 		return nil, err
 	}
 
-	return entity.ScannedRows[*entity.Product](rows)
+	return entity.ScannedRows[entity.Product](rows)
 }
 
 func (r *ProductRepository) GetProduct(ctx context.Context, id entity.PK) (*entity.Product, error) {
@@ -50,6 +51,7 @@ func (r *ProductRepository) GetProduct(ctx context.Context, id entity.PK) (*enti
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 	var p *entity.Product
 	for rows.Next() {
 		p = &entity.Product{}
@@ -58,17 +60,17 @@ func (r *ProductRepository) GetProduct(ctx context.Context, id entity.PK) (*enti
 		}
 	}
 	if p == nil {
-		return nil, errors.New("product by id not found")
+		return nil, errs.New(errs.CodeNotFound, "product by id not found").WithDetail("product_id", id)
 	}
 	return p, nil
 }
 
 func (r *ProductRepository) GetProducts(ctx context.Context, id ...entity.PK) ([]*entity.Product, error) {
-	rows, err := r.DBI(ctx).QueryContext(ctx, "SELECT * FROM products WHERE id IN ($1)", id)
+	rows, err := r.DBI(ctx).QueryContext(ctx, "SELECT * FROM products WHERE id = ANY($1) ORDER BY id", pkArray(id))
 	if err != nil {
 		return nil, err
 	}
-	return entity.ScannedRows[*entity.Product](rows)
+	return entity.ScannedRows[entity.Product](rows)
 }
 
 func (r *ProductRepository) CreateProduct(ctx context.Context, products ...*entity.Product) ([]*entity.Product, error) {
@@ -85,7 +87,7 @@ func (r *ProductRepository) CreateProduct(ctx context.Context, products ...*enti
 	if err != nil {
 		return nil, err
 	}
-	return entity.ScannedRows[*entity.Product](rows)
+	return entity.ScannedRows[entity.Product](rows)
 }
 
 func (r *ProductRepository) UpdateProduct(ctx context.Context, products ...*entity.Product) ([]*entity.Product, error) {
@@ -95,7 +97,7 @@ func (r *ProductRepository) UpdateProduct(ctx context.Context, products ...*enti
 		vendor = c.vendor,
 		size = c.size
 	FROM (VALUES `)
-	argB := argBuilder{}
+	argB := argBuilder{types: []string{"bigint", "varchar", "varchar", "varchar"}}
 	for _, p := range products {
 		argB.add(p.ID, p.Name, p.Vendor, p.Size)
 	}
@@ -105,11 +107,11 @@ func (r *ProductRepository) UpdateProduct(ctx context.Context, products ...*enti
 	if err != nil {
 		return nil, err
 	}
-	return entity.ScannedRows[*entity.Product](rows)
+	return entity.ScannedRows[entity.Product](rows)
 }
 
 func (r *ProductRepository) DeleteProduct(ctx context.Context, ids ...entity.PK) error {
-	_, err := r.DBI(ctx).ExecContext(ctx, "DELETE FROM products WHERE id IN ($1)", ids)
+	_, err := r.DBI(ctx).ExecContext(ctx, "DELETE FROM products WHERE id = ANY($1)", pkArray(ids))
 	return err
 }
 
@@ -124,9 +126,13 @@ type ListProductFilter struct {
 
 func (f *ListProductFilter) apply(q *strings.Builder) ([]interface{}, error) {
 	args := []interface{}{}
+	if f == nil {
+		return args, nil
+	}
 	if len(f.Vendors) > 0 {
-		q.WriteString("AND products.vendor IN ($1) ")
+		// []string передается как text[]
 		args = append(args, f.Vendors)
+		q.WriteString(fmt.Sprintf("AND products.vendor = ANY($%d) ", len(args)))
 	}
 	return args, nil
 }
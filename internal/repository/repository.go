@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"storageapi/internal/database"
+	"storageapi/internal/entity"
 	"strings"
 
 	"go.uber.org/zap"
@@ -41,6 +42,11 @@ type Repository struct {
 	*ProductRepository
 	*StoredProductRepository
 	*ReservationsRepository
+	*ReservationOrderRepository
+	*IdempotencyKeyRepository
+	*ShipmentRepository
+	*StockAdjustmentRepository
+	*TransferRepository
 }
 
 func NewRepository(db DBI, log *zap.SugaredLogger) IRepository {
@@ -49,11 +55,16 @@ func NewRepository(db DBI, log *zap.SugaredLogger) IRepository {
 		log: log,
 	}
 	return &Repository{
-		repoMixin:               mixin,
-		StorageRepository:       NewStorageRepository(db, log),
-		ProductRepository:       NewProductRepository(db, log),
-		StoredProductRepository: NewStoredProductRepository(db, log),
-		ReservationsRepository:  NewReservationsRepository(db, log),
+		repoMixin:                  mixin,
+		StorageRepository:          NewStorageRepository(db, log),
+		ProductRepository:          NewProductRepository(db, log),
+		StoredProductRepository:    NewStoredProductRepository(db, log),
+		ReservationsRepository:     NewReservationsRepository(db, log),
+		ReservationOrderRepository: NewReservationOrderRepository(db, log),
+		IdempotencyKeyRepository:   NewIdempotencyKeyRepository(db, log),
+		ShipmentRepository:         NewShipmentRepository(db, log),
+		StockAdjustmentRepository:  NewStockAdjustmentRepository(db, log),
+		TransferRepository:         NewTransferRepository(db, log),
 	}
 }
 
@@ -63,6 +74,11 @@ type IRepository interface {
 	IProductRepository
 	IStoredProductRepository
 	IReservationsRepository
+	IReservationOrderRepository
+	IIdempotencyKeyRepository
+	IShipmentRepository
+	IStockAdjustmentRepository
+	ITransferRepository
 }
 
 // нужен для сбора значений в аргументы insert
@@ -71,13 +87,20 @@ type argBuilder struct {
 	i    int
 	b    strings.Builder
 	args []interface{}
+	// типы колонок для приведения плейсхолдеров, нужны в UPDATE ... FROM (VALUES ...),
+	// иначе postgres считает параметры text
+	types []string
 }
 
 func (b *argBuilder) add(args ...interface{}) {
 	placeholders := make([]string, 0, len(args))
-	for _, a := range args {
-		placeholders = append(placeholders, fmt.Sprintf("$%d", b.i+1))
-		args = append(args, a)
+	for i, a := range args {
+		placeholder := fmt.Sprintf("$%d", b.i+1)
+		if i < len(b.types) {
+			placeholder += "::" + b.types[i]
+		}
+		placeholders = append(placeholders, placeholder)
+		b.args = append(b.args, a)
 		b.i++
 	}
 	b.b.WriteString("(" + strings.Join(placeholders, ",") + "),")
@@ -86,3 +109,14 @@ func (b *argBuilder) add(args ...interface{}) {
 func (b *argBuilder) done() (string, []interface{}) {
 	return b.b.String()[:b.b.Len()-1], b.args
 }
+
+// pgx не раскрывает слайс в IN ($1), поэтому списки передаются
+// массивом и проверяются через = ANY($1): id - bigint[], строки - text[].
+// пустой список дает пустой массив, под который не подходит ни одна строка
+func pkArray(ids []entity.PK) []int64 {
+	result := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		result = append(result, int64(id))
+	}
+	return result
+}
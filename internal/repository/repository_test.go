@@ -0,0 +1,27 @@
+package repository_test
+
+import (
+	"storageapi/internal/database"
+	"storageapi/internal/repository"
+	"storageapi/internal/repository/repotest"
+	"storageapi/internal/test/testdb"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestMain(m *testing.M) {
+	testdb.Main(m)
+}
+
+// каждый тест набора получает свою схему с миграциями
+func TestConformance(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repository.IRepository {
+		db, err := database.NewDBWithPgx(testdb.New(t))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(db.Close)
+		return repository.NewRepository(db, zap.NewNop().Sugar())
+	})
+}
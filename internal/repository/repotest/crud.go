@@ -0,0 +1,334 @@
+package repotest
+
+import (
+	"context"
+	"storageapi/internal/database"
+	"storageapi/internal/entity"
+	"storageapi/internal/repository"
+	"testing"
+)
+
+func testStorages(t *testing.T, repo repository.IRepository) {
+	ctx := context.Background()
+	created, err := repo.CreateStorage(ctx,
+		&entity.Storage{IsAvailable: true},
+		&entity.Storage{IsAvailable: false},
+		&entity.Storage{IsAvailable: true},
+	)
+	must(t, err)
+	ids := pksOf(created, storageID)
+	if len(ids) != 3 || !orderedPKs(ids, sortedPKs(ids)) {
+		t.Fatalf("expected 3 storages with ascending ids, got %v", ids)
+	}
+	if !created[0].IsAvailable || created[1].IsAvailable {
+		t.Fatalf("created storages do not match input: %+v", created)
+	}
+
+	s, err := repo.GetStorage(ctx, ids[1])
+	must(t, err)
+	if s.ID != ids[1] || s.IsAvailable {
+		t.Fatalf("got storage %+v", s)
+	}
+	_, err = repo.GetStorage(ctx, missing(ids...))
+	expectNotFound(t, err)
+	err = repo.RunInTransaction(ctx, func(ctx database.TxContext, repo repository.IRepository) error {
+		s, err := repo.LockStorage(ctx, ids[0])
+		if err != nil {
+			return err
+		}
+		if s.ID != ids[0] {
+			t.Errorf("locked storage %+v", s)
+		}
+		_, err = repo.LockStorage(ctx, missing(ids...))
+		expectNotFound(t, err)
+		return nil
+	})
+	must(t, err)
+
+	available, unavailable := true, false
+	filters := []struct {
+		name   string
+		filter *repository.ListStorageFilter
+		want   []int
+	}{
+		{"nil", nil, []int{0, 1, 2}},
+		{"ids", &repository.ListStorageFilter{IDs: pick(ids, []int{2, 0})}, []int{0, 2}},
+		{"available", &repository.ListStorageFilter{IsAvailable: &available}, []int{0, 2}},
+		{"ids and unavailable", &repository.ListStorageFilter{IDs: ids[:1], IsAvailable: &unavailable}, []int{}},
+		{"limit", &repository.ListStorageFilter{Limit: 2}, []int{0, 1}},
+		{"limit and offset", &repository.ListStorageFilter{Limit: 1, Offset: 1}, []int{1}},
+		{"offset past end", &repository.ListStorageFilter{Offset: 3}, []int{}},
+	}
+	for _, f := range filters {
+		storages, err := repo.ListStorages(ctx, f.filter)
+		must(t, err)
+		if got, want := pksOf(storages, storageID), pick(ids, f.want); !orderedPKs(got, want) {
+			t.Errorf("ListStorages %s: got %v, want %v", f.name, got, want)
+		}
+	}
+
+	// строки, которых нет, не обновляются и не возвращаются
+	updated, err := repo.UpdateStorage(ctx, storageWithID(ids[1], true), storageWithID(missing(ids...), true))
+	must(t, err)
+	if len(updated) != 1 || updated[0].ID != ids[1] || !updated[0].IsAvailable {
+		t.Fatalf("updated %+v", updated)
+	}
+	s, err = repo.GetStorage(ctx, ids[1])
+	must(t, err)
+	if !s.IsAvailable {
+		t.Fatal("update is not saved")
+	}
+
+	must(t, repo.DeleteStorage(ctx, ids[0]))
+	_, err = repo.GetStorage(ctx, ids[0])
+	expectNotFound(t, err)
+	storages, err := repo.ListStorages(ctx, nil)
+	must(t, err)
+	if got := pksOf(storages, storageID); !orderedPKs(got, ids[1:]) {
+		t.Fatalf("after delete got %v, want %v", got, ids[1:])
+	}
+}
+
+func testProducts(t *testing.T, repo repository.IRepository) {
+	ctx := context.Background()
+	created, err := repo.CreateProduct(ctx,
+		&entity.Product{Name: "shirt", Vendor: "v-0", Size: "m"},
+		&entity.Product{Name: "hat", Vendor: "v-1", Size: "s"},
+	)
+	must(t, err)
+	ids := pksOf(created, productID)
+	if len(ids) != 2 || ids[0] >= ids[1] {
+		t.Fatalf("expected 2 products with ascending ids, got %v", ids)
+	}
+
+	p, err := repo.GetProduct(ctx, ids[1])
+	must(t, err)
+	if p.ID != ids[1] || p.Name != "hat" || p.Vendor != "v-1" || p.Size != "s" {
+		t.Fatalf("got product %+v", p)
+	}
+	_, err = repo.GetProduct(ctx, missing(ids...))
+	expectNotFound(t, err)
+
+	products, err := repo.GetProducts(ctx, ids[1], missing(ids...), ids[0])
+	must(t, err)
+	if got := pksOf(products, productID); !orderedPKs(got, ids) {
+		t.Fatalf("GetProducts got %v, want %v", got, ids)
+	}
+	products, err = repo.ListProducts(ctx, nil)
+	must(t, err)
+	if got := pksOf(products, productID); !samePKs(got, ids) {
+		t.Fatalf("ListProducts without filter got %v, want %v", got, ids)
+	}
+	products, err = repo.ListProducts(ctx, &repository.ListProductFilter{Vendors: []string{"v-1", "absent"}})
+	must(t, err)
+	if got := pksOf(products, productID); !samePKs(got, ids[1:]) {
+		t.Fatalf("ListProducts by vendor got %v, want %v", got, ids[1:])
+	}
+
+	// выборка соединяется с остатками, товар повторяется по числу складов
+	storages := createStorages(t, repo, 2)
+	_, err = repo.CreateStorageData(ctx,
+		&entity.StoredProduct{StorageID: storages[0], ProductID: ids[0], Amount: 1},
+		&entity.StoredProduct{StorageID: storages[1], ProductID: ids[0], Amount: 1},
+	)
+	must(t, err)
+	products, err = repo.ListProducts(ctx, &repository.ListProductFilter{Vendors: []string{"v-0"}})
+	must(t, err)
+	if got := pksOf(products, productID); !samePKs(got, []entity.PK{ids[0], ids[0]}) {
+		t.Fatalf("ListProducts of product stored twice got %v", got)
+	}
+
+	// vendor уникален, запрос с нарушением не сохраняет ни одной строки
+	_, err = repo.CreateProduct(ctx,
+		&entity.Product{Name: "scarf", Vendor: "v-2", Size: "l"},
+		&entity.Product{Name: "shirt", Vendor: "v-0", Size: "l"},
+	)
+	expectCode(t, err, codeUniqueViolation)
+	products, err = repo.ListProducts(ctx, &repository.ListProductFilter{Vendors: []string{"v-2"}})
+	must(t, err)
+	if len(products) != 0 {
+		t.Fatalf("failed insert left %+v", products)
+	}
+
+	updated, err := repo.UpdateProduct(ctx,
+		productWithID(ids[1], "cap", "v-1b", "xl"),
+		productWithID(missing(ids...), "none", "v-none", "xl"),
+	)
+	must(t, err)
+	if len(updated) != 1 || updated[0].ID != ids[1] {
+		t.Fatalf("updated %+v", updated)
+	}
+	p, err = repo.GetProduct(ctx, ids[1])
+	must(t, err)
+	if p.Name != "cap" || p.Vendor != "v-1b" || p.Size != "xl" {
+		t.Fatalf("update is not saved: %+v", p)
+	}
+	_, err = repo.UpdateProduct(ctx, productWithID(ids[1], "cap", "v-0", "xl"))
+	expectCode(t, err, codeUniqueViolation)
+
+	// на таблицы ссылаются внешние ключи, TRUNCATE без CASCADE запрещен
+	expectCode(t, repo.TruncateProducts(ctx), codeFeatureNotSupported)
+	expectCode(t, repo.TruncateStorages(ctx), codeFeatureNotSupported)
+
+	must(t, repo.DeleteProduct(ctx, ids[0]))
+	_, err = repo.GetProduct(ctx, ids[0])
+	expectNotFound(t, err)
+}
+
+func testStoredProducts(t *testing.T, repo repository.IRepository) {
+	ctx := context.Background()
+	storages := createStorages(t, repo, 2)
+	products := createProducts(t, repo, "sp-0", "sp-1")
+
+	created, err := repo.CreateStorageData(ctx,
+		&entity.StoredProduct{StorageID: storages[0], ProductID: products[0], Amount: 5},
+		&entity.StoredProduct{StorageID: storages[1], ProductID: products[0], Amount: 2},
+	)
+	must(t, err)
+	ids := pksOf(created, storedID)
+	if len(ids) != 2 || created[0].Amount != 5 || created[1].StorageID != storages[1] {
+		t.Fatalf("created %+v", created)
+	}
+	_, err = repo.CreateStorageData(ctx, &entity.StoredProduct{StorageID: storages[0], ProductID: products[0], Amount: 1})
+	expectCode(t, err, codeUniqueViolation)
+	_, err = repo.CreateStorageData(ctx, &entity.StoredProduct{StorageID: missing(storages...), ProductID: products[0], Amount: 1})
+	expectCode(t, err, codeForeignKeyViolation)
+	_, err = repo.CreateStorageData(ctx, &entity.StoredProduct{StorageID: storages[0], ProductID: missing(products...), Amount: 1})
+	expectCode(t, err, codeForeignKeyViolation)
+
+	sp, err := repo.GetStorageData(ctx, ids[0])
+	must(t, err)
+	if sp.StorageID != storages[0] || sp.ProductID != products[0] || sp.Amount != 5 {
+		t.Fatalf("got %+v", sp)
+	}
+	_, err = repo.GetStorageData(ctx, missing(ids...))
+	expectNotFound(t, err)
+
+	data, err := repo.GetStorageDataByProduct(ctx, products[0])
+	must(t, err)
+	if got := pksOf(data, storedID); !orderedPKs(got, ids) {
+		t.Fatalf("GetStorageDataByProduct got %v, want %v", got, ids)
+	}
+	data, err = repo.GetStorageDataByStorage(ctx, storages[1])
+	must(t, err)
+	if got := pksOf(data, storedID); !orderedPKs(got, ids[1:]) {
+		t.Fatalf("GetStorageDataByStorage got %v, want %v", got, ids[1:])
+	}
+
+	// upsert добавляет к существующей строке или создает новую
+	upserted, err := repo.UpsertStorageData(ctx,
+		&entity.StoredProduct{StorageID: storages[0], ProductID: products[0], Amount: 2},
+		&entity.StoredProduct{StorageID: storages[0], ProductID: products[1], Amount: 3},
+	)
+	must(t, err)
+	if len(upserted) != 2 {
+		t.Fatalf("upserted %+v", upserted)
+	}
+	sp, err = repo.GetStorageData(ctx, ids[0])
+	must(t, err)
+	if sp.Amount != 7 {
+		t.Fatalf("expected amount 7 after upsert, got %d", sp.Amount)
+	}
+	// одна строка не обновляется дважды за запрос
+	_, err = repo.UpsertStorageData(ctx,
+		&entity.StoredProduct{StorageID: storages[1], ProductID: products[1], Amount: 1},
+		&entity.StoredProduct{StorageID: storages[1], ProductID: products[1], Amount: 1},
+	)
+	expectCode(t, err, codeCardinalityViolation)
+	data, err = repo.GetStorageDataByStorage(ctx, storages[1])
+	must(t, err)
+	if len(data) != 1 {
+		t.Fatalf("failed upsert left %+v", data)
+	}
+
+	updated, err := repo.UpdateStorageData(ctx,
+		&entity.StoredProduct{ID: ids[1], Amount: 9},
+		&entity.StoredProduct{ID: missing(ids...), Amount: 9},
+	)
+	must(t, err)
+	if len(updated) != 1 || updated[0].ID != ids[1] || updated[0].Amount != 9 {
+		t.Fatalf("updated %+v", updated)
+	}
+
+	// резерв больше остатка не делает свободный остаток отрицательным
+	_, err = repo.CreateReservation(ctx, &entity.ProductReservation{StorageID: storages[0], ProductID: products[0], Amount: 10})
+	must(t, err)
+	stock, err := repo.GetStockByStorage(ctx)
+	must(t, err)
+	want := []entity.StorageStock{
+		{StorageID: storages[0], Stored: 10, Reserved: 10, Free: 3},
+		{StorageID: storages[1], Stored: 9, Reserved: 0, Free: 9},
+	}
+	if len(stock) != len(want) {
+		t.Fatalf("got stock %+v", stock)
+	}
+	for i := range want {
+		if *stock[i] != want[i] {
+			t.Errorf("stock[%d] = %+v, want %+v", i, *stock[i], want[i])
+		}
+	}
+
+	must(t, repo.DeleteStorageData(ctx, ids[1]))
+	data, err = repo.GetStorageDataByStorage(ctx, storages[1])
+	must(t, err)
+	if len(data) != 0 {
+		t.Fatalf("after delete got %+v", data)
+	}
+}
+
+func testReservations(t *testing.T, repo repository.IRepository) {
+	ctx := context.Background()
+	storages := createStorages(t, repo, 1)
+	products := createProducts(t, repo, "pr-0", "pr-1")
+
+	created, err := repo.CreateReservation(ctx,
+		&entity.ProductReservation{StorageID: storages[0], ProductID: products[0], Amount: 2},
+		&entity.ProductReservation{StorageID: storages[0], ProductID: products[1], Amount: 1},
+	)
+	must(t, err)
+	ids := pksOf(created, reservationID)
+	if len(ids) != 2 || created[0].Amount != 2 || created[1].ProductID != products[1] {
+		t.Fatalf("created %+v", created)
+	}
+	_, err = repo.CreateReservation(ctx, &entity.ProductReservation{StorageID: storages[0], ProductID: products[0], Amount: 1})
+	expectCode(t, err, codeUniqueViolation)
+	_, err = repo.CreateReservation(ctx, &entity.ProductReservation{StorageID: storages[0], ProductID: missing(products...), Amount: 1})
+	expectCode(t, err, codeForeignKeyViolation)
+
+	r, err := repo.GetReservation(ctx, ids[0])
+	must(t, err)
+	if r.StorageID != storages[0] || r.ProductID != products[0] || r.Amount != 2 {
+		t.Fatalf("got %+v", r)
+	}
+	_, err = repo.GetReservation(ctx, missing(ids...))
+	expectNotFound(t, err)
+
+	reservations, err := repo.GetReservationByProduct(ctx, products[1])
+	must(t, err)
+	if got := pksOf(reservations, reservationID); !samePKs(got, ids[1:]) {
+		t.Fatalf("GetReservationByProduct got %v, want %v", got, ids[1:])
+	}
+	reservations, err = repo.GetReservationByStorage(ctx, storages[0])
+	must(t, err)
+	if got := pksOf(reservations, reservationID); !samePKs(got, ids) {
+		t.Fatalf("GetReservationByStorage got %v, want %v", got, ids)
+	}
+
+	updated, err := repo.UpdateReservation(ctx, &entity.ProductReservation{ID: ids[0], Amount: 4})
+	must(t, err)
+	if len(updated) != 1 || updated[0].Amount != 4 {
+		t.Fatalf("updated %+v", updated)
+	}
+	r, err = repo.GetReservation(ctx, ids[0])
+	must(t, err)
+	if r.Amount != 4 {
+		t.Fatalf("update is not saved: %+v", r)
+	}
+
+	must(t, repo.DeleteReservation(ctx, ids[0]))
+	reservations, err = repo.GetReservationByStorage(ctx, storages[0])
+	must(t, err)
+	if got := pksOf(reservations, reservationID); !samePKs(got, ids[1:]) {
+		t.Fatalf("after delete got %v, want %v", got, ids[1:])
+	}
+}
@@ -0,0 +1,254 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"storageapi/internal/database"
+	"storageapi/internal/entity"
+	"storageapi/internal/repository"
+	"storageapi/pkg/algo"
+	"testing"
+)
+
+// по три строки каждой таблицы, строка i связана со складом i и товаром i
+type listFixture struct {
+	storages, products, stored, reservations     []entity.PK
+	orders, orderItems, transfers, transferItems []entity.PK
+	vendors                                      []string
+}
+
+func seedListFixture(ctx context.Context, repo repository.IRepository) (*listFixture, error) {
+	const n = 3
+	f := &listFixture{}
+	err := repo.RunInTransaction(ctx, func(ctx database.TxContext, repo repository.IRepository) error {
+		storages, err := repo.CreateStorage(ctx, &entity.Storage{IsAvailable: true}, &entity.Storage{IsAvailable: true}, &entity.Storage{IsAvailable: true})
+		if err != nil {
+			return err
+		}
+		products := make([]*entity.Product, 0, n)
+		for i := 0; i < n; i++ {
+			products = append(products, &entity.Product{Name: "product", Vendor: fmt.Sprintf("list-%d", i), Size: "m"})
+		}
+		if products, err = repo.CreateProduct(ctx, products...); err != nil {
+			return err
+		}
+		stored := make([]*entity.StoredProduct, 0, n)
+		reservations := make([]*entity.ProductReservation, 0, n)
+		orders := make([]*entity.ReservationOrder, 0, n)
+		transfers := make([]*entity.Transfer, 0, n)
+		for i := 0; i < n; i++ {
+			stored = append(stored, &entity.StoredProduct{StorageID: storages[i].ID, ProductID: products[i].ID, Amount: 10})
+			reservations = append(reservations, &entity.ProductReservation{StorageID: storages[i].ID, ProductID: products[i].ID, Amount: 1})
+			orders = append(orders, &entity.ReservationOrder{Status: entity.ReservationStatusActive})
+			transfers = append(transfers, &entity.Transfer{
+				SourceStorageID:      storages[i].ID,
+				DestinationStorageID: storages[(i+1)%n].ID,
+				Status:               entity.TransferStatusInTransit,
+			})
+		}
+		if stored, err = repo.CreateStorageData(ctx, stored...); err != nil {
+			return err
+		}
+		if reservations, err = repo.CreateReservation(ctx, reservations...); err != nil {
+			return err
+		}
+		if orders, err = repo.CreateReservationOrder(ctx, orders...); err != nil {
+			return err
+		}
+		if transfers, err = repo.CreateTransfer(ctx, transfers...); err != nil {
+			return err
+		}
+		orderItems := make([]*entity.ReservationOrderItem, 0, n)
+		transferItems := make([]*entity.TransferItem, 0, n)
+		for i := 0; i < n; i++ {
+			orderItems = append(orderItems, &entity.ReservationOrderItem{
+				ReservationID: orders[i].ID,
+				StorageID:     storages[i].ID,
+				ProductID:     products[i].ID,
+				Amount:        1,
+			})
+			transferItems = append(transferItems, &entity.TransferItem{TransferID: transfers[i].ID, ProductID: products[i].ID, Amount: 1})
+		}
+		if orderItems, err = repo.CreateReservationOrderItem(ctx, orderItems...); err != nil {
+			return err
+		}
+		if transferItems, err = repo.CreateTransferItem(ctx, transferItems...); err != nil {
+			return err
+		}
+
+		f.storages = algo.Map(storages, func(s *entity.Storage, _ int) entity.PK { return s.ID })
+		f.products = algo.Map(products, func(p *entity.Product, _ int) entity.PK { return p.ID })
+		f.vendors = algo.Map(products, func(p *entity.Product, _ int) string { return p.Vendor })
+		f.stored = algo.Map(stored, func(sp *entity.StoredProduct, _ int) entity.PK { return sp.ID })
+		f.reservations = algo.Map(reservations, func(r *entity.ProductReservation, _ int) entity.PK { return r.ID })
+		f.orders = algo.Map(orders, func(o *entity.ReservationOrder, _ int) entity.PK { return o.ID })
+		f.orderItems = algo.Map(orderItems, func(i *entity.ReservationOrderItem, _ int) entity.PK { return i.ID })
+		f.transfers = algo.Map(transfers, func(t *entity.Transfer, _ int) entity.PK { return t.ID })
+		f.transferItems = algo.Map(transferItems, func(i *entity.TransferItem, _ int) entity.PK { return i.ID })
+		return nil
+	})
+	return f, err
+}
+
+// списки id передаются массивом: пустой список не находит ничего,
+// один и несколько id находят ровно свои строки
+func testListParameters(t *testing.T, repo repository.IRepository) {
+	ctx := context.Background()
+	f, err := seedListFixture(ctx, repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	all := []int{0, 1, 2}
+
+	// run возвращает id найденных (или оставшихся после удаления) строк
+	// и id, которые ожидаются для выбранных индексов
+	cases := []struct {
+		name string
+		run  func(ctx database.TxContext, repo repository.IRepository, idx []int) (got, want []entity.PK, err error)
+	}{
+		{"GetProducts", func(ctx database.TxContext, repo repository.IRepository, idx []int) ([]entity.PK, []entity.PK, error) {
+			products, err := repo.GetProducts(ctx, pick(f.products, idx)...)
+			return pksOf(products, productID), pick(f.products, idx), err
+		}},
+		{"ListProducts", func(ctx database.TxContext, repo repository.IRepository, idx []int) ([]entity.PK, []entity.PK, error) {
+			products, err := repo.ListProducts(ctx, &repository.ListProductFilter{Vendors: pick(f.vendors, idx)})
+			// пустой фильтр не ограничивает выборку
+			if len(idx) == 0 {
+				idx = all
+			}
+			return pksOf(products, productID), pick(f.products, idx), err
+		}},
+		{"DeleteProduct", func(ctx database.TxContext, repo repository.IRepository, idx []int) ([]entity.PK, []entity.PK, error) {
+			if err := repo.DeleteProduct(ctx, pick(f.products, idx)...); err != nil {
+				return nil, nil, err
+			}
+			products, err := repo.GetProducts(ctx, f.products...)
+			return pksOf(products, productID), pick(f.products, rest(idx)), err
+		}},
+		{"ListStorages", func(ctx database.TxContext, repo repository.IRepository, idx []int) ([]entity.PK, []entity.PK, error) {
+			storages, err := repo.ListStorages(ctx, &repository.ListStorageFilter{IDs: pick(f.storages, idx)})
+			if len(idx) == 0 {
+				idx = all
+			}
+			return pksOf(storages, storageID), pick(f.storages, idx), err
+		}},
+		{"DeleteStorage", func(ctx database.TxContext, repo repository.IRepository, idx []int) ([]entity.PK, []entity.PK, error) {
+			if err := repo.DeleteStorage(ctx, pick(f.storages, idx)...); err != nil {
+				return nil, nil, err
+			}
+			storages, err := repo.ListStorages(ctx, &repository.ListStorageFilter{IDs: f.storages})
+			return pksOf(storages, storageID), pick(f.storages, rest(idx)), err
+		}},
+		{"GetStorageDataByProduct", func(ctx database.TxContext, repo repository.IRepository, idx []int) ([]entity.PK, []entity.PK, error) {
+			data, err := repo.GetStorageDataByProduct(ctx, pick(f.products, idx)...)
+			return pksOf(data, storedID), pick(f.stored, idx), err
+		}},
+		{"LockStorageDataByProduct", func(ctx database.TxContext, repo repository.IRepository, idx []int) ([]entity.PK, []entity.PK, error) {
+			data, err := repo.LockStorageDataByProduct(ctx, pick(f.products, idx)...)
+			return pksOf(data, storedID), pick(f.stored, idx), err
+		}},
+		{"GetStorageDataByStorage", func(ctx database.TxContext, repo repository.IRepository, idx []int) ([]entity.PK, []entity.PK, error) {
+			data, err := repo.GetStorageDataByStorage(ctx, pick(f.storages, idx)...)
+			return pksOf(data, storedID), pick(f.stored, idx), err
+		}},
+		{"LockStorageDataByStorage", func(ctx database.TxContext, repo repository.IRepository, idx []int) ([]entity.PK, []entity.PK, error) {
+			data, err := repo.LockStorageDataByStorage(ctx, pick(f.storages, idx)...)
+			return pksOf(data, storedID), pick(f.stored, idx), err
+		}},
+		{"DeleteStorageData", func(ctx database.TxContext, repo repository.IRepository, idx []int) ([]entity.PK, []entity.PK, error) {
+			if err := repo.DeleteStorageData(ctx, pick(f.stored, idx)...); err != nil {
+				return nil, nil, err
+			}
+			data, err := repo.GetStorageDataByStorage(ctx, f.storages...)
+			return pksOf(data, storedID), pick(f.stored, rest(idx)), err
+		}},
+		{"GetReservationByProduct", func(ctx database.TxContext, repo repository.IRepository, idx []int) ([]entity.PK, []entity.PK, error) {
+			reservations, err := repo.GetReservationByProduct(ctx, pick(f.products, idx)...)
+			return pksOf(reservations, reservationID), pick(f.reservations, idx), err
+		}},
+		{"GetReservationByStorage", func(ctx database.TxContext, repo repository.IRepository, idx []int) ([]entity.PK, []entity.PK, error) {
+			reservations, err := repo.GetReservationByStorage(ctx, pick(f.storages, idx)...)
+			return pksOf(reservations, reservationID), pick(f.reservations, idx), err
+		}},
+		{"DeleteReservation", func(ctx database.TxContext, repo repository.IRepository, idx []int) ([]entity.PK, []entity.PK, error) {
+			if err := repo.DeleteReservation(ctx, pick(f.reservations, idx)...); err != nil {
+				return nil, nil, err
+			}
+			reservations, err := repo.GetReservationByStorage(ctx, f.storages...)
+			return pksOf(reservations, reservationID), pick(f.reservations, rest(idx)), err
+		}},
+		{"GetReservationOrderItems", func(ctx database.TxContext, repo repository.IRepository, idx []int) ([]entity.PK, []entity.PK, error) {
+			items, err := repo.GetReservationOrderItems(ctx, pick(f.orders, idx)...)
+			return pksOf(items, orderItemID), pick(f.orderItems, idx), err
+		}},
+		{"DeleteReservationOrderItem", func(ctx database.TxContext, repo repository.IRepository, idx []int) ([]entity.PK, []entity.PK, error) {
+			if err := repo.DeleteReservationOrderItem(ctx, pick(f.orderItems, idx)...); err != nil {
+				return nil, nil, err
+			}
+			items, err := repo.GetReservationOrderItems(ctx, f.orders...)
+			return pksOf(items, orderItemID), pick(f.orderItems, rest(idx)), err
+		}},
+		{"GetTransferItems", func(ctx database.TxContext, repo repository.IRepository, idx []int) ([]entity.PK, []entity.PK, error) {
+			items, err := repo.GetTransferItems(ctx, pick(f.transfers, idx)...)
+			return pksOf(items, transferItemID), pick(f.transferItems, idx), err
+		}},
+	}
+	sizes := []struct {
+		name string
+		idx  []int
+	}{
+		{"zero", []int{}},
+		{"one", []int{1}},
+		{"many", []int{0, 2}},
+	}
+	for _, c := range cases {
+		for _, size := range sizes {
+			c, size := c, size
+			t.Run(c.name+"/"+size.name, func(t *testing.T) {
+				err := repo.RunInTransaction(ctx, func(ctx database.TxContext, repo repository.IRepository) error {
+					got, want, err := c.run(ctx, repo, size.idx)
+					if err != nil {
+						return err
+					}
+					if !samePKs(got, want) {
+						t.Errorf("got ids %v, want %v", sortedPKs(got), sortedPKs(want))
+					}
+					return errRollback
+				})
+				if !errors.Is(err, errRollback) {
+					t.Fatal(err)
+				}
+			})
+		}
+	}
+}
+
+// UpdateProduct передает значения через VALUES, без приведения типов postgres считает их text
+func testUpdateProduct(t *testing.T, repo repository.IRepository) {
+	ctx := context.Background()
+	f, err := seedListFixture(ctx, repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changes := []*entity.Product{
+		{Name: "renamed-0", Vendor: "upd-0", Size: "s"},
+		{Name: "renamed-2", Vendor: "upd-2", Size: "l"},
+	}
+	changes[0].ID, changes[1].ID = f.products[0], f.products[2]
+	updated, err := repo.UpdateProduct(ctx, changes...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !samePKs(pksOf(updated, productID), pick(f.products, []int{0, 2})) {
+		t.Fatalf("updated %+v", updated)
+	}
+	products, err := repo.ListProducts(ctx, &repository.ListProductFilter{Vendors: []string{"upd-0", "upd-2"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(products) != 2 {
+		t.Fatalf("found %d updated products, want 2", len(products))
+	}
+}
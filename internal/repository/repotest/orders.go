@@ -0,0 +1,367 @@
+package repotest
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"storageapi/internal/database"
+	"storageapi/internal/entity"
+	"storageapi/internal/repository"
+	"testing"
+	"time"
+)
+
+func testReservationOrders(t *testing.T, repo repository.IRepository) {
+	ctx := context.Background()
+	storages := createStorages(t, repo, 2)
+	products := createProducts(t, repo, "ro-0")
+	// postgres хранит микросекунды, сравниваем с тем же округлением
+	now := time.Now().Truncate(time.Microsecond)
+	at := func(d time.Duration) *time.Time {
+		t := now.Add(d)
+		return &t
+	}
+
+	created, err := repo.CreateReservationOrder(ctx,
+		&entity.ReservationOrder{Status: entity.ReservationStatusActive, ExpiresAt: at(-time.Hour)},
+		&entity.ReservationOrder{Status: entity.ReservationStatusActive, ExpiresAt: at(-2 * time.Hour)},
+		&entity.ReservationOrder{Status: entity.ReservationStatusActive, ExpiresAt: at(time.Hour)},
+		&entity.ReservationOrder{Status: entity.ReservationStatusActive},
+		&entity.ReservationOrder{Status: entity.ReservationStatusCancelled, ExpiresAt: at(-3 * time.Hour)},
+	)
+	must(t, err)
+	ids := pksOf(created, orderID)
+	if len(ids) != 5 || created[0].CreatedAt.IsZero() || !created[0].ExpiresAt.Equal(*at(-time.Hour)) || created[3].ExpiresAt != nil {
+		t.Fatalf("created %+v", created)
+	}
+	o, err := repo.GetReservationOrder(ctx, ids[1])
+	must(t, err)
+	if o.Status != entity.ReservationStatusActive || o.ExpiresAt == nil || !o.ExpiresAt.Equal(*at(-2 * time.Hour)) {
+		t.Fatalf("got %+v", o)
+	}
+	_, err = repo.GetReservationOrder(ctx, missing(ids...))
+	expectNotFound(t, err)
+
+	items, err := repo.CreateReservationOrderItem(ctx,
+		&entity.ReservationOrderItem{ReservationID: ids[0], StorageID: storages[0], ProductID: products[0], Amount: 1},
+		&entity.ReservationOrderItem{ReservationID: ids[0], StorageID: storages[1], ProductID: products[0], Amount: 1},
+		&entity.ReservationOrderItem{ReservationID: ids[2], StorageID: storages[1], ProductID: products[0], Amount: 2},
+	)
+	must(t, err)
+	itemIDs := pksOf(items, orderItemID)
+	_, err = repo.CreateReservationOrderItem(ctx,
+		&entity.ReservationOrderItem{ReservationID: ids[0], StorageID: storages[0], ProductID: products[0], Amount: 1},
+	)
+	expectCode(t, err, codeUniqueViolation)
+	_, err = repo.CreateReservationOrderItem(ctx,
+		&entity.ReservationOrderItem{ReservationID: missing(ids...), StorageID: storages[0], ProductID: products[0], Amount: 1},
+	)
+	expectCode(t, err, codeForeignKeyViolation)
+	items, err = repo.GetReservationOrderItems(ctx, ids[0])
+	must(t, err)
+	if got := pksOf(items, orderItemID); !orderedPKs(got, itemIDs[:2]) {
+		t.Fatalf("GetReservationOrderItems got %v, want %v", got, itemIDs[:2])
+	}
+
+	err = repo.RunInTransaction(ctx, func(ctx database.TxContext, repo repository.IRepository) error {
+		// истекшие активные заказы, раньше истекшие первыми
+		expired, err := repo.LockExpiredReservationOrders(ctx, now, 10)
+		if err != nil {
+			return err
+		}
+		if got, want := pksOf(expired, orderID), pick(ids, []int{1, 0}); !orderedPKs(got, want) {
+			t.Errorf("LockExpiredReservationOrders got %v, want %v", got, want)
+		}
+		expired, err = repo.LockExpiredReservationOrders(ctx, now, 1)
+		if err != nil {
+			return err
+		}
+		if got, want := pksOf(expired, orderID), pick(ids, []int{1}); !orderedPKs(got, want) {
+			t.Errorf("LockExpiredReservationOrders with limit got %v, want %v", got, want)
+		}
+
+		byStorage, err := repo.LockReservationOrdersByStorage(ctx, storages[1], entity.ReservationStatusActive)
+		if err != nil {
+			return err
+		}
+		if got, want := pksOf(byStorage, orderID), pick(ids, []int{0, 2}); !orderedPKs(got, want) {
+			t.Errorf("LockReservationOrdersByStorage got %v, want %v", got, want)
+		}
+		byStorage, err = repo.LockReservationOrdersByStorage(ctx, storages[0], entity.ReservationStatusCancelled)
+		if err != nil {
+			return err
+		}
+		if len(byStorage) != 0 {
+			t.Errorf("LockReservationOrdersByStorage for cancelled got %+v", byStorage)
+		}
+		locked, err := repo.LockReservationOrder(ctx, ids[2])
+		if err != nil {
+			return err
+		}
+		if locked.ID != ids[2] {
+			t.Errorf("locked %+v", locked)
+		}
+		return nil
+	})
+	must(t, err)
+
+	updated, err := repo.UpdateReservationOrder(ctx, &entity.ReservationOrder{ID: ids[1], Status: entity.ReservationStatusExpired})
+	must(t, err)
+	if len(updated) != 1 || updated[0].Status != entity.ReservationStatusExpired || !updated[0].ExpiresAt.Equal(*at(-2 * time.Hour)) {
+		t.Fatalf("updated %+v", updated)
+	}
+
+	// перенос позиции на склад, где у заказа уже есть этот товар, нарушает уникальность
+	_, err = repo.UpdateReservationOrderItem(ctx,
+		&entity.ReservationOrderItem{ID: itemIDs[1], StorageID: storages[0], Amount: 1},
+	)
+	expectCode(t, err, codeUniqueViolation)
+	updatedItems, err := repo.UpdateReservationOrderItem(ctx,
+		&entity.ReservationOrderItem{ID: itemIDs[2], StorageID: storages[0], Amount: 5},
+	)
+	must(t, err)
+	if len(updatedItems) != 1 || updatedItems[0].StorageID != storages[0] || updatedItems[0].Amount != 5 || updatedItems[0].ReservationID != ids[2] {
+		t.Fatalf("updated items %+v", updatedItems)
+	}
+
+	must(t, repo.DeleteReservationOrderItem(ctx, itemIDs[0]))
+	items, err = repo.GetReservationOrderItems(ctx, ids...)
+	must(t, err)
+	if got := pksOf(items, orderItemID); !orderedPKs(got, itemIDs[1:]) {
+		t.Fatalf("after delete got %v, want %v", got, itemIDs[1:])
+	}
+}
+
+func testTransfers(t *testing.T, repo repository.IRepository) {
+	ctx := context.Background()
+	storages := createStorages(t, repo, 2)
+	products := createProducts(t, repo, "tr-0", "tr-1")
+
+	created, err := repo.CreateTransfer(ctx, &entity.Transfer{
+		SourceStorageID:      storages[0],
+		DestinationStorageID: storages[1],
+		Status:               entity.TransferStatusInTransit,
+	})
+	must(t, err)
+	if len(created) != 1 || created[0].CreatedAt.IsZero() || !created[0].UpdatedAt.Equal(created[0].CreatedAt) {
+		t.Fatalf("created %+v", created)
+	}
+	transfer := created[0]
+	_, err = repo.CreateTransfer(ctx, &entity.Transfer{
+		SourceStorageID:      storages[0],
+		DestinationStorageID: storages[0],
+		Status:               entity.TransferStatusInTransit,
+	})
+	expectCode(t, err, codeCheckViolation)
+	_, err = repo.CreateTransfer(ctx, &entity.Transfer{
+		SourceStorageID:      storages[0],
+		DestinationStorageID: missing(storages...),
+		Status:               entity.TransferStatusInTransit,
+	})
+	expectCode(t, err, codeForeignKeyViolation)
+
+	items, err := repo.CreateTransferItem(ctx,
+		&entity.TransferItem{TransferID: transfer.ID, ProductID: products[0], Amount: 1},
+		&entity.TransferItem{TransferID: transfer.ID, ProductID: products[1], Amount: 2},
+	)
+	must(t, err)
+	itemIDs := pksOf(items, transferItemID)
+	_, err = repo.CreateTransferItem(ctx, &entity.TransferItem{TransferID: transfer.ID, ProductID: products[0], Amount: 1})
+	expectCode(t, err, codeUniqueViolation)
+	_, err = repo.CreateTransferItem(ctx, &entity.TransferItem{TransferID: missing(transfer.ID), ProductID: products[0], Amount: 1})
+	expectCode(t, err, codeForeignKeyViolation)
+	items, err = repo.GetTransferItems(ctx, transfer.ID)
+	must(t, err)
+	if got := pksOf(items, transferItemID); !orderedPKs(got, itemIDs) {
+		t.Fatalf("GetTransferItems got %v, want %v", got, itemIDs)
+	}
+
+	err = repo.RunInTransaction(ctx, func(ctx database.TxContext, repo repository.IRepository) error {
+		locked, err := repo.LockTransfer(ctx, transfer.ID)
+		if err != nil {
+			return err
+		}
+		updated, err := repo.UpdateTransfer(ctx, &entity.Transfer{ID: locked.ID, Status: entity.TransferStatusReceived})
+		if err != nil {
+			return err
+		}
+		if len(updated) != 1 || updated[0].Status != entity.TransferStatusReceived || updated[0].UpdatedAt.Before(updated[0].CreatedAt) {
+			t.Errorf("updated %+v", updated)
+		}
+		return nil
+	})
+	must(t, err)
+	got, err := repo.GetTransfer(ctx, transfer.ID)
+	must(t, err)
+	if got.Status != entity.TransferStatusReceived || got.SourceStorageID != storages[0] || got.DestinationStorageID != storages[1] {
+		t.Fatalf("got %+v", got)
+	}
+	_, err = repo.GetTransfer(ctx, missing(transfer.ID))
+	expectNotFound(t, err)
+}
+
+func testShipments(t *testing.T, repo repository.IRepository) {
+	ctx := context.Background()
+	storages := createStorages(t, repo, 1)
+	products := createProducts(t, repo, "sh-0")
+	orders, err := repo.CreateReservationOrder(ctx, &entity.ReservationOrder{Status: entity.ReservationStatusConfirmed})
+	must(t, err)
+
+	shipments, err := repo.CreateShipment(ctx, &entity.Shipment{ReservationID: orders[0].ID})
+	must(t, err)
+	if len(shipments) != 1 || shipments[0].ReservationID != orders[0].ID || shipments[0].CreatedAt.IsZero() {
+		t.Fatalf("created %+v", shipments)
+	}
+	// заказ отгружается один раз
+	_, err = repo.CreateShipment(ctx, &entity.Shipment{ReservationID: orders[0].ID})
+	expectCode(t, err, codeUniqueViolation)
+	_, err = repo.CreateShipment(ctx, &entity.Shipment{ReservationID: missing(orders[0].ID)})
+	expectCode(t, err, codeForeignKeyViolation)
+
+	items, err := repo.CreateShipmentItem(ctx, &entity.ShipmentItem{
+		ShipmentID: shipments[0].ID,
+		StorageID:  storages[0],
+		ProductID:  products[0],
+		Amount:     2,
+	})
+	must(t, err)
+	if len(items) != 1 || items[0].ShipmentID != shipments[0].ID || items[0].Amount != 2 {
+		t.Fatalf("created items %+v", items)
+	}
+	_, err = repo.CreateShipmentItem(ctx, &entity.ShipmentItem{
+		ShipmentID: missing(shipments[0].ID),
+		StorageID:  storages[0],
+		ProductID:  products[0],
+		Amount:     2,
+	})
+	expectCode(t, err, codeForeignKeyViolation)
+}
+
+func testStockAdjustments(t *testing.T, repo repository.IRepository) {
+	ctx := context.Background()
+	storages := createStorages(t, repo, 1)
+	products := createProducts(t, repo, "sa-0")
+
+	created, err := repo.CreateStockAdjustment(ctx, &entity.StockAdjustment{
+		StorageID: storages[0],
+		ProductID: products[0],
+		Delta:     -2,
+		Reason:    entity.StockReasonDamage,
+		Comment:   "broken box",
+	})
+	must(t, err)
+	if len(created) != 1 {
+		t.Fatalf("created %+v", created)
+	}
+	a := created[0]
+	if a.StorageID != storages[0] || a.ProductID != products[0] || a.Delta != -2 ||
+		a.Reason != entity.StockReasonDamage || a.Comment != "broken box" || a.CreatedAt.IsZero() {
+		t.Fatalf("created %+v", a)
+	}
+	second, err := repo.CreateStockAdjustment(ctx, &entity.StockAdjustment{
+		StorageID: storages[0],
+		ProductID: products[0],
+		Delta:     5,
+		Reason:    entity.StockReasonReceipt,
+	})
+	must(t, err)
+	journal, err := repo.GetStockAdjustmentsByStorage(ctx, storages[0])
+	must(t, err)
+	if len(journal) != 2 || journal[0].ID != a.ID || journal[1].ID != second[0].ID {
+		t.Fatalf("journal %+v", journal)
+	}
+	journal, err = repo.GetStockAdjustmentsByStorage(ctx, missing(storages...))
+	must(t, err)
+	if len(journal) != 0 {
+		t.Fatalf("journal of missing storage %+v", journal)
+	}
+	_, err = repo.CreateStockAdjustment(ctx, &entity.StockAdjustment{
+		StorageID: missing(storages...),
+		ProductID: products[0],
+		Delta:     1,
+		Reason:    entity.StockReasonRecount,
+	})
+	expectCode(t, err, codeForeignKeyViolation)
+}
+
+func testIdempotencyKeys(t *testing.T, repo repository.IRepository) {
+	ctx := context.Background()
+	err := repo.RunInTransaction(ctx, func(ctx database.TxContext, repo repository.IRepository) error {
+		if err := repo.LockIdempotencyKey(ctx, "Reservation.ReserveProducts", "key-1"); err != nil {
+			return err
+		}
+		k, err := repo.FindIdempotencyKey(ctx, "Reservation.ReserveProducts", "key-1")
+		if err != nil {
+			return err
+		}
+		if k != nil {
+			t.Errorf("unused key found: %+v", k)
+		}
+		return repo.CreateIdempotencyKey(ctx, &entity.IdempotencyKey{
+			Method:      "Reservation.ReserveProducts",
+			Key:         "key-1",
+			RequestHash: "hash",
+			Response:    []byte(`{"id":1,"status":"active"}`),
+		})
+	})
+	must(t, err)
+
+	k, err := repo.FindIdempotencyKey(ctx, "Reservation.ReserveProducts", "key-1")
+	must(t, err)
+	if k == nil || k.RequestHash != "hash" || k.CreatedAt.IsZero() {
+		t.Fatalf("got %+v", k)
+	}
+	first := k
+	// jsonb хранит не исходный текст, поэтому ответ сравнивается как JSON
+	var got, want interface{}
+	must(t, json.Unmarshal(k.Response, &got))
+	must(t, json.Unmarshal([]byte(`{"id":1,"status":"active"}`), &want))
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("response %s", k.Response)
+	}
+	// ключ уникален в пределах метода
+	k, err = repo.FindIdempotencyKey(ctx, "Reservation.UndoReserve", "key-1")
+	must(t, err)
+	if k != nil {
+		t.Fatalf("key of another method found: %+v", k)
+	}
+	err = repo.CreateIdempotencyKey(ctx, &entity.IdempotencyKey{
+		Method:      "Reservation.ReserveProducts",
+		Key:         "key-1",
+		RequestHash: "other",
+		Response:    []byte(`{}`),
+	})
+	expectCode(t, err, codeUniqueViolation)
+
+	// истекшие ключи удаляются от старых к новым, не больше limit за раз
+	must(t, repo.CreateIdempotencyKey(ctx, &entity.IdempotencyKey{
+		Method:      "Reservation.ReserveProducts",
+		Key:         "key-2",
+		RequestHash: "hash",
+		Response:    []byte(`{}`),
+	}))
+	newer, err := repo.FindIdempotencyKey(ctx, "Reservation.ReserveProducts", "key-2")
+	must(t, err)
+	deleted, err := repo.DeleteExpiredIdempotencyKeys(ctx, first.CreatedAt.Add(-time.Second), 10)
+	must(t, err)
+	if deleted != 0 {
+		t.Fatalf("deleted %d keys created after the cutoff", deleted)
+	}
+	deleted, err = repo.DeleteExpiredIdempotencyKeys(ctx, newer.CreatedAt, 1)
+	must(t, err)
+	if deleted != 1 {
+		t.Fatalf("expected one key to be deleted, got %d", deleted)
+	}
+	for key, exists := range map[string]bool{"key-1": false, "key-2": true} {
+		k, err := repo.FindIdempotencyKey(ctx, "Reservation.ReserveProducts", key)
+		must(t, err)
+		if (k != nil) != exists {
+			t.Errorf("%s: expected exists=%v, got %+v", key, exists, k)
+		}
+	}
+	deleted, err = repo.DeleteExpiredIdempotencyKeys(ctx, newer.CreatedAt, 10)
+	must(t, err)
+	if deleted != 1 {
+		t.Fatalf("expected the remaining key to be deleted, got %d", deleted)
+	}
+}
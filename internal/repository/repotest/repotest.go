@@ -0,0 +1,197 @@
+// Package repotest - общий набор проверок для реализаций repository.IRepository.
+// Одни и те же тесты запускаются для репозитория поверх PostgreSQL и для
+// хранилища в памяти, поэтому поведение реализаций не расходится: выборки и
+// фильтры, транзакции и откат, нарушения ограничений с кодами postgres
+package repotest
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"storageapi/internal/entity"
+	"storageapi/internal/repository"
+	"storageapi/pkg/algo"
+	"storageapi/pkg/errs"
+	"testing"
+
+	"github.com/jackc/pgx"
+)
+
+// Factory создает пустое хранилище для одного теста набора
+type Factory func(t *testing.T) repository.IRepository
+
+// коды ошибок postgres, которые реализации обязаны возвращать одинаково
+const (
+	codeUniqueViolation      = "23505"
+	codeForeignKeyViolation  = "23503"
+	codeCheckViolation       = "23514"
+	codeCardinalityViolation = "21000"
+	codeTransactionAborted   = "25P02"
+	codeFeatureNotSupported  = "0A000"
+)
+
+// проверки ListParameters работают в транзакции, которая откатывается, и видят исходные данные
+var errRollback = errors.New("rollback")
+
+func Run(t *testing.T, newRepo Factory) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, repo repository.IRepository)
+	}{
+		{"Storages", testStorages},
+		{"Products", testProducts},
+		{"StoredProducts", testStoredProducts},
+		{"Reservations", testReservations},
+		{"ReservationOrders", testReservationOrders},
+		{"Transfers", testTransfers},
+		{"Shipments", testShipments},
+		{"StockAdjustments", testStockAdjustments},
+		{"IdempotencyKeys", testIdempotencyKeys},
+		{"CascadeDelete", testCascadeDelete},
+		{"Transactions", testTransactions},
+		{"ListParameters", testListParameters},
+		{"UpdateProduct", testUpdateProduct},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			tc.run(t, newRepo(t))
+		})
+	}
+}
+
+func pgCode(err error) string {
+	var pgErr pgx.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code
+	}
+	return ""
+}
+
+func expectCode(t *testing.T, err error, code string) {
+	t.Helper()
+	if got := pgCode(err); got != code {
+		t.Fatalf("expected SQLSTATE %s, got %v", code, err)
+	}
+}
+
+func expectNotFound(t *testing.T, err error) {
+	t.Helper()
+	if errs.CodeOf(err) != errs.CodeNotFound {
+		t.Fatalf("expected not found, got %v", err)
+	}
+}
+
+func must(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// id, под которым строки точно нет
+func missing(ids ...entity.PK) entity.PK {
+	var max entity.PK
+	for _, id := range ids {
+		if id > max {
+			max = id
+		}
+	}
+	return max + 1000
+}
+
+// у сущностей со встроенным baseEntity id не задается в литерале
+func storageWithID(id entity.PK, isAvailable bool) *entity.Storage {
+	s := &entity.Storage{IsAvailable: isAvailable}
+	s.ID = id
+	return s
+}
+
+func productWithID(id entity.PK, name, vendor, size string) *entity.Product {
+	p := &entity.Product{Name: name, Vendor: vendor, Size: size}
+	p.ID = id
+	return p
+}
+
+func createStorages(t *testing.T, repo repository.IRepository, n int) []entity.PK {
+	t.Helper()
+	storages := make([]*entity.Storage, 0, n)
+	for i := 0; i < n; i++ {
+		storages = append(storages, &entity.Storage{IsAvailable: true})
+	}
+	created, err := repo.CreateStorage(context.Background(), storages...)
+	must(t, err)
+	return pksOf(created, storageID)
+}
+
+func createProducts(t *testing.T, repo repository.IRepository, vendors ...string) []entity.PK {
+	t.Helper()
+	products := make([]*entity.Product, 0, len(vendors))
+	for _, v := range vendors {
+		products = append(products, &entity.Product{Name: "product", Vendor: v, Size: "m"})
+	}
+	created, err := repo.CreateProduct(context.Background(), products...)
+	must(t, err)
+	return pksOf(created, productID)
+}
+
+func storageID(s *entity.Storage) entity.PK                { return s.ID }
+func productID(p *entity.Product) entity.PK                { return p.ID }
+func storedID(sp *entity.StoredProduct) entity.PK          { return sp.ID }
+func reservationID(r *entity.ProductReservation) entity.PK { return r.ID }
+func orderID(o *entity.ReservationOrder) entity.PK         { return o.ID }
+func orderItemID(i *entity.ReservationOrderItem) entity.PK { return i.ID }
+func transferItemID(i *entity.TransferItem) entity.PK      { return i.ID }
+
+func pick[T any](values []T, idx []int) []T {
+	return algo.Map(idx, func(i int, _ int) T { return values[i] })
+}
+
+// индексы строк фикстуры, которые не выбраны
+func rest(idx []int) []int {
+	result := []int{}
+	for i := 0; i < 3; i++ {
+		if !containsIdx(idx, i) {
+			result = append(result, i)
+		}
+	}
+	return result
+}
+
+func containsIdx(idx []int, i int) bool {
+	for _, j := range idx {
+		if j == i {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedPKs(ids []entity.PK) []entity.PK {
+	result := append([]entity.PK{}, ids...)
+	sort.Slice(result, func(i, j int) bool { return result[i] < result[j] })
+	return result
+}
+
+// одинаковые id без учета порядка, для выборок без ORDER BY
+func samePKs(got, want []entity.PK) bool {
+	return orderedPKs(sortedPKs(got), sortedPKs(want))
+}
+
+// одинаковые id в том же порядке
+func orderedPKs(got, want []entity.PK) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func pksOf[T any](items []*T, id func(*T) entity.PK) []entity.PK {
+	return algo.Map(items, func(item *T, _ int) entity.PK { return id(item) })
+}
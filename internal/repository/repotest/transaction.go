@@ -0,0 +1,210 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"storageapi/internal/database"
+	"storageapi/internal/entity"
+	"storageapi/internal/repository"
+	"testing"
+
+	"github.com/jackc/pgx"
+)
+
+func testTransactions(t *testing.T, repo repository.IRepository) {
+	ctx := context.Background()
+	products := createProducts(t, repo, "tx-0")
+
+	var committed entity.PK
+	err := repo.RunInTransaction(ctx, func(ctx database.TxContext, repo repository.IRepository) error {
+		storages, err := repo.CreateStorage(ctx, &entity.Storage{IsAvailable: true})
+		if err != nil {
+			return err
+		}
+		committed = storages[0].ID
+		return nil
+	})
+	must(t, err)
+	_, err = repo.GetStorage(ctx, committed)
+	must(t, err)
+
+	// ошибка fn откатывает все изменения и возвращается как есть
+	var rolledBack entity.PK
+	err = repo.RunInTransaction(ctx, func(ctx database.TxContext, repo repository.IRepository) error {
+		storages, err := repo.CreateStorage(ctx, &entity.Storage{IsAvailable: true})
+		if err != nil {
+			return err
+		}
+		rolledBack = storages[0].ID
+		if _, err := repo.CreateStorageData(ctx, &entity.StoredProduct{StorageID: committed, ProductID: products[0], Amount: 1}); err != nil {
+			return err
+		}
+		if err := repo.DeleteProduct(ctx, products[0]); err != nil {
+			return err
+		}
+		return errRollback
+	})
+	if !errors.Is(err, errRollback) {
+		t.Fatalf("expected rollback error, got %v", err)
+	}
+	_, err = repo.GetStorage(ctx, rolledBack)
+	expectNotFound(t, err)
+	_, err = repo.GetProduct(ctx, products[0])
+	must(t, err)
+	data, err := repo.GetStorageDataByStorage(ctx, committed)
+	must(t, err)
+	if len(data) != 0 {
+		t.Fatalf("rolled back insert left %+v", data)
+	}
+
+	// после нарушения ограничения транзакция отклоняет запросы,
+	// а ее commit превращается в rollback
+	err = repo.RunInTransaction(ctx, func(ctx database.TxContext, repo repository.IRepository) error {
+		if _, err := repo.CreateStorage(ctx, &entity.Storage{IsAvailable: true}); err != nil {
+			return err
+		}
+		_, err := repo.CreateProduct(ctx, &entity.Product{Name: "dup", Vendor: "tx-0", Size: "m"})
+		expectCode(t, err, codeUniqueViolation)
+		_, err = repo.GetStorage(ctx, committed)
+		expectCode(t, err, codeTransactionAborted)
+		return nil
+	})
+	if !errors.Is(err, pgx.ErrTxCommitRollback) {
+		t.Fatalf("expected commit of aborted transaction to roll back, got %v", err)
+	}
+	storages, err := repo.ListStorages(ctx, nil)
+	must(t, err)
+	if got := pksOf(storages, storageID); !orderedPKs(got, []entity.PK{committed}) {
+		t.Fatalf("aborted transaction left storages %v", got)
+	}
+
+	// вложенная транзакция откатывается сама, внешняя продолжается и
+	// сохраняет свои изменения, в том числе после нарушения ограничения внутри
+	var outer, inner, dup entity.PK
+	err = repo.RunInTransaction(ctx, func(ctx database.TxContext, repo repository.IRepository) error {
+		storages, err := repo.CreateStorage(ctx, &entity.Storage{IsAvailable: true})
+		if err != nil {
+			return err
+		}
+		outer = storages[0].ID
+		err = repo.RunInTransaction(ctx, func(ctx database.TxContext, repo repository.IRepository) error {
+			storages, err := repo.CreateStorage(ctx, &entity.Storage{IsAvailable: true})
+			if err != nil {
+				return err
+			}
+			inner = storages[0].ID
+			return errRollback
+		})
+		if !errors.Is(err, errRollback) {
+			t.Errorf("expected nested rollback error, got %v", err)
+		}
+		err = repo.RunInTransaction(ctx, func(ctx database.TxContext, repo repository.IRepository) error {
+			storages, err := repo.CreateStorage(ctx, &entity.Storage{IsAvailable: true})
+			if err != nil {
+				return err
+			}
+			dup = storages[0].ID
+			_, err = repo.CreateProduct(ctx, &entity.Product{Name: "dup", Vendor: "tx-0", Size: "m"})
+			return err
+		})
+		if pgCode(err) != codeUniqueViolation {
+			t.Errorf("expected nested unique violation, got %v", err)
+		}
+		_, err = repo.GetStorage(ctx, outer)
+		return err
+	})
+	must(t, err)
+	_, err = repo.GetStorage(ctx, outer)
+	must(t, err)
+	_, err = repo.GetStorage(ctx, inner)
+	expectNotFound(t, err)
+	_, err = repo.GetStorage(ctx, dup)
+	expectNotFound(t, err)
+
+	// паника в fn откатывает транзакцию и передается вызывающему
+	var panicked entity.PK
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("expected panic to be propagated")
+			}
+		}()
+		_ = repo.RunInTransaction(ctx, func(ctx database.TxContext, repo repository.IRepository) error {
+			storages, err := repo.CreateStorage(ctx, &entity.Storage{IsAvailable: true})
+			if err != nil {
+				return err
+			}
+			panicked = storages[0].ID
+			panic("boom")
+		})
+	}()
+	_, err = repo.GetStorage(ctx, panicked)
+	expectNotFound(t, err)
+	must(t, repo.RunInTransaction(ctx, func(ctx database.TxContext, repo repository.IRepository) error {
+		_, err := repo.GetStorage(ctx, committed)
+		return err
+	}))
+}
+
+// внешние ключи удаляют зависимые строки через ON DELETE CASCADE
+func testCascadeDelete(t *testing.T, repo repository.IRepository) {
+	ctx := context.Background()
+	f, err := seedListFixture(ctx, repo)
+	must(t, err)
+
+	// склад 0 - источник перемещения 0 и получатель перемещения 2
+	must(t, repo.DeleteStorage(ctx, f.storages[0]))
+	stored, err := repo.GetStorageDataByStorage(ctx, f.storages...)
+	must(t, err)
+	if got := pksOf(stored, storedID); !samePKs(got, f.stored[1:]) {
+		t.Errorf("stored products after storage delete: got %v, want %v", got, f.stored[1:])
+	}
+	reservations, err := repo.GetReservationByStorage(ctx, f.storages...)
+	must(t, err)
+	if got := pksOf(reservations, reservationID); !samePKs(got, f.reservations[1:]) {
+		t.Errorf("reservations after storage delete: got %v, want %v", got, f.reservations[1:])
+	}
+	items, err := repo.GetReservationOrderItems(ctx, f.orders...)
+	must(t, err)
+	if got := pksOf(items, orderItemID); !samePKs(got, f.orderItems[1:]) {
+		t.Errorf("order items after storage delete: got %v, want %v", got, f.orderItems[1:])
+	}
+	for i, id := range f.transfers {
+		_, err := repo.GetTransfer(ctx, id)
+		if i == 1 {
+			must(t, err)
+		} else {
+			expectNotFound(t, err)
+		}
+	}
+	transferItems, err := repo.GetTransferItems(ctx, f.transfers...)
+	must(t, err)
+	if got := pksOf(transferItems, transferItemID); !samePKs(got, f.transferItems[1:2]) {
+		t.Errorf("transfer items after storage delete: got %v, want %v", got, f.transferItems[1:2])
+	}
+	// заказ ссылается на склад только через позиции и остается
+	_, err = repo.GetReservationOrder(ctx, f.orders[0])
+	must(t, err)
+
+	must(t, repo.DeleteProduct(ctx, f.products[1]))
+	stored, err = repo.GetStorageDataByProduct(ctx, f.products...)
+	must(t, err)
+	if got := pksOf(stored, storedID); !samePKs(got, f.stored[2:]) {
+		t.Errorf("stored products after product delete: got %v, want %v", got, f.stored[2:])
+	}
+	reservations, err = repo.GetReservationByProduct(ctx, f.products...)
+	must(t, err)
+	if got := pksOf(reservations, reservationID); !samePKs(got, f.reservations[2:]) {
+		t.Errorf("reservations after product delete: got %v, want %v", got, f.reservations[2:])
+	}
+	items, err = repo.GetReservationOrderItems(ctx, f.orders...)
+	must(t, err)
+	if got := pksOf(items, orderItemID); !samePKs(got, f.orderItems[2:]) {
+		t.Errorf("order items after product delete: got %v, want %v", got, f.orderItems[2:])
+	}
+	transferItems, err = repo.GetTransferItems(ctx, f.transfers...)
+	must(t, err)
+	if len(transferItems) != 0 {
+		t.Errorf("transfer items after product delete: %+v", transferItems)
+	}
+}
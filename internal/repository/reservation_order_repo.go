@@ -0,0 +1,191 @@
+package repository
+
+import (
+	"context"
+	"storageapi/internal/entity"
+	"storageapi/pkg/errs"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type ReservationOrderRepository struct {
+	*repoMixin
+}
+
+var _ IReservationOrderRepository = (*ReservationOrderRepository)(nil)
+
+func NewReservationOrderRepository(db DBI, log *zap.SugaredLogger) *ReservationOrderRepository {
+	return &ReservationOrderRepository{
+		repoMixin: &repoMixin{
+			db:  db,
+			log: log,
+		},
+	}
+}
+
+func (r *ReservationOrderRepository) GetReservationOrder(ctx context.Context, id entity.PK) (*entity.ReservationOrder, error) {
+	return r.getReservationOrder(ctx, "SELECT * FROM reservation_orders WHERE id = $1", id)
+}
+
+// то же, что GetReservationOrder, но блокирует заказ до конца транзакции
+func (r *ReservationOrderRepository) LockReservationOrder(ctx context.Context, id entity.PK) (*entity.ReservationOrder, error) {
+	return r.getReservationOrder(ctx, "SELECT * FROM reservation_orders WHERE id = $1 FOR UPDATE", id)
+}
+
+func (r *ReservationOrderRepository) getReservationOrder(ctx context.Context, q string, id entity.PK) (*entity.ReservationOrder, error) {
+	rows, err := r.DBI(ctx).QueryContext(ctx, q, id)
+	if err != nil {
+		return nil, err
+	}
+	orders, err := entity.ScannedRows[entity.ReservationOrder](rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(orders) == 0 {
+		return nil, errs.New(errs.CodeNotFound, "cannot find reservation by id").WithDetail("reservation_id", id)
+	}
+	return orders[0], nil
+}
+
+// блокирует до limit активных заказов с истекшим сроком, пропуская уже
+// заблокированные другими транзакциями (несколько инстансов не снимут один резерв дважды)
+func (r *ReservationOrderRepository) LockExpiredReservationOrders(ctx context.Context, now time.Time, limit uint) ([]*entity.ReservationOrder, error) {
+	rows, err := r.DBI(ctx).QueryContext(
+		ctx,
+		`SELECT * FROM reservation_orders
+		WHERE status = $1 AND expires_at <= $2
+		ORDER BY expires_at
+		LIMIT $3
+		FOR UPDATE SKIP LOCKED`,
+		entity.ReservationStatusActive,
+		now,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return entity.ScannedRows[entity.ReservationOrder](rows)
+}
+
+// блокирует заказы в статусе status, у которых есть позиции на складе storageID
+func (r *ReservationOrderRepository) LockReservationOrdersByStorage(ctx context.Context, storageID entity.PK, status string) ([]*entity.ReservationOrder, error) {
+	rows, err := r.DBI(ctx).QueryContext(
+		ctx,
+		`SELECT * FROM reservation_orders AS o
+		WHERE o.status = $1 AND EXISTS (
+			SELECT 1 FROM reservation_order_items AS i
+			WHERE i.reservation_id = o.id AND i.storage_id = $2
+		)
+		ORDER BY o.id
+		FOR UPDATE`,
+		status,
+		storageID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return entity.ScannedRows[entity.ReservationOrder](rows)
+}
+
+func (r *ReservationOrderRepository) CreateReservationOrder(ctx context.Context, orders ...*entity.ReservationOrder) ([]*entity.ReservationOrder, error) {
+	q := strings.Builder{}
+	q.WriteString("INSERT INTO reservation_orders (status, expires_at) VALUES ")
+	argB := argBuilder{}
+	for _, o := range orders {
+		argB.add(o.Status, o.ExpiresAt)
+	}
+	expr, args := argB.done()
+	q.WriteString(expr + " RETURNING *")
+
+	rows, err := r.DBI(ctx).QueryContext(ctx, q.String(), args...)
+	if err != nil {
+		return nil, err
+	}
+	return entity.ScannedRows[entity.ReservationOrder](rows)
+}
+
+func (r *ReservationOrderRepository) UpdateReservationOrder(ctx context.Context, orders ...*entity.ReservationOrder) ([]*entity.ReservationOrder, error) {
+	q := strings.Builder{}
+	q.WriteString(`UPDATE reservation_orders AS o SET
+		status = c.status
+	FROM (VALUES `)
+	argB := argBuilder{types: []string{"bigint", "varchar"}}
+	for _, o := range orders {
+		argB.add(o.ID, o.Status)
+	}
+	expr, args := argB.done()
+	q.WriteString(expr + ") AS c (id, status) WHERE c.id = o.id RETURNING o.*")
+	rows, err := r.DBI(ctx).QueryContext(ctx, q.String(), args...)
+	if err != nil {
+		return nil, err
+	}
+	return entity.ScannedRows[entity.ReservationOrder](rows)
+}
+
+func (r *ReservationOrderRepository) GetReservationOrderItems(ctx context.Context, orderIDs ...entity.PK) ([]*entity.ReservationOrderItem, error) {
+	rows, err := r.DBI(ctx).QueryContext(
+		ctx,
+		"SELECT * FROM reservation_order_items WHERE reservation_id = ANY($1) ORDER BY id",
+		pkArray(orderIDs),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return entity.ScannedRows[entity.ReservationOrderItem](rows)
+}
+
+func (r *ReservationOrderRepository) CreateReservationOrderItem(ctx context.Context, items ...*entity.ReservationOrderItem) ([]*entity.ReservationOrderItem, error) {
+	q := strings.Builder{}
+	q.WriteString("INSERT INTO reservation_order_items (reservation_id, storage_id, product_id, amount) VALUES ")
+	argB := argBuilder{}
+	for _, i := range items {
+		argB.add(i.ReservationID, i.StorageID, i.ProductID, i.Amount)
+	}
+	expr, args := argB.done()
+	q.WriteString(expr + " RETURNING *")
+
+	rows, err := r.DBI(ctx).QueryContext(ctx, q.String(), args...)
+	if err != nil {
+		return nil, err
+	}
+	return entity.ScannedRows[entity.ReservationOrderItem](rows)
+}
+
+func (r *ReservationOrderRepository) UpdateReservationOrderItem(ctx context.Context, items ...*entity.ReservationOrderItem) ([]*entity.ReservationOrderItem, error) {
+	q := strings.Builder{}
+	q.WriteString(`UPDATE reservation_order_items AS i SET
+		storage_id = c.storage_id,
+		amount = c.amount
+	FROM (VALUES `)
+	argB := argBuilder{types: []string{"bigint", "bigint", "int"}}
+	for _, i := range items {
+		argB.add(i.ID, i.StorageID, i.Amount)
+	}
+	expr, args := argB.done()
+	q.WriteString(expr + ") AS c (id, storage_id, amount) WHERE c.id = i.id RETURNING i.*")
+	rows, err := r.DBI(ctx).QueryContext(ctx, q.String(), args...)
+	if err != nil {
+		return nil, err
+	}
+	return entity.ScannedRows[entity.ReservationOrderItem](rows)
+}
+
+func (r *ReservationOrderRepository) DeleteReservationOrderItem(ctx context.Context, ids ...entity.PK) error {
+	_, err := r.DBI(ctx).ExecContext(ctx, "DELETE FROM reservation_order_items WHERE id = ANY($1)", pkArray(ids))
+	return err
+}
+
+type IReservationOrderRepository interface {
+	GetReservationOrder(ctx context.Context, id entity.PK) (*entity.ReservationOrder, error)
+	LockReservationOrder(ctx context.Context, id entity.PK) (*entity.ReservationOrder, error)
+	LockExpiredReservationOrders(ctx context.Context, now time.Time, limit uint) ([]*entity.ReservationOrder, error)
+	LockReservationOrdersByStorage(ctx context.Context, storageID entity.PK, status string) ([]*entity.ReservationOrder, error)
+	CreateReservationOrder(ctx context.Context, orders ...*entity.ReservationOrder) ([]*entity.ReservationOrder, error)
+	UpdateReservationOrder(ctx context.Context, orders ...*entity.ReservationOrder) ([]*entity.ReservationOrder, error)
+	GetReservationOrderItems(ctx context.Context, orderIDs ...entity.PK) ([]*entity.ReservationOrderItem, error)
+	CreateReservationOrderItem(ctx context.Context, items ...*entity.ReservationOrderItem) ([]*entity.ReservationOrderItem, error)
+	UpdateReservationOrderItem(ctx context.Context, items ...*entity.ReservationOrderItem) ([]*entity.ReservationOrderItem, error)
+	DeleteReservationOrderItem(ctx context.Context, ids ...entity.PK) error
+}
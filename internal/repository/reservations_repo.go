@@ -2,8 +2,8 @@ package repository
 
 import (
 	"context"
-	"errors"
 	"storageapi/internal/entity"
+	"storageapi/pkg/errs"
 	"strings"
 
 	"go.uber.org/zap"
@@ -25,19 +25,19 @@ func NewReservationsRepository(db DBI, log *zap.SugaredLogger) *ReservationsRepo
 }
 
 func (r *ReservationsRepository) GetReservationByProduct(ctx context.Context, productIDs ...entity.PK) ([]*entity.ProductReservation, error) {
-	rows, err := r.DBI(ctx).QueryContext(ctx, "SELECT * FROM product_reservations WHERE product_id IN ($1)", productIDs)
+	rows, err := r.DBI(ctx).QueryContext(ctx, "SELECT * FROM product_reservations WHERE product_id = ANY($1)", pkArray(productIDs))
 	if err != nil {
 		return nil, err
 	}
-	return entity.ScannedRows[*entity.ProductReservation](rows)
+	return entity.ScannedRows[entity.ProductReservation](rows)
 }
 
 func (r *ReservationsRepository) GetReservationByStorage(ctx context.Context, storageIDs ...entity.PK) ([]*entity.ProductReservation, error) {
-	rows, err := r.DBI(ctx).QueryContext(ctx, "SELECT * FROM product_reservations WHERE storage_id IN ($1)", storageIDs)
+	rows, err := r.DBI(ctx).QueryContext(ctx, "SELECT * FROM product_reservations WHERE storage_id = ANY($1)", pkArray(storageIDs))
 	if err != nil {
 		return nil, err
 	}
-	return entity.ScannedRows[*entity.ProductReservation](rows)
+	return entity.ScannedRows[entity.ProductReservation](rows)
 }
 
 func (r *ReservationsRepository) GetReservation(ctx context.Context, id entity.PK) (*entity.ProductReservation, error) {
@@ -45,6 +45,7 @@ func (r *ReservationsRepository) GetReservation(ctx context.Context, id entity.P
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 	var p *entity.ProductReservation
 	for rows.Next() {
 		p = &entity.ProductReservation{}
@@ -53,7 +54,7 @@ func (r *ReservationsRepository) GetReservation(ctx context.Context, id entity.P
 		}
 	}
 	if p == nil {
-		return nil, errors.New("product reservation by id not found")
+		return nil, errs.New(errs.CodeNotFound, "product reservation by id not found").WithDetail("product_reservation_id", id)
 	}
 	return p, nil
 }
@@ -72,7 +73,7 @@ func (r *ReservationsRepository) CreateReservation(ctx context.Context, reservat
 	if err != nil {
 		return nil, err
 	}
-	return entity.ScannedRows[*entity.ProductReservation](rows)
+	return entity.ScannedRows[entity.ProductReservation](rows)
 }
 
 func (r *ReservationsRepository) UpdateReservation(ctx context.Context, reservations ...*entity.ProductReservation) ([]*entity.ProductReservation, error) {
@@ -80,21 +81,21 @@ func (r *ReservationsRepository) UpdateReservation(ctx context.Context, reservat
 	q.WriteString(`UPDATE product_reservations AS r SET
 		amount = c.amount
 	FROM (VALUES `)
-	argB := argBuilder{}
+	argB := argBuilder{types: []string{"bigint", "int"}}
 	for _, r := range reservations {
 		argB.add(r.ID, r.Amount)
 	}
 	expr, args := argB.done()
-	q.WriteString(expr + ") AS c (id, amount) WHERE c.id = r.id RETURNING *")
+	q.WriteString(expr + ") AS c (id, amount) WHERE c.id = r.id RETURNING r.*")
 	rows, err := r.DBI(ctx).QueryContext(ctx, q.String(), args...)
 	if err != nil {
 		return nil, err
 	}
-	return entity.ScannedRows[*entity.ProductReservation](rows)
+	return entity.ScannedRows[entity.ProductReservation](rows)
 }
 
 func (r *ReservationsRepository) DeleteReservation(ctx context.Context, ids ...entity.PK) error {
-	_, err := r.DBI(ctx).ExecContext(ctx, "DELETE FROM product_reservations WHERE id IN ($1)", ids)
+	_, err := r.DBI(ctx).ExecContext(ctx, "DELETE FROM product_reservations WHERE id = ANY($1)", pkArray(ids))
 	return err
 }
 
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"storageapi/internal/entity"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+type ShipmentRepository struct {
+	*repoMixin
+}
+
+var _ IShipmentRepository = (*ShipmentRepository)(nil)
+
+func NewShipmentRepository(db DBI, log *zap.SugaredLogger) *ShipmentRepository {
+	return &ShipmentRepository{
+		repoMixin: &repoMixin{
+			db:  db,
+			log: log,
+		},
+	}
+}
+
+func (r *ShipmentRepository) CreateShipment(ctx context.Context, shipments ...*entity.Shipment) ([]*entity.Shipment, error) {
+	q := strings.Builder{}
+	q.WriteString("INSERT INTO shipments (reservation_id) VALUES ")
+	argB := argBuilder{}
+	for _, s := range shipments {
+		argB.add(s.ReservationID)
+	}
+	expr, args := argB.done()
+	q.WriteString(expr + " RETURNING *")
+
+	rows, err := r.DBI(ctx).QueryContext(ctx, q.String(), args...)
+	if err != nil {
+		return nil, err
+	}
+	return entity.ScannedRows[entity.Shipment](rows)
+}
+
+func (r *ShipmentRepository) CreateShipmentItem(ctx context.Context, items ...*entity.ShipmentItem) ([]*entity.ShipmentItem, error) {
+	q := strings.Builder{}
+	q.WriteString("INSERT INTO shipment_items (shipment_id, storage_id, product_id, amount) VALUES ")
+	argB := argBuilder{}
+	for _, i := range items {
+		argB.add(i.ShipmentID, i.StorageID, i.ProductID, i.Amount)
+	}
+	expr, args := argB.done()
+	q.WriteString(expr + " RETURNING *")
+
+	rows, err := r.DBI(ctx).QueryContext(ctx, q.String(), args...)
+	if err != nil {
+		return nil, err
+	}
+	return entity.ScannedRows[entity.ShipmentItem](rows)
+}
+
+type IShipmentRepository interface {
+	CreateShipment(ctx context.Context, shipments ...*entity.Shipment) ([]*entity.Shipment, error)
+	CreateShipmentItem(ctx context.Context, items ...*entity.ShipmentItem) ([]*entity.ShipmentItem, error)
+}
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"storageapi/internal/entity"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+type StockAdjustmentRepository struct {
+	*repoMixin
+}
+
+var _ IStockAdjustmentRepository = (*StockAdjustmentRepository)(nil)
+
+func NewStockAdjustmentRepository(db DBI, log *zap.SugaredLogger) *StockAdjustmentRepository {
+	return &StockAdjustmentRepository{
+		repoMixin: &repoMixin{
+			db:  db,
+			log: log,
+		},
+	}
+}
+
+func (r *StockAdjustmentRepository) CreateStockAdjustment(ctx context.Context, adjustments ...*entity.StockAdjustment) ([]*entity.StockAdjustment, error) {
+	q := strings.Builder{}
+	q.WriteString("INSERT INTO stock_adjustments (storage_id, product_id, delta, reason, comment) VALUES ")
+	argB := argBuilder{}
+	for _, a := range adjustments {
+		argB.add(a.StorageID, a.ProductID, a.Delta, a.Reason, a.Comment)
+	}
+	expr, args := argB.done()
+	q.WriteString(expr + " RETURNING *")
+
+	rows, err := r.DBI(ctx).QueryContext(ctx, q.String(), args...)
+	if err != nil {
+		return nil, err
+	}
+	return entity.ScannedRows[entity.StockAdjustment](rows)
+}
+
+// журнал изменений остатков складов в порядке записи
+func (r *StockAdjustmentRepository) GetStockAdjustmentsByStorage(ctx context.Context, storageIDs ...entity.PK) ([]*entity.StockAdjustment, error) {
+	rows, err := r.DBI(ctx).QueryContext(ctx, "SELECT * FROM stock_adjustments WHERE storage_id = ANY($1) ORDER BY id", pkArray(storageIDs))
+	if err != nil {
+		return nil, err
+	}
+	return entity.ScannedRows[entity.StockAdjustment](rows)
+}
+
+type IStockAdjustmentRepository interface {
+	CreateStockAdjustment(ctx context.Context, adjustments ...*entity.StockAdjustment) ([]*entity.StockAdjustment, error)
+	GetStockAdjustmentsByStorage(ctx context.Context, storageIDs ...entity.PK) ([]*entity.StockAdjustment, error)
+}
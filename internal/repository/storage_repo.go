@@ -2,8 +2,9 @@ package repository
 
 import (
 	"context"
-	"errors"
+	"fmt"
 	"storageapi/internal/entity"
+	"storageapi/pkg/errs"
 	"strings"
 
 	"go.uber.org/zap"
@@ -25,34 +26,41 @@ func NewStorageRepository(db DBI, log *zap.SugaredLogger) *StorageRepository {
 }
 
 func (r *StorageRepository) GetStorage(ctx context.Context, id entity.PK) (*entity.Storage, error) {
-	rows, err := r.DBI(ctx).QueryContext(ctx, "SELECT * FROM storages WHERE id = $1", id)
+	return r.getStorage(ctx, "SELECT * FROM storages WHERE id = $1", id)
+}
+
+// то же, что GetStorage, но блокирует склад до конца транзакции
+func (r *StorageRepository) LockStorage(ctx context.Context, id entity.PK) (*entity.Storage, error) {
+	return r.getStorage(ctx, "SELECT * FROM storages WHERE id = $1 FOR UPDATE", id)
+}
+
+func (r *StorageRepository) getStorage(ctx context.Context, q string, id entity.PK) (*entity.Storage, error) {
+	rows, err := r.DBI(ctx).QueryContext(ctx, q, id)
 	if err != nil {
 		return nil, err
 	}
-	var s *entity.Storage
-	for rows.Next() {
-		s = &entity.Storage{}
-		if err := s.Scan(rows); err != nil {
-			return nil, err
-		}
+	storages, err := entity.ScannedRows[entity.Storage](rows)
+	if err != nil {
+		return nil, err
 	}
-	if s == nil {
-		return nil, errors.New("cannot find storage by id")
+	if len(storages) == 0 {
+		return nil, errs.New(errs.CodeNotFound, "cannot find storage by id").WithDetail("storage_id", id)
 	}
-	return s, nil
+	return storages[0], nil
 }
 
-func (r *StorageRepository) ListStorages(ctx context.Context, isAvailable ...bool) ([]*entity.Storage, error) {
-	q := "SELECT * FROM storages"
-	if len(isAvailable) > 0 {
-		q += " WHERE is_available = $1"
+func (r *StorageRepository) ListStorages(ctx context.Context, filter *ListStorageFilter) ([]*entity.Storage, error) {
+	q := strings.Builder{}
+	q.WriteString("SELECT * FROM storages WHERE TRUE ")
+	args, err := filter.apply(&q)
+	if err != nil {
+		return nil, err
 	}
-	args := make([]interface{}, 0, len(isAvailable))
-	rows, err := r.DBI(ctx).QueryContext(ctx, q, args...)
+	rows, err := r.DBI(ctx).QueryContext(ctx, q.String(), args...)
 	if err != nil {
 		return nil, err
 	}
-	return entity.ScannedRows[*entity.Storage](rows)
+	return entity.ScannedRows[entity.Storage](rows)
 }
 
 func (r *StorageRepository) CreateStorage(ctx context.Context, storages ...*entity.Storage) ([]*entity.Storage, error) {
@@ -69,7 +77,7 @@ func (r *StorageRepository) CreateStorage(ctx context.Context, storages ...*enti
 	if err != nil {
 		return nil, err
 	}
-	return entity.ScannedRows[*entity.Storage](rows)
+	return entity.ScannedRows[entity.Storage](rows)
 }
 
 func (r *StorageRepository) UpdateStorage(ctx context.Context, storages ...*entity.Storage) ([]*entity.Storage, error) {
@@ -78,21 +86,21 @@ func (r *StorageRepository) UpdateStorage(ctx context.Context, storages ...*enti
 		id = c.id,
 		is_available = c.is_available
 	FROM (VALUES `)
-	argB := argBuilder{}
+	argB := argBuilder{types: []string{"bigint", "boolean"}}
 	for _, s := range storages {
 		argB.add(s.ID, s.IsAvailable)
 	}
 	expr, args := argB.done()
-	q.WriteString(expr + ") AS c(id, is_available) WHERE c.id = s.id RETURNING *")
+	q.WriteString(expr + ") AS c(id, is_available) WHERE c.id = s.id RETURNING s.*")
 	rows, err := r.DBI(ctx).QueryContext(ctx, q.String(), args...)
 	if err != nil {
 		return nil, err
 	}
-	return entity.ScannedRows[*entity.Storage](rows)
+	return entity.ScannedRows[entity.Storage](rows)
 }
 
 func (r *StorageRepository) DeleteStorage(ctx context.Context, ids ...entity.PK) error {
-	_, err := r.DBI(ctx).ExecContext(ctx, "DELETE FROM storages WHERE id IN ($1)", ids)
+	_, err := r.DBI(ctx).ExecContext(ctx, "DELETE FROM storages WHERE id = ANY($1)", pkArray(ids))
 	return err
 }
 
@@ -101,9 +109,44 @@ func (r *StorageRepository) TruncateStorages(ctx context.Context) error {
 	return err
 }
 
+type ListStorageFilter struct {
+	IDs         []entity.PK // пусто - без фильтра по id
+	IsAvailable *bool
+	Limit       uint // 0 - без ограничения
+	Offset      uint
+}
+
+func (f *ListStorageFilter) apply(q *strings.Builder) ([]interface{}, error) {
+	args := []interface{}{}
+	if f == nil {
+		q.WriteString("ORDER BY id")
+		return args, nil
+	}
+	if len(f.IDs) > 0 {
+		args = append(args, pkArray(f.IDs))
+		q.WriteString(fmt.Sprintf("AND id = ANY($%d) ", len(args)))
+	}
+	if f.IsAvailable != nil {
+		args = append(args, *f.IsAvailable)
+		q.WriteString(fmt.Sprintf("AND is_available = $%d ", len(args)))
+	}
+	// порядок нужен для стабильной пагинации
+	q.WriteString("ORDER BY id")
+	if f.Limit > 0 {
+		args = append(args, f.Limit)
+		q.WriteString(fmt.Sprintf(" LIMIT $%d", len(args)))
+	}
+	if f.Offset > 0 {
+		args = append(args, f.Offset)
+		q.WriteString(fmt.Sprintf(" OFFSET $%d", len(args)))
+	}
+	return args, nil
+}
+
 type IStorageRepository interface {
 	GetStorage(ctx context.Context, id entity.PK) (*entity.Storage, error)
-	ListStorages(ctx context.Context, isAvailable ...bool) ([]*entity.Storage, error)
+	LockStorage(ctx context.Context, id entity.PK) (*entity.Storage, error)
+	ListStorages(ctx context.Context, filter *ListStorageFilter) ([]*entity.Storage, error)
 	CreateStorage(ctx context.Context, storages ...*entity.Storage) ([]*entity.Storage, error)
 	UpdateStorage(ctx context.Context, storages ...*entity.Storage) ([]*entity.Storage, error)
 	DeleteStorage(ctx context.Context, ids ...entity.PK) error
@@ -2,8 +2,8 @@ package repository
 
 import (
 	"context"
-	"errors"
 	"storageapi/internal/entity"
+	"storageapi/pkg/errs"
 	"strings"
 
 	"go.uber.org/zap"
@@ -29,6 +29,7 @@ func (r *StoredProductRepository) GetStorageData(ctx context.Context, id entity.
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 	var sp *entity.StoredProduct
 	for rows.Next() {
 		sp = &entity.StoredProduct{}
@@ -37,25 +38,72 @@ func (r *StoredProductRepository) GetStorageData(ctx context.Context, id entity.
 		}
 	}
 	if sp == nil {
-		return nil, errors.New("product reservation by id not found")
+		return nil, errs.New(errs.CodeNotFound, "stored product by id not found").WithDetail("stored_product_id", id)
 	}
 	return sp, nil
 }
 
 func (r *StoredProductRepository) GetStorageDataByProduct(ctx context.Context, productIDs ...entity.PK) ([]*entity.StoredProduct, error) {
-	rows, err := r.DBI(ctx).QueryContext(ctx, "SELECT * FROM stored_products WHERE product_id IN ($1)", productIDs)
+	rows, err := r.DBI(ctx).QueryContext(ctx, "SELECT * FROM stored_products WHERE product_id = ANY($1) ORDER BY id", pkArray(productIDs))
 	if err != nil {
 		return nil, err
 	}
-	return entity.ScannedRows[*entity.StoredProduct](rows)
+	return entity.ScannedRows[entity.StoredProduct](rows)
+}
+
+// то же, что GetStorageDataByProduct, но блокирует строки до конца транзакции.
+// строки блокируются в порядке id, чтобы параллельные транзакции не ловили дедлок
+func (r *StoredProductRepository) LockStorageDataByProduct(ctx context.Context, productIDs ...entity.PK) ([]*entity.StoredProduct, error) {
+	rows, err := r.DBI(ctx).QueryContext(
+		ctx,
+		"SELECT * FROM stored_products WHERE product_id = ANY($1) ORDER BY id FOR UPDATE",
+		pkArray(productIDs),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return entity.ScannedRows[entity.StoredProduct](rows)
+}
+
+// блокирует остатки склада в том же порядке по id, что и LockStorageDataByProduct
+func (r *StoredProductRepository) LockStorageDataByStorage(ctx context.Context, storageIDs ...entity.PK) ([]*entity.StoredProduct, error) {
+	rows, err := r.DBI(ctx).QueryContext(
+		ctx,
+		"SELECT * FROM stored_products WHERE storage_id = ANY($1) ORDER BY id FOR UPDATE",
+		pkArray(storageIDs),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return entity.ScannedRows[entity.StoredProduct](rows)
 }
 
 func (r *StoredProductRepository) GetStorageDataByStorage(ctx context.Context, storageIDs ...entity.PK) ([]*entity.StoredProduct, error) {
-	rows, err := r.DBI(ctx).QueryContext(ctx, "SELECT * FROM stored_products WHERE storage_id IN ($1)", storageIDs)
+	rows, err := r.DBI(ctx).QueryContext(ctx, "SELECT * FROM stored_products WHERE storage_id = ANY($1) ORDER BY id", pkArray(storageIDs))
+	if err != nil {
+		return nil, err
+	}
+	return entity.ScannedRows[entity.StoredProduct](rows)
+}
+
+// остатки по складам: всего, в резерве и свободно. свободный остаток
+// считается по каждому товару, резерв больше остатка дает 0, а не минус
+func (r *StoredProductRepository) GetStockByStorage(ctx context.Context) ([]*entity.StorageStock, error) {
+	rows, err := r.DBI(ctx).QueryContext(ctx, `
+		SELECT
+			sp.storage_id,
+			SUM(sp.amount),
+			SUM(COALESCE(pr.amount, 0)),
+			SUM(GREATEST(sp.amount - COALESCE(pr.amount, 0), 0))
+		FROM stored_products sp
+		LEFT JOIN product_reservations pr
+			ON pr.storage_id = sp.storage_id AND pr.product_id = sp.product_id
+		GROUP BY sp.storage_id
+		ORDER BY sp.storage_id`)
 	if err != nil {
 		return nil, err
 	}
-	return entity.ScannedRows[*entity.StoredProduct](rows)
+	return entity.ScannedRows[entity.StorageStock](rows)
 }
 
 func (r *StoredProductRepository) CreateStorageData(ctx context.Context, data ...*entity.StoredProduct) ([]*entity.StoredProduct, error) {
@@ -72,7 +120,27 @@ func (r *StoredProductRepository) CreateStorageData(ctx context.Context, data ..
 	if err != nil {
 		return nil, err
 	}
-	return entity.ScannedRows[*entity.StoredProduct](rows)
+	return entity.ScannedRows[entity.StoredProduct](rows)
+}
+
+// добавляет amount к существующей строке (storage_id, product_id) или создает новую
+func (r *StoredProductRepository) UpsertStorageData(ctx context.Context, data ...*entity.StoredProduct) ([]*entity.StoredProduct, error) {
+	q := strings.Builder{}
+	q.WriteString("INSERT INTO stored_products (storage_id, product_id, amount) VALUES ")
+	argB := argBuilder{}
+	for _, sp := range data {
+		argB.add(sp.StorageID, sp.ProductID, sp.Amount)
+	}
+	expr, args := argB.done()
+	q.WriteString(expr + ` ON CONFLICT (storage_id, product_id)
+		DO UPDATE SET amount = stored_products.amount + EXCLUDED.amount
+		RETURNING *`)
+
+	rows, err := r.DBI(ctx).QueryContext(ctx, q.String(), args...)
+	if err != nil {
+		return nil, err
+	}
+	return entity.ScannedRows[entity.StoredProduct](rows)
 }
 
 func (r *StoredProductRepository) UpdateStorageData(ctx context.Context, data ...*entity.StoredProduct) ([]*entity.StoredProduct, error) {
@@ -80,29 +148,33 @@ func (r *StoredProductRepository) UpdateStorageData(ctx context.Context, data ..
 	q.WriteString(`UPDATE stored_products AS sp SET
 		amount = c.amount
 	FROM (VALUES `)
-	argB := argBuilder{}
+	argB := argBuilder{types: []string{"bigint", "int"}}
 	for _, r := range data {
 		argB.add(r.ID, r.Amount)
 	}
 	expr, args := argB.done()
-	q.WriteString(expr + ") AS c (id, amount) WHERE c.id = sp.id RETURNING *")
+	q.WriteString(expr + ") AS c (id, amount) WHERE c.id = sp.id RETURNING sp.*")
 	rows, err := r.DBI(ctx).QueryContext(ctx, q.String(), args...)
 	if err != nil {
 		return nil, err
 	}
-	return entity.ScannedRows[*entity.StoredProduct](rows)
+	return entity.ScannedRows[entity.StoredProduct](rows)
 }
 
 func (r *StoredProductRepository) DeleteStorageData(ctx context.Context, ids ...entity.PK) error {
-	_, err := r.DBI(ctx).ExecContext(ctx, "DELETE FROM stored_products WHERE id IN ($1)", ids)
+	_, err := r.DBI(ctx).ExecContext(ctx, "DELETE FROM stored_products WHERE id = ANY($1)", pkArray(ids))
 	return err
 }
 
 type IStoredProductRepository interface {
 	GetStorageData(ctx context.Context, id entity.PK) (*entity.StoredProduct, error)
 	GetStorageDataByProduct(ctx context.Context, productIDs ...entity.PK) ([]*entity.StoredProduct, error)
+	LockStorageDataByProduct(ctx context.Context, productIDs ...entity.PK) ([]*entity.StoredProduct, error)
 	GetStorageDataByStorage(ctx context.Context, storageIDs ...entity.PK) ([]*entity.StoredProduct, error)
+	LockStorageDataByStorage(ctx context.Context, storageIDs ...entity.PK) ([]*entity.StoredProduct, error)
 	CreateStorageData(ctx context.Context, data ...*entity.StoredProduct) ([]*entity.StoredProduct, error)
+	UpsertStorageData(ctx context.Context, data ...*entity.StoredProduct) ([]*entity.StoredProduct, error)
 	UpdateStorageData(ctx context.Context, data ...*entity.StoredProduct) ([]*entity.StoredProduct, error)
 	DeleteStorageData(ctx context.Context, ids ...entity.PK) error
+	GetStockByStorage(ctx context.Context) ([]*entity.StorageStock, error)
 }
@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"storageapi/internal/entity"
+	"storageapi/pkg/errs"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+type TransferRepository struct {
+	*repoMixin
+}
+
+var _ ITransferRepository = (*TransferRepository)(nil)
+
+func NewTransferRepository(db DBI, log *zap.SugaredLogger) *TransferRepository {
+	return &TransferRepository{
+		repoMixin: &repoMixin{
+			db:  db,
+			log: log,
+		},
+	}
+}
+
+func (r *TransferRepository) GetTransfer(ctx context.Context, id entity.PK) (*entity.Transfer, error) {
+	return r.getTransfer(ctx, "SELECT * FROM transfers WHERE id = $1", id)
+}
+
+// то же, что GetTransfer, но блокирует перемещение до конца транзакции
+func (r *TransferRepository) LockTransfer(ctx context.Context, id entity.PK) (*entity.Transfer, error) {
+	return r.getTransfer(ctx, "SELECT * FROM transfers WHERE id = $1 FOR UPDATE", id)
+}
+
+func (r *TransferRepository) getTransfer(ctx context.Context, q string, id entity.PK) (*entity.Transfer, error) {
+	rows, err := r.DBI(ctx).QueryContext(ctx, q, id)
+	if err != nil {
+		return nil, err
+	}
+	transfers, err := entity.ScannedRows[entity.Transfer](rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(transfers) == 0 {
+		return nil, errs.New(errs.CodeNotFound, "cannot find transfer by id").WithDetail("transfer_id", id)
+	}
+	return transfers[0], nil
+}
+
+func (r *TransferRepository) CreateTransfer(ctx context.Context, transfers ...*entity.Transfer) ([]*entity.Transfer, error) {
+	q := strings.Builder{}
+	q.WriteString("INSERT INTO transfers (source_storage_id, destination_storage_id, status) VALUES ")
+	argB := argBuilder{}
+	for _, t := range transfers {
+		argB.add(t.SourceStorageID, t.DestinationStorageID, t.Status)
+	}
+	expr, args := argB.done()
+	q.WriteString(expr + " RETURNING *")
+
+	rows, err := r.DBI(ctx).QueryContext(ctx, q.String(), args...)
+	if err != nil {
+		return nil, err
+	}
+	return entity.ScannedRows[entity.Transfer](rows)
+}
+
+func (r *TransferRepository) UpdateTransfer(ctx context.Context, transfers ...*entity.Transfer) ([]*entity.Transfer, error) {
+	q := strings.Builder{}
+	q.WriteString(`UPDATE transfers AS t SET
+		status = c.status,
+		updated_at = NOW()
+	FROM (VALUES `)
+	argB := argBuilder{types: []string{"bigint", "varchar"}}
+	for _, t := range transfers {
+		argB.add(t.ID, t.Status)
+	}
+	expr, args := argB.done()
+	q.WriteString(expr + ") AS c (id, status) WHERE c.id = t.id RETURNING t.*")
+	rows, err := r.DBI(ctx).QueryContext(ctx, q.String(), args...)
+	if err != nil {
+		return nil, err
+	}
+	return entity.ScannedRows[entity.Transfer](rows)
+}
+
+func (r *TransferRepository) GetTransferItems(ctx context.Context, transferIDs ...entity.PK) ([]*entity.TransferItem, error) {
+	rows, err := r.DBI(ctx).QueryContext(
+		ctx,
+		"SELECT * FROM transfer_items WHERE transfer_id = ANY($1) ORDER BY id",
+		pkArray(transferIDs),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return entity.ScannedRows[entity.TransferItem](rows)
+}
+
+func (r *TransferRepository) CreateTransferItem(ctx context.Context, items ...*entity.TransferItem) ([]*entity.TransferItem, error) {
+	q := strings.Builder{}
+	q.WriteString("INSERT INTO transfer_items (transfer_id, product_id, amount) VALUES ")
+	argB := argBuilder{}
+	for _, i := range items {
+		argB.add(i.TransferID, i.ProductID, i.Amount)
+	}
+	expr, args := argB.done()
+	q.WriteString(expr + " RETURNING *")
+
+	rows, err := r.DBI(ctx).QueryContext(ctx, q.String(), args...)
+	if err != nil {
+		return nil, err
+	}
+	return entity.ScannedRows[entity.TransferItem](rows)
+}
+
+type ITransferRepository interface {
+	GetTransfer(ctx context.Context, id entity.PK) (*entity.Transfer, error)
+	LockTransfer(ctx context.Context, id entity.PK) (*entity.Transfer, error)
+	CreateTransfer(ctx context.Context, transfers ...*entity.Transfer) ([]*entity.Transfer, error)
+	UpdateTransfer(ctx context.Context, transfers ...*entity.Transfer) ([]*entity.Transfer, error)
+	GetTransferItems(ctx context.Context, transferIDs ...entity.PK) ([]*entity.TransferItem, error)
+	CreateTransferItem(ctx context.Context, items ...*entity.TransferItem) ([]*entity.TransferItem, error)
+}
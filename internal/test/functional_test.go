@@ -1,7 +0,0 @@
-package test
-
-import "testing"
-
-func TestWithRpcClient(t *testing.T) {
-
-}
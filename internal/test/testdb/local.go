@@ -0,0 +1,161 @@
+package testdb
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// каталог с initdb и postgres, без него бинарники ищутся в PATH
+const EnvPostgresBin = "TEST_POSTGRES_BIN"
+
+var errNoPostgres = errors.New("postgres binaries are not found")
+
+// локальный сервер один на процесс тестов, останавливает его Main
+var local struct {
+	once sync.Once
+	url  string
+	err  error
+	stop func()
+}
+
+func localURL() (string, error) {
+	local.once.Do(func() {
+		local.url, local.stop, local.err = startLocal()
+	})
+	return local.url, local.err
+}
+
+// Main запускает тесты пакета и останавливает локальный PostgreSQL, если его
+// поднимал New. Вызывается из TestMain пакетов, которые используют New
+func Main(m *testing.M) {
+	code := m.Run()
+	if local.stop != nil {
+		local.stop()
+	}
+	os.Exit(code)
+}
+
+func findBinary(name string) (string, error) {
+	if dir := os.Getenv(EnvPostgresBin); dir != "" {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err != nil {
+			return "", fmt.Errorf("%w: %v", errNoPostgres, err)
+		}
+		return path, nil
+	}
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", errNoPostgres, err)
+	}
+	return path, nil
+}
+
+// startLocal создает кластер во временном каталоге и запускает сервер на
+// свободном порту. postgres не запускается от root, в таких окружениях
+// нужен TEST_DATABASE_URL
+func startLocal() (string, func(), error) {
+	initdb, err := findBinary("initdb")
+	if err != nil {
+		return "", nil, err
+	}
+	postgres, err := findBinary("postgres")
+	if err != nil {
+		return "", nil, err
+	}
+	dir, err := os.MkdirTemp("", "storageapi-pg-")
+	if err != nil {
+		return "", nil, err
+	}
+	data := filepath.Join(dir, "data")
+	if out, err := exec.Command(initdb, "-D", data, "-U", "postgres", "-A", "trust", "-E", "UTF8", "--no-sync").CombinedOutput(); err != nil {
+		os.RemoveAll(dir)
+		return "", nil, fmt.Errorf("initdb: %v: %s", err, out)
+	}
+	port, err := freePort()
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", nil, err
+	}
+	logPath := filepath.Join(dir, "postgres.log")
+	logFile, err := os.Create(logPath)
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", nil, err
+	}
+	// данные временные, поэтому сброс на диск не нужен
+	cmd := exec.Command(postgres,
+		"-D", data,
+		"-p", strconv.Itoa(port),
+		"-k", dir,
+		"-c", "listen_addresses=127.0.0.1",
+		"-c", "fsync=off",
+		"-c", "synchronous_commit=off",
+		"-c", "full_page_writes=off",
+	)
+	cmd.Stdout, cmd.Stderr = logFile, logFile
+	if err := cmd.Start(); err != nil {
+		logFile.Close()
+		os.RemoveAll(dir)
+		return "", nil, err
+	}
+	exited := make(chan struct{})
+	go func() {
+		_ = cmd.Wait()
+		close(exited)
+	}()
+	stop := func() {
+		// SIGINT - fast shutdown, открытые соединения обрываются
+		_ = cmd.Process.Signal(os.Interrupt)
+		<-exited
+		logFile.Close()
+		os.RemoveAll(dir)
+	}
+
+	url := fmt.Sprintf("postgres://postgres@127.0.0.1:%d/postgres?sslmode=disable", port)
+	if err := waitReady(url, exited); err != nil {
+		out, _ := os.ReadFile(logPath)
+		stop()
+		return "", nil, fmt.Errorf("%v: %s", err, out)
+	}
+	return url, stop, nil
+}
+
+func waitReady(url string, exited <-chan struct{}) error {
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	deadline := time.Now().Add(30 * time.Second)
+	for {
+		if err = db.Ping(); err == nil {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("postgres is not ready: %w", err)
+		}
+		select {
+		case <-exited:
+			return errors.New("postgres exited on startup")
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
@@ -0,0 +1,96 @@
+package testdb
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/pressly/goose"
+)
+
+// адрес базы для тестов, которым нужен PostgreSQL
+const EnvURL = "TEST_DATABASE_URL"
+
+// goose хранит диалект в глобальной переменной
+var migrateMu sync.Mutex
+
+// New создает в тестовой базе отдельную схему, накатывает в нее миграции
+// и возвращает адрес базы с search_path на эту схему, так что тесты можно
+// запускать параллельно. Схема удаляется по завершении теста.
+// Если TEST_DATABASE_URL не задан, база поднимается из локальных бинарников
+// PostgreSQL (см. local.go), а если их нет - тест пропускается.
+func New(t testing.TB) string {
+	t.Helper()
+	baseURL := os.Getenv(EnvURL)
+	if baseURL == "" {
+		var err error
+		if baseURL, err = localURL(); errors.Is(err, errNoPostgres) {
+			t.Skipf("%s is not set and %v", EnvURL, err)
+		} else if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	schema := fmt.Sprintf("test_%d_%d", time.Now().UnixNano(), rand.Intn(1<<16))
+	admin, err := sql.Open("postgres", baseURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer admin.Close()
+	if _, err := admin.Exec("CREATE SCHEMA " + schema); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		admin, err := sql.Open("postgres", baseURL)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer admin.Close()
+		if _, err := admin.Exec("DROP SCHEMA " + schema + " CASCADE"); err != nil {
+			t.Error(err)
+		}
+	})
+
+	schemaURL, err := withSearchPath(baseURL, schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	migrateMu.Lock()
+	defer migrateMu.Unlock()
+	migrateDB, err := goose.OpenDBWithDriver("postgres", schemaURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer migrateDB.Close()
+	if err := goose.Run("up", migrateDB, FixturesPath()); err != nil {
+		t.Fatal(err)
+	}
+	return schemaURL
+}
+
+// путь к миграциям относительно исходников, не зависит от рабочей директории теста
+func FixturesPath() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Join(filepath.Dir(file), "..", "..", "..", "fixtures")
+}
+
+func withSearchPath(rawURL, schema string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	q.Set("search_path", schema)
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
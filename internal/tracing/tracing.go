@@ -0,0 +1,114 @@
+// Package tracing - спаны OpenTelemetry от вызова api до запросов в бд
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"storageapi/pkg/errs"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const serviceName = "storageapi"
+
+// экспортеры, TRACES_EXPORTER
+const (
+	ExporterNone   = ""
+	ExporterOTLP   = "otlp"   // OTLP/gRPC, адрес и заголовки из OTEL_EXPORTER_OTLP_*
+	ExporterStdout = "stdout" // JSON в stdout
+	ExporterFile   = "file"   // JSON в TRACES_FILE, для проверки без коллектора
+)
+
+// Setup настраивает глобальный TracerProvider. без экспортера спаны не пишутся,
+// Start возвращает noop спаны. shutdown дописывает буфер спанов, вызывается при остановке
+func Setup(ctx context.Context, exporter, file string) (shutdown func(context.Context) error, err error) {
+	var exp sdktrace.SpanExporter
+	closeFile := func() error { return nil }
+	switch exporter {
+	case ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterOTLP:
+		exp, err = otlptracegrpc.New(ctx)
+	case ExporterStdout:
+		exp, err = stdouttrace.New()
+	case ExporterFile:
+		if file == "" {
+			return nil, fmt.Errorf("tracing: file exporter requires a file path")
+		}
+		f, openErr := os.OpenFile(file, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if openErr != nil {
+			return nil, openErr
+		}
+		closeFile = f.Close
+		exp, err = stdouttrace.New(stdouttrace.WithWriter(f))
+	default:
+		return nil, fmt.Errorf("tracing: unknown exporter %q", exporter)
+	}
+	if err != nil {
+		closeFile()
+		return nil, err
+	}
+
+	// OTEL_SERVICE_NAME и OTEL_RESOURCE_ATTRIBUTES перекрывают значения по умолчанию
+	res, err := resource.New(ctx,
+		resource.WithSchemaURL(semconv.SchemaURL),
+		resource.WithAttributes(semconv.ServiceName(serviceName)),
+		resource.WithTelemetrySDK(),
+		resource.WithFromEnv(),
+	)
+	if err != nil {
+		closeFile()
+		return nil, err
+	}
+	// семплер по умолчанию - все спаны, меняется через OTEL_TRACES_SAMPLER
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exp),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if closeErr := closeFile(); err == nil {
+			err = closeErr
+		}
+		return err
+	}, nil
+}
+
+// Start начинает дочерний спан ctx
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(serviceName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// FromHeader продолжает трассу клиента из заголовков traceparent/baggage
+func FromHeader(ctx context.Context, h http.Header) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.HeaderCarrier(h))
+}
+
+// End записывает ошибку в спан и завершает его. err - указатель на
+// именованный результат, чтобы вызывать как defer tracing.End(span, &err)
+func End(span trace.Span, err *error) {
+	if err != nil && *err != nil {
+		span.RecordError(*err)
+		// код есть только у ошибок errs, ответы net/rpc уже сведены к строке
+		var e *errs.Error
+		if errors.As(*err, &e) {
+			span.SetAttributes(attribute.String("error.code", string(e.Code)))
+		}
+		span.SetStatus(codes.Error, (*err).Error())
+	}
+	span.End()
+}
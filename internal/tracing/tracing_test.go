@@ -0,0 +1,95 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"storageapi/pkg/errs"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// поля спана в JSON stdouttrace, нужные тесту
+type exportedSpan struct {
+	Name        string
+	SpanContext struct{ SpanID, TraceID string }
+	Parent      struct{ SpanID string }
+	Status      struct{ Code string }
+	Attributes  []struct {
+		Key   string
+		Value struct{ Value interface{} }
+	}
+}
+
+func TestFileExporter(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "spans.json")
+	shutdown, err := Setup(context.Background(), ExporterFile, file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { otel.SetTracerProvider(trace.NewNoopTracerProvider()) })
+
+	func() (err error) {
+		ctx, span := Start(context.Background(), "parent")
+		defer End(span, &err)
+		func() (err error) {
+			_, span := Start(ctx, "child")
+			defer End(span, &err)
+			return errs.New(errs.CodeNotFound, "storage not found")
+		}()
+		return nil
+	}()
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	spans := map[string]exportedSpan{}
+	dec := json.NewDecoder(f)
+	for {
+		var s exportedSpan
+		if err := dec.Decode(&s); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		spans[s.Name] = s
+	}
+	parent, child := spans["parent"], spans["child"]
+	if parent.Name == "" || child.Name == "" {
+		t.Fatalf("spans are not exported: %+v", spans)
+	}
+	if child.Parent.SpanID != parent.SpanContext.SpanID || child.SpanContext.TraceID != parent.SpanContext.TraceID {
+		t.Fatal("child span is not linked to parent")
+	}
+	if parent.Status.Code != "Unset" || child.Status.Code != "Error" {
+		t.Fatalf("unexpected statuses: parent %q, child %q", parent.Status.Code, child.Status.Code)
+	}
+	var code interface{}
+	for _, a := range child.Attributes {
+		if a.Key == "error.code" {
+			code = a.Value.Value
+		}
+	}
+	if code != string(errs.CodeNotFound) {
+		t.Fatalf("error.code is %v", code)
+	}
+}
+
+func TestSetupUnknownExporter(t *testing.T) {
+	if _, err := Setup(context.Background(), "zipkin", ""); err == nil {
+		t.Fatal("unknown exporter is accepted")
+	}
+	if _, err := Setup(context.Background(), ExporterFile, ""); err == nil {
+		t.Fatal("file exporter without a path is accepted")
+	}
+}
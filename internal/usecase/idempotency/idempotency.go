@@ -0,0 +1,85 @@
+package idempotency
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"storageapi/internal/database"
+	"storageapi/internal/entity"
+	"storageapi/internal/repository"
+	"storageapi/pkg/errs"
+)
+
+var ErrKeyReused = errors.New("idempotency key was already used with a different request")
+
+// Do выполняет fn не более одного раза для пары method + key.
+// Повтор с тем же ключом и тем же запросом возвращает сохраненный ответ,
+// с тем же ключом и другим запросом - ErrKeyReused.
+// Должен вызываться в транзакции, в которой fn делает изменения,
+// иначе ответ может сохраниться без результата или наоборот.
+// Пустой key отключает проверку. Ключ хранится не меньше ttl Reaper'а,
+// повтор после удаления ключа выполняет fn заново.
+func Do[Resp any](
+	ctx database.TxContext,
+	repo repository.IRepository,
+	method, key string,
+	req interface{},
+	fn func() (Resp, error),
+) (Resp, error) {
+	var empty Resp
+	if key == "" {
+		return fn()
+	}
+	reqHash, err := hashRequest(req)
+	if err != nil {
+		return empty, err
+	}
+	if err := repo.LockIdempotencyKey(ctx, method, key); err != nil {
+		return empty, err
+	}
+	stored, err := repo.FindIdempotencyKey(ctx, method, key)
+	if err != nil {
+		return empty, err
+	}
+	if stored != nil {
+		if stored.RequestHash != reqHash {
+			return empty, errs.Wrap(errs.CodeConflict, ErrKeyReused, fmt.Sprintf("key %q", key)).
+				WithDetail("idempotency_key", key)
+		}
+		var resp Resp
+		if err := json.Unmarshal(stored.Response, &resp); err != nil {
+			return empty, err
+		}
+		return resp, nil
+	}
+
+	resp, err := fn()
+	if err != nil {
+		return empty, err
+	}
+	respData, err := json.Marshal(resp)
+	if err != nil {
+		return empty, err
+	}
+	err = repo.CreateIdempotencyKey(ctx, &entity.IdempotencyKey{
+		Method:      method,
+		Key:         key,
+		RequestHash: reqHash,
+		Response:    respData,
+	})
+	if err != nil {
+		return empty, err
+	}
+	return resp, nil
+}
+
+func hashRequest(req interface{}) (string, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,130 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"storageapi/internal/database"
+	"storageapi/internal/repository"
+	"storageapi/internal/repository/memory"
+	"storageapi/pkg/errs"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type testReq struct {
+	Key    string `json:"key"`
+	Amount int    `json:"amount"`
+}
+
+type testResp struct {
+	ID int `json:"id"`
+}
+
+// счетчик вызовов fn: каждый вызов возвращает новый id
+type counter struct {
+	calls int
+}
+
+func (c *counter) fn() (testResp, error) {
+	c.calls++
+	return testResp{ID: c.calls}, nil
+}
+
+func do(repo repository.IRepository, method string, req testReq, fn func() (testResp, error)) (testResp, error) {
+	var resp testResp
+	err := repo.RunInTransaction(context.Background(), func(ctx database.TxContext, repo repository.IRepository) (err error) {
+		resp, err = Do(ctx, repo, method, req.Key, req, fn)
+		return err
+	})
+	return resp, err
+}
+
+func TestDo(t *testing.T) {
+	repo := memory.NewRepository()
+	c := &counter{}
+	req := testReq{Key: "key-1", Amount: 2}
+
+	first, err := do(repo, "Test.Method", req, c.fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// повтор с тем же ключом и телом возвращает сохраненный ответ без вызова fn
+	replayed, err := do(repo, "Test.Method", req, c.fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.calls != 1 || replayed != first {
+		t.Fatalf("replay must return stored response %+v, got %+v after %d calls", first, replayed, c.calls)
+	}
+
+	// тот же ключ с другим телом - ошибка, fn не вызывается
+	_, err = do(repo, "Test.Method", testReq{Key: "key-1", Amount: 3}, c.fn)
+	if !errors.Is(err, ErrKeyReused) || errs.CodeOf(err) != errs.CodeConflict {
+		t.Fatalf("expected ErrKeyReused with conflict code, got %v", err)
+	}
+	if c.calls != 1 {
+		t.Fatalf("fn must not run for a reused key, %d calls", c.calls)
+	}
+
+	// ключ уникален в пределах метода
+	if _, err := do(repo, "Test.Other", req, c.fn); err != nil || c.calls != 2 {
+		t.Fatalf("key of another method must run fn: %d calls, %v", c.calls, err)
+	}
+	// без ключа fn выполняется каждый раз
+	for i := 0; i < 2; i++ {
+		if _, err := do(repo, "Test.Method", testReq{Amount: 2}, c.fn); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if c.calls != 4 {
+		t.Fatalf("requests without key must always run fn, %d calls", c.calls)
+	}
+}
+
+// неудачный запрос не сохраняет ключ, повтор выполняет fn заново
+func TestDoFailedRequest(t *testing.T) {
+	repo := memory.NewRepository()
+	errFailed := errors.New("failed")
+	req := testReq{Key: "key-1", Amount: 2}
+	_, err := do(repo, "Test.Method", req, func() (testResp, error) {
+		return testResp{}, errFailed
+	})
+	if !errors.Is(err, errFailed) {
+		t.Fatalf("expected fn error, got %v", err)
+	}
+	c := &counter{}
+	resp, err := do(repo, "Test.Method", req, c.fn)
+	if err != nil || c.calls != 1 || resp.ID != 1 {
+		t.Fatalf("retry must run fn: %+v, %d calls, %v", resp, c.calls, err)
+	}
+}
+
+func TestReaper(t *testing.T) {
+	repo := memory.NewRepository()
+	c := &counter{}
+	for _, key := range []string{"key-1", "key-2", "key-3"} {
+		if _, err := do(repo, "Test.Method", testReq{Key: key}, c.fn); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// ключи моложе ttl не удаляются
+	NewReaper(repo, zap.NewNop().Sugar(), time.Hour, time.Hour, 2).reap(context.Background())
+	if k, err := repo.FindIdempotencyKey(context.Background(), "Test.Method", "key-1"); err != nil || k == nil {
+		t.Fatalf("fresh key must be kept: %+v, %v", k, err)
+	}
+
+	// пачка меньше числа ключей: reap выбирает пачки, пока они полные
+	NewReaper(repo, zap.NewNop().Sugar(), time.Hour, -time.Minute, 2).reap(context.Background())
+	for _, key := range []string{"key-1", "key-2", "key-3"} {
+		if k, err := repo.FindIdempotencyKey(context.Background(), "Test.Method", key); err != nil || k != nil {
+			t.Fatalf("%s must be deleted: %+v, %v", key, k, err)
+		}
+	}
+	// после удаления ключа повтор выполняется как новый запрос
+	if _, err := do(repo, "Test.Method", testReq{Key: "key-1"}, c.fn); err != nil || c.calls != 4 {
+		t.Fatalf("request with deleted key must run fn: %d calls, %v", c.calls, err)
+	}
+}
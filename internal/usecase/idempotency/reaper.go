@@ -0,0 +1,62 @@
+package idempotency
+
+import (
+	"context"
+	"storageapi/internal/repository"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Reaper периодически удаляет ключи старше ttl, иначе таблица ключей растет
+// без ограничений. Повтор запроса с удаленным ключом выполняется как новый запрос,
+// поэтому ttl должен быть больше времени, в течение которого клиенты повторяют запросы
+type Reaper struct {
+	repo      repository.IRepository
+	log       *zap.SugaredLogger
+	interval  time.Duration
+	ttl       time.Duration
+	batchSize uint
+}
+
+func NewReaper(repo repository.IRepository, log *zap.SugaredLogger, interval, ttl time.Duration, batchSize uint) *Reaper {
+	return &Reaper{
+		repo:      repo,
+		log:       log,
+		interval:  interval,
+		ttl:       ttl,
+		batchSize: batchSize,
+	}
+}
+
+// блокируется до отмены ctx
+func (r *Reaper) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.reap(ctx)
+		}
+	}
+}
+
+func (r *Reaper) reap(ctx context.Context) {
+	before := time.Now().Add(-r.ttl)
+	for {
+		deleted, err := r.repo.DeleteExpiredIdempotencyKeys(ctx, before, r.batchSize)
+		if err != nil {
+			r.log.Errorw("failed to delete expired idempotency keys", "error", err)
+			return
+		}
+		if deleted > 0 {
+			r.log.Infow("deleted expired idempotency keys", "count", deleted)
+		}
+		// пачка выбрана не полностью - истекших ключей больше нет
+		if uint(deleted) < r.batchSize {
+			return
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package reservation
+
+import (
+	"sort"
+	"storageapi/internal/entity"
+	"storageapi/pkg/algo"
+	"storageapi/pkg/errs"
+	"storageapi/pkg/model"
+)
+
+// стратегии распределения позиции резерва по складам, описаны в pkg/model
+const (
+	StrategyLargestFirst   = model.StrategyLargestFirst
+	StrategyFewestStorages = model.StrategyFewestStorages
+	StrategySingleStorage  = model.StrategySingleStorage
+	StrategyEvenSpread     = model.StrategyEvenSpread
+	StrategyPriority       = model.StrategyPriority
+)
+
+// свободный (незарезервированный) остаток товара на складе
+type FreeStock struct {
+	StorageID entity.PK
+	Amount    uint
+}
+
+type Allocation struct {
+	StorageID entity.PK
+	Amount    uint
+}
+
+// Allocator решает, с каких складов зарезервировать amount единиц одного товара.
+// Вызывающий гарантирует, что суммы free хватает на amount
+type Allocator interface {
+	Allocate(amount uint, free []FreeStock) []Allocation
+}
+
+// storagePriority используется только стратегией priority
+func NewAllocator(strategy string, storagePriority []entity.PK) (Allocator, error) {
+	switch strategy {
+	case StrategyLargestFirst:
+		return largestFirstAllocator{}, nil
+	case StrategyFewestStorages:
+		return fewestStoragesAllocator{}, nil
+	case StrategySingleStorage:
+		return singleStorageAllocator{}, nil
+	case StrategyEvenSpread:
+		return evenSpreadAllocator{}, nil
+	case StrategyPriority:
+		return priorityAllocator{priority: storagePriority}, nil
+	}
+	return nil, errs.Newf(errs.CodeValidation, "unknown allocation strategy %q", strategy).WithDetail("strategy", strategy)
+}
+
+type largestFirstAllocator struct{}
+
+func (largestFirstAllocator) Allocate(amount uint, free []FreeStock) []Allocation {
+	free = sortedFree(free, false)
+	return takeInOrder(amount, free)
+}
+
+type fewestStoragesAllocator struct{}
+
+func (fewestStoragesAllocator) Allocate(amount uint, free []FreeStock) []Allocation {
+	free = sortedFree(free, false)
+	// k самых больших складов - минимальное число складов, которого хватит
+	var (
+		taken uint
+		k     int
+	)
+	for k < len(free) && taken+free[k].Amount < amount {
+		taken += free[k].Amount
+		k++
+	}
+	result := takeInOrder(taken, free[:k])
+	if remainder := amount - taken; remainder > 0 {
+		// из оставшихся выбираем наименьший склад, где хватает остатка
+		best := k
+		for i := k + 1; i < len(free); i++ {
+			if free[i].Amount >= remainder && free[i].Amount < free[best].Amount {
+				best = i
+			}
+		}
+		result = append(result, Allocation{StorageID: free[best].StorageID, Amount: remainder})
+	}
+	return result
+}
+
+type singleStorageAllocator struct{}
+
+func (singleStorageAllocator) Allocate(amount uint, free []FreeStock) []Allocation {
+	free = sortedFree(free, true)
+	for _, f := range free {
+		if f.Amount >= amount {
+			return []Allocation{{StorageID: f.StorageID, Amount: amount}}
+		}
+	}
+	return fewestStoragesAllocator{}.Allocate(amount, free)
+}
+
+type evenSpreadAllocator struct{}
+
+func (evenSpreadAllocator) Allocate(amount uint, free []FreeStock) []Allocation {
+	// по возрастанию остатка: то, что не влезло в маленькие склады,
+	// поровну делится между оставшимися
+	free = sortedFree(free, true)
+	nonEmpty := make([]FreeStock, 0, len(free))
+	for _, f := range free {
+		if f.Amount > 0 {
+			nonEmpty = append(nonEmpty, f)
+		}
+	}
+	result := []Allocation{}
+	remaining := amount
+	for i, f := range nonEmpty {
+		if remaining == 0 {
+			break
+		}
+		left := uint(len(nonEmpty) - i)
+		share := algo.Min((remaining+left-1)/left, f.Amount)
+		remaining -= share
+		result = append(result, Allocation{StorageID: f.StorageID, Amount: share})
+	}
+	return result
+}
+
+type priorityAllocator struct {
+	priority []entity.PK
+}
+
+func (a priorityAllocator) Allocate(amount uint, free []FreeStock) []Allocation {
+	rank := make(map[entity.PK]int, len(a.priority))
+	for i, id := range a.priority {
+		if _, ok := rank[id]; !ok {
+			rank[id] = i
+		}
+	}
+	free = sortedFree(free, false)
+	sort.SliceStable(free, func(i, j int) bool {
+		ri, iok := rank[free[i].StorageID]
+		rj, jok := rank[free[j].StorageID]
+		if iok && jok {
+			return ri < rj
+		}
+		return iok && !jok
+	})
+	return takeInOrder(amount, free)
+}
+
+// копия free, отсортированная по остатку (при равенстве - по id склада)
+func sortedFree(free []FreeStock, asc bool) []FreeStock {
+	result := append([]FreeStock{}, free...)
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Amount == result[j].Amount {
+			return result[i].StorageID < result[j].StorageID
+		}
+		if asc {
+			return result[i].Amount < result[j].Amount
+		}
+		return result[i].Amount > result[j].Amount
+	})
+	return result
+}
+
+// жадно берет со складов в переданном порядке
+func takeInOrder(amount uint, free []FreeStock) []Allocation {
+	result := []Allocation{}
+	for _, f := range free {
+		if amount == 0 {
+			break
+		}
+		if f.Amount == 0 {
+			continue
+		}
+		take := algo.Min(amount, f.Amount)
+		amount -= take
+		result = append(result, Allocation{StorageID: f.StorageID, Amount: take})
+	}
+	return result
+}
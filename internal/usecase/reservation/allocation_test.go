@@ -0,0 +1,81 @@
+package reservation
+
+import (
+	"reflect"
+	"storageapi/internal/entity"
+	"testing"
+)
+
+func TestAllocators(t *testing.T) {
+	free := []FreeStock{
+		{StorageID: 1, Amount: 3},
+		{StorageID: 2, Amount: 10},
+		{StorageID: 3, Amount: 6},
+		{StorageID: 4, Amount: 0},
+	}
+	tests := []struct {
+		strategy string
+		priority []entity.PK
+		amount   uint
+		want     []Allocation
+	}{
+		{
+			strategy: StrategyLargestFirst,
+			amount:   12,
+			want:     []Allocation{{StorageID: 2, Amount: 10}, {StorageID: 3, Amount: 2}},
+		},
+		{
+			strategy: StrategyFewestStorages,
+			amount:   12,
+			want:     []Allocation{{StorageID: 2, Amount: 10}, {StorageID: 1, Amount: 2}},
+		},
+		{
+			strategy: StrategySingleStorage,
+			amount:   5,
+			want:     []Allocation{{StorageID: 3, Amount: 5}},
+		},
+		{
+			// ни на одном складе не хватает - как fewest_storages
+			strategy: StrategySingleStorage,
+			amount:   12,
+			want:     []Allocation{{StorageID: 2, Amount: 10}, {StorageID: 1, Amount: 2}},
+		},
+		{
+			strategy: StrategyEvenSpread,
+			amount:   12,
+			want:     []Allocation{{StorageID: 1, Amount: 3}, {StorageID: 3, Amount: 5}, {StorageID: 2, Amount: 4}},
+		},
+		{
+			strategy: StrategyEvenSpread,
+			amount:   2,
+			want:     []Allocation{{StorageID: 1, Amount: 1}, {StorageID: 3, Amount: 1}},
+		},
+		{
+			strategy: StrategyPriority,
+			priority: []entity.PK{1, 3},
+			amount:   12,
+			want:     []Allocation{{StorageID: 1, Amount: 3}, {StorageID: 3, Amount: 6}, {StorageID: 2, Amount: 3}},
+		},
+		{
+			strategy: StrategyPriority,
+			amount:   4,
+			want:     []Allocation{{StorageID: 2, Amount: 4}},
+		},
+	}
+	for _, tt := range tests {
+		allocator, err := NewAllocator(tt.strategy, tt.priority)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := allocator.Allocate(tt.amount, free)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s(%d): got %v, want %v", tt.strategy, tt.amount, got, tt.want)
+		}
+	}
+}
+
+func TestNewAllocatorUnknownStrategy(t *testing.T) {
+	if _, err := NewAllocator("random", nil); err == nil {
+		t.Error("expected error for unknown strategy")
+	}
+}
@@ -0,0 +1,222 @@
+package reservation
+
+import (
+	"fmt"
+	"storageapi/internal/database"
+	"storageapi/internal/entity"
+	"storageapi/internal/repository"
+	"storageapi/internal/tracing"
+	"storageapi/pkg/algo"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// результат переноса резервов с выключаемого склада
+type EvacuationResult struct {
+	Moved    []entity.PK
+	NotMoved []NotMovedReservation
+}
+
+// заказ, который остался на выключенном складе
+type NotMovedReservation struct {
+	ReservationID entity.PK
+	ProductID     entity.PK
+	Reason        string
+}
+
+// EvacuateStorage переносит позиции активных заказов со склада storageID на другие
+// доступные склады той же логикой распределения, что и при резерве.
+// Заказ переносится целиком или остается на складе и попадает в NotMoved.
+// Должен вызываться внутри транзакции, в которой склад уже помечен недоступным
+func (s *Service) EvacuateStorage(
+	ctx database.TxContext,
+	repo repository.IRepository,
+	storageID entity.PK,
+	strategy string,
+) (_ *EvacuationResult, err error) {
+	spanCtx, span := tracing.Start(ctx, "reservation.EvacuateStorage", attribute.Int64("storage_id", int64(storageID)))
+	defer tracing.End(span, &err)
+	ctx = database.WithTX(spanCtx, ctx.TX())
+	result := &EvacuationResult{
+		Moved:    []entity.PK{},
+		NotMoved: []NotMovedReservation{},
+	}
+	allocator, err := s.allocatorFor(ReserveProductsReq{Strategy: strategy})
+	if err != nil {
+		return nil, err
+	}
+	// резерв блокирует остатки товаров до своего коммита. пока остатки склада
+	// заблокированы, новый заказ на нем не появится, а заказ резерва, который
+	// успел их взять раньше, будет виден следующему запросу после его коммита.
+	// встречная блокировка в другом порядке дает deadlock, транзакция повторяется
+	if _, err := repo.LockStorageDataByStorage(ctx, storageID); err != nil {
+		return nil, err
+	}
+	orders, err := repo.LockReservationOrdersByStorage(ctx, storageID, entity.ReservationStatusActive)
+	if err != nil {
+		return nil, err
+	}
+	if len(orders) == 0 {
+		return result, nil
+	}
+	items, err := repo.GetReservationOrderItems(ctx, algo.Map(orders, func(o *entity.ReservationOrder, _ int) entity.PK {
+		return o.ID
+	})...)
+	if err != nil {
+		return nil, err
+	}
+	productIDs := algo.Map(
+		algo.UniqBy(
+			algo.Filter(items, func(i *entity.ReservationOrderItem, _ int) bool {
+				return i.StorageID == storageID
+			}),
+			func(i *entity.ReservationOrderItem) entity.PK {
+				return i.ProductID
+			},
+		),
+		func(i *entity.ReservationOrderItem, _ int) entity.PK {
+			return i.ProductID
+		},
+	)
+	stResData, err := s.getStorageDataWithReservation(ctx, repo, productIDs...)
+	if err != nil {
+		return nil, err
+	}
+	unreserved, err := s.getFreeReservations(stResData.storeData, stResData.reservations, productIDs...)
+	if err != nil {
+		return nil, err
+	}
+	unreserved = algo.Filter(unreserved, func(u *unreservedProduct, _ int) bool {
+		return u.storageID != storageID
+	})
+	free := map[storageProductKey]uint{}
+	for _, u := range unreserved {
+		free[storageProductKey{u.storageID, u.productID}] = u.amount
+	}
+
+	moved := []*entity.ReservationOrderItem{}
+	added := []*entity.ReservationOrderItem{}
+	for _, o := range orders {
+		orderItems := algo.Filter(items, func(i *entity.ReservationOrderItem, _ int) bool {
+			return i.ReservationID == o.ID && i.StorageID == storageID
+		})
+		orderAdded, notMoved := s.allocateEvacuated(allocator, orderItems, unreserved, free)
+		if notMoved != nil {
+			result.NotMoved = append(result.NotMoved, *notMoved)
+			continue
+		}
+		for _, a := range orderAdded {
+			free[storageProductKey{a.StorageID, a.ProductID}] -= a.Amount
+		}
+		moved = append(moved, orderItems...)
+		added = append(added, orderAdded...)
+		result.Moved = append(result.Moved, o.ID)
+	}
+	if len(moved) == 0 {
+		return result, nil
+	}
+
+	// агрегат: снимаем резерв с выключенного склада и добавляем на новые
+	reservations := []*entity.ProductReservation{}
+	for _, r := range stResData.reservations {
+		reservations = append(reservations, r...)
+	}
+	if err := s.subtractFromAggregate(ctx, repo, reservations, moved); err != nil {
+		return nil, err
+	}
+	addedReservations := map[storageProductKey]*entity.ProductReservation{}
+	for _, a := range added {
+		key := storageProductKey{a.StorageID, a.ProductID}
+		if r, ok := addedReservations[key]; ok {
+			r.Amount += a.Amount
+			continue
+		}
+		addedReservations[key] = &entity.ProductReservation{
+			StorageID: a.StorageID,
+			ProductID: a.ProductID,
+			Amount:    a.Amount,
+		}
+	}
+	toAdd := make([]*entity.ProductReservation, 0, len(addedReservations))
+	for _, r := range addedReservations {
+		toAdd = append(toAdd, r)
+	}
+	if err := s.addToAggregate(ctx, repo, stResData.reservations, toAdd); err != nil {
+		return nil, err
+	}
+
+	// позиции заказов: старые удаляются, новые сливаются с уже существующими
+	err = repo.DeleteReservationOrderItem(ctx, algo.Map(moved, func(i *entity.ReservationOrderItem, _ int) entity.PK {
+		return i.ID
+	})...)
+	if err != nil {
+		return nil, err
+	}
+	updatedItems := []*entity.ReservationOrderItem{}
+	createdItems := []*entity.ReservationOrderItem{}
+	for _, a := range added {
+		if existing, ok := algo.Find(items, func(i *entity.ReservationOrderItem) bool {
+			return i.ReservationID == a.ReservationID && i.StorageID == a.StorageID && i.ProductID == a.ProductID
+		}); ok {
+			existing.Amount += a.Amount
+			updatedItems = append(updatedItems, existing)
+			continue
+		}
+		createdItems = append(createdItems, a)
+	}
+	if len(updatedItems) > 0 {
+		if _, err := repo.UpdateReservationOrderItem(ctx, updatedItems...); err != nil {
+			return nil, err
+		}
+	}
+	if len(createdItems) > 0 {
+		if _, err := repo.CreateReservationOrderItem(ctx, createdItems...); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// распределяет позиции одного заказа по свободным остаткам free (не изменяя его).
+// если хотя бы одну позицию разместить нельзя, заказ не переносится
+func (s *Service) allocateEvacuated(
+	allocator Allocator,
+	orderItems []*entity.ReservationOrderItem,
+	unreserved []*unreservedProduct,
+	free map[storageProductKey]uint,
+) ([]*entity.ReservationOrderItem, *NotMovedReservation) {
+	result := []*entity.ReservationOrderItem{}
+	for _, i := range orderItems {
+		var total uint
+		freeStock := []FreeStock{}
+		for _, u := range unreserved {
+			if u.productID != i.ProductID {
+				continue
+			}
+			amount := free[storageProductKey{u.storageID, u.productID}]
+			total += amount
+			freeStock = append(freeStock, FreeStock{StorageID: u.storageID, Amount: amount})
+		}
+		if total < i.Amount {
+			return nil, &NotMovedReservation{
+				ReservationID: i.ReservationID,
+				ProductID:     i.ProductID,
+				Reason: fmt.Sprintf(
+					"only %d of product %d is free on available storages (reserved %d)",
+					total,
+					i.ProductID,
+					i.Amount,
+				),
+			}
+		}
+		for _, a := range allocator.Allocate(i.Amount, freeStock) {
+			result = append(result, &entity.ReservationOrderItem{
+				ReservationID: i.ReservationID,
+				StorageID:     a.StorageID,
+				ProductID:     i.ProductID,
+				Amount:        a.Amount,
+			})
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,103 @@
+package reservation
+
+import (
+	"context"
+	"storageapi/internal/database"
+	"storageapi/internal/entity"
+	"storageapi/internal/repository"
+	"storageapi/internal/test/testdb"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// заказ, которому хватает остатка на других складах, переносится целиком,
+// остальные остаются на выключенном складе
+func TestEvacuateStorage(t *testing.T) {
+	db, err := database.NewDBWithPgx(testdb.New(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	log := zap.NewNop().Sugar()
+	repo := repository.NewRepository(db, log)
+	ctx := context.Background()
+
+	var (
+		offline, online *entity.Storage
+		productID       entity.PK
+	)
+	err = repo.RunInTransaction(ctx, func(ctx database.TxContext, repo repository.IRepository) error {
+		storages, err := repo.CreateStorage(ctx, &entity.Storage{IsAvailable: true}, &entity.Storage{IsAvailable: true})
+		if err != nil {
+			return err
+		}
+		offline, online = storages[0], storages[1]
+		products, err := repo.CreateProduct(ctx, &entity.Product{Name: "evacuate", Vendor: "evacuate-1", Size: "m"})
+		if err != nil {
+			return err
+		}
+		productID = products[0].ID
+		_, err = repo.CreateStorageData(
+			ctx,
+			&entity.StoredProduct{StorageID: offline.ID, ProductID: productID, Amount: 10},
+			&entity.StoredProduct{StorageID: online.ID, ProductID: productID, Amount: 4},
+		)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	service := NewService(repo, log, ServiceConf{
+		DefaultStrategy: StrategyPriority,
+		StoragePriority: []entity.PK{offline.ID},
+	})
+	reserve := func(amount uint) *ReservationResp {
+		resp, err := service.ReserveProducts(ctx, ReserveProductsReq{
+			Products: []ReserveProductsReqItem{{ID: productID.ToUint(), Amount: amount}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+	small, large := reserve(3), reserve(6)
+
+	var result *EvacuationResult
+	err = repo.RunInTransaction(ctx, func(ctx database.TxContext, repo repository.IRepository) (err error) {
+		offline.IsAvailable = false
+		if _, err := repo.UpdateStorage(ctx, offline); err != nil {
+			return err
+		}
+		result, err = service.EvacuateStorage(ctx, repo, offline.ID, StrategyLargestFirst)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Moved) != 1 || result.Moved[0].ToUint() != small.ID {
+		t.Errorf("moved %v, want [%d]", result.Moved, small.ID)
+	}
+	if len(result.NotMoved) != 1 || result.NotMoved[0].ReservationID.ToUint() != large.ID {
+		t.Errorf("not moved %v, want reservation %d", result.NotMoved, large.ID)
+	}
+	moved, err := service.GetReservation(ctx, GetReservationReq{ID: small.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(moved.Items) != 1 || moved.Items[0].StorageID != online.ID.ToUint() || moved.Items[0].Amount != 3 {
+		t.Errorf("moved reservation items %+v", moved.Items)
+	}
+	reservations, err := repo.GetReservationByProduct(ctx, productID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[entity.PK]uint{offline.ID: 6, online.ID: 3}
+	for _, r := range reservations {
+		if r.Amount != want[r.StorageID] {
+			t.Errorf("storage %d: reserved %d, want %d", r.StorageID, r.Amount, want[r.StorageID])
+		}
+	}
+}
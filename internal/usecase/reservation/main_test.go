@@ -0,0 +1,10 @@
+package reservation
+
+import (
+	"storageapi/internal/test/testdb"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	testdb.Main(m)
+}
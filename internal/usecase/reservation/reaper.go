@@ -0,0 +1,58 @@
+package reservation
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Reaper периодически снимает заказы с истекшим сроком резерва.
+// Несколько инстансов могут работать одновременно: заказы блокируются
+// с SKIP LOCKED и каждый снимается только одним из них.
+type Reaper struct {
+	service   *Service
+	log       *zap.SugaredLogger
+	interval  time.Duration
+	batchSize uint
+}
+
+func NewReaper(s *Service, log *zap.SugaredLogger, interval time.Duration, batchSize uint) *Reaper {
+	return &Reaper{
+		service:   s,
+		log:       log,
+		interval:  interval,
+		batchSize: batchSize,
+	}
+}
+
+// блокируется до отмены ctx
+func (r *Reaper) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.reap(ctx)
+		}
+	}
+}
+
+func (r *Reaper) reap(ctx context.Context) {
+	for {
+		released, err := r.service.ReleaseExpiredReservations(ctx, time.Now(), r.batchSize)
+		if err != nil {
+			r.log.Errorw("failed to release expired reservations", "error", err)
+			return
+		}
+		if released > 0 {
+			r.log.Infow("released expired reservations", "count", released)
+		}
+		// пачка выбрана не полностью - истекших заказов больше нет
+		if uint(released) < r.batchSize {
+			return
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package reservation
+
+import (
+	"context"
+	"storageapi/internal/entity"
+	"storageapi/internal/repository"
+	"storageapi/pkg/errs"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// активный заказ на amount товара со склада, истекший в expiresAt.
+// агрегат product_reservations заполняет вызывающий
+func createExpiredOrder(t *testing.T, repo repository.IRepository, storageID, productID entity.PK, amount uint, expiresAt time.Time) entity.PK {
+	t.Helper()
+	ctx := context.Background()
+	orders, err := repo.CreateReservationOrder(ctx, &entity.ReservationOrder{
+		Status:    entity.ReservationStatusActive,
+		ExpiresAt: &expiresAt,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.CreateReservationOrderItem(ctx, &entity.ReservationOrderItem{
+		ReservationID: orders[0].ID,
+		StorageID:     storageID,
+		ProductID:     productID,
+		Amount:        amount,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return orders[0].ID
+}
+
+func TestReleaseExpiredReservations(t *testing.T) {
+	service, repo, _, productID := newMemoryService(t, 10)
+	ctx := context.Background()
+	reserve := func(ttl uint) entity.PK {
+		t.Helper()
+		resp, err := service.ReserveProducts(ctx, ReserveProductsReq{
+			Products:   []ReserveProductsReqItem{{ID: productID.ToUint(), Amount: 2}},
+			TTLSeconds: ttl,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return entity.PK(resp.ID)
+	}
+	short, long, forever := reserve(60), reserve(120), reserve(0)
+	now := time.Now()
+
+	released, err := service.ReleaseExpiredReservations(ctx, now, 10)
+	if err != nil || released != 0 {
+		t.Fatalf("nothing is expired yet, released %d: %v", released, err)
+	}
+	released, err = service.ReleaseExpiredReservations(ctx, now.Add(90*time.Second), 10)
+	if err != nil || released != 1 {
+		t.Fatalf("expected one expired order, released %d: %v", released, err)
+	}
+	want := map[entity.PK]string{
+		short:   entity.ReservationStatusExpired,
+		long:    entity.ReservationStatusActive,
+		forever: entity.ReservationStatusActive,
+	}
+	for id, status := range want {
+		got, err := service.GetReservation(ctx, GetReservationReq{ID: id.ToUint()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Status != status {
+			t.Errorf("order %d: expected %s, got %s", id, status, got.Status)
+		}
+	}
+	if got := reservedAmount(t, repo, productID); got != 4 {
+		t.Fatalf("expected 4 reserved after release, got %d", got)
+	}
+	// снятый заказ нельзя отменить или снять еще раз
+	if err := service.UndoReserve(ctx, UndoReservationReq{ID: short.ToUint()}); errs.CodeOf(err) != errs.CodeConflict {
+		t.Fatalf("expected conflict for expired order, got %v", err)
+	}
+
+	// за вызов снимается не больше limit заказов, бессрочный не снимается никогда
+	later := now.Add(time.Hour)
+	reserve(60)
+	for _, wantReleased := range []int{1, 1, 0} {
+		released, err := service.ReleaseExpiredReservations(ctx, later, 1)
+		if err != nil || released != wantReleased {
+			t.Fatalf("expected %d released, got %d: %v", wantReleased, released, err)
+		}
+	}
+	if got := reservedAmount(t, repo, productID); got != 2 {
+		t.Fatalf("expected only the order without ttl to stay reserved, got %d", got)
+	}
+}
+
+func TestReaper(t *testing.T) {
+	service, repo, storages, productID := newMemoryService(t, 10)
+	expired := time.Now().Add(-time.Minute)
+	for i := 0; i < 3; i++ {
+		createExpiredOrder(t, repo, storages[0], productID, 1, expired)
+	}
+	if _, err := repo.CreateReservation(context.Background(), &entity.ProductReservation{
+		StorageID: storages[0],
+		ProductID: productID,
+		Amount:    3,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// пачка меньше числа заказов: за один проход reap выбирает пачки, пока они полные
+	reaper := NewReaper(service, zap.NewNop().Sugar(), 10*time.Millisecond, 2)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		reaper.Run(ctx)
+	}()
+	deadline := time.Now().Add(5 * time.Second)
+	for reservedAmount(t, repo, productID) != 0 {
+		if time.Now().After(deadline) {
+			cancel()
+			t.Fatal("reaper did not release expired orders")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Run возвращается после отмены ctx
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("reaper did not stop after ctx was cancelled")
+	}
+}
@@ -2,54 +2,121 @@ package reservation
 
 import (
 	"context"
-	"errors"
-	"fmt"
-	"sort"
 	"storageapi/internal/database"
 	"storageapi/internal/entity"
 	"storageapi/internal/repository"
+	"storageapi/internal/tracing"
+	"storageapi/internal/usecase/idempotency"
 	"storageapi/pkg/algo"
+	"storageapi/pkg/errs"
+	"time"
 
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
 )
 
+type ServiceConf struct {
+	// стратегия распределения, если клиент не указал свою
+	DefaultStrategy string
+	// порядок складов для стратегии priority, если клиент не указал свой
+	StoragePriority []entity.PK
+}
+
 type Service struct {
 	repo Repository
 	log  *zap.SugaredLogger
+	conf ServiceConf
 }
 
-func NewService(r repository.IRepository, log *zap.SugaredLogger) *Service {
+func NewService(r repository.IRepository, log *zap.SugaredLogger, conf ServiceConf) *Service {
 	return &Service{
 		repo: r,
 		log:  log,
+		conf: conf,
 	}
 }
 
-func (s *Service) ReserveProducts(ctx context.Context, req ReserveProductsReq) error {
-	// todo: make this method singlethreaded only by attaching to channel
-	productIDs := algo.Map(req, func(r ReserveProductsReqItem, _ int) entity.PK {
+func (s *Service) ReserveProducts(ctx context.Context, req ReserveProductsReq) (_ *ReservationResp, err error) {
+	ctx, span := tracing.Start(ctx, "reservation.ReserveProducts", attribute.Int("products", len(req.Products)))
+	defer tracing.End(span, &err)
+	var result *ReservationResp
+	err = s.repo.RunInTransaction(ctx, func(ctx database.TxContext, repo repository.IRepository) (err error) {
+		result, err = idempotency.Do(ctx, repo, "Reservation.CreateReservation", req.IdempotencyKey, req, func() (*ReservationResp, error) {
+			return s.reserveProducts(ctx, repo, req)
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// чтение остатков, расчет и запись резерва идут в одной транзакции,
+// остатки товаров блокируются, поэтому параллельные резервы не продадут один товар дважды
+func (s *Service) reserveProducts(ctx database.TxContext, repo repository.IRepository, req ReserveProductsReq) (*ReservationResp, error) {
+	productIDs := algo.Map(req.Products, func(r ReserveProductsReqItem, _ int) entity.PK {
 		return entity.PK(r.ID)
 	})
 
-	stResData, err := s.getStorageDataWithReservation(ctx, productIDs...)
+	stResData, err := s.getStorageDataWithReservation(ctx, repo, productIDs...)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	addedReservations, err := s.getReservationsToAdd(req, stResData.storeData, stResData.reservations)
+	allocator, err := s.allocatorFor(req)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	// расчет распределения без обращений к бд - отдельный спан, чтобы отличать его от запросов
+	_, span := tracing.Start(ctx, "reservation.allocate", attribute.Int("products", len(req.Products)))
+	addedReservations, err := s.getReservationsToAdd(req, allocator, stResData.storeData, stResData.reservations)
+	span.SetAttributes(attribute.Int("reservations", len(addedReservations)))
+	tracing.End(span, &err)
+	if err != nil {
+		return nil, err
+	}
+
+	order := &entity.ReservationOrder{
+		Status: entity.ReservationStatusActive,
+	}
+	if req.TTLSeconds > 0 {
+		expiresAt := time.Now().Add(req.TTL())
+		order.ExpiresAt = &expiresAt
 	}
+	orders, err := repo.CreateReservationOrder(ctx, order)
+	if err != nil {
+		return nil, err
+	}
+	order = orders[0]
+	items := algo.Map(addedReservations, func(r *entity.ProductReservation, _ int) *entity.ReservationOrderItem {
+		return &entity.ReservationOrderItem{
+			ReservationID: order.ID,
+			StorageID:     r.StorageID,
+			ProductID:     r.ProductID,
+			Amount:        r.Amount,
+		}
+	})
+	if items, err = repo.CreateReservationOrderItem(ctx, items...); err != nil {
+		return nil, err
+	}
+	if err := s.addToAggregate(ctx, repo, stResData.reservations, addedReservations); err != nil {
+		return nil, err
+	}
+	return newReservationResp(order, items), nil
+}
 
-	// upsert all the reservations to database
+// upsert all the reservations to product_reservations aggregate
+func (s *Service) addToAggregate(
+	ctx database.TxContext,
+	repo repository.IRepository,
+	reservationDataByProductID map[entity.PK][]*entity.ProductReservation,
+	addedReservations []*entity.ProductReservation,
+) error {
 	updated := []*entity.ProductReservation{}
 	created := []*entity.ProductReservation{}
 	for _, r := range addedReservations {
-		reservations, ok := stResData.reservations[r.ProductID]
-		if !ok {
-			return errors.New("cannot find reservation data by product id, returned by added reservations (debug)")
-		}
-		res, ok := algo.Find(reservations, func(_r *entity.ProductReservation) bool {
+		res, ok := algo.Find(reservationDataByProductID[r.ProductID], func(_r *entity.ProductReservation) bool {
 			return _r.StorageID == r.StorageID
 		})
 		if ok {
@@ -61,13 +128,39 @@ func (s *Service) ReserveProducts(ctx context.Context, req ReserveProductsReq) e
 			created = append(created, r)
 		}
 	}
-	return s.repo.RunInTransaction(ctx, func(ctx database.TxContext, repo repository.IRepository) error {
+	if len(updated) > 0 {
 		if _, err := repo.UpdateReservation(ctx, updated...); err != nil {
 			return err
 		}
-		_, err := repo.CreateReservation(ctx, created...)
-		return err
+	}
+	if len(created) > 0 {
+		if _, err := repo.CreateReservation(ctx, created...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Service) GetReservation(ctx context.Context, req GetReservationReq) (_ *ReservationResp, err error) {
+	ctx, span := tracing.Start(ctx, "reservation.GetReservation")
+	defer tracing.End(span, &err)
+	var result *ReservationResp
+	err = s.repo.RunInTransaction(ctx, func(ctx database.TxContext, repo repository.IRepository) error {
+		order, err := repo.GetReservationOrder(ctx, entity.PK(req.ID))
+		if err != nil {
+			return err
+		}
+		items, err := repo.GetReservationOrderItems(ctx, order.ID)
+		if err != nil {
+			return err
+		}
+		result = newReservationResp(order, items)
+		return nil
 	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
 }
 
 type storageDataReservation struct {
@@ -75,28 +168,43 @@ type storageDataReservation struct {
 	reservations map[entity.PK][]*entity.ProductReservation
 }
 
-func (s *Service) getStorageDataWithReservation(ctx context.Context, productIDs ...entity.PK) (*storageDataReservation, error) {
-	var (
-		storedData      []*entity.StoredProduct
-		reservationData []*entity.ProductReservation
-	)
-	err := s.repo.RunInTransaction(ctx, func(ctx database.TxContext, repo repository.IRepository) (err error) {
-		// fetch data associated with products from request
-		storedData, err = repo.GetStorageDataByProduct(ctx, productIDs...)
-		if err != nil {
-			return err
-		}
-		reservationData, err = repo.GetReservationByProduct(ctx, productIDs...)
-		return err
-	})
+// должен вызываться внутри транзакции, данные читаются через переданный repo.
+// остатки товаров блокируются до конца транзакции
+func (s *Service) getStorageDataWithReservation(
+	ctx database.TxContext,
+	repo repository.IRepository,
+	productIDs ...entity.PK,
+) (*storageDataReservation, error) {
+	// fetch data associated with products from request
+	storedData, err := repo.LockStorageDataByProduct(ctx, productIDs...)
 	if err != nil {
 		return nil, err
 	}
+	reservationData, err := repo.GetReservationByProduct(ctx, productIDs...)
+	if err != nil {
+		return nil, err
+	}
+	// недоступность склада читается после блокировки остатков: EvacuateStorage
+	// блокирует остатки склада, поэтому резерв либо ждет его и видит склад
+	// выключенным, либо успевает раньше, и тогда его заказ переносится
+	isAvailable := false
+	unavailableStorages, err := repo.ListStorages(ctx, &repository.ListStorageFilter{IsAvailable: &isAvailable})
+	if err != nil {
+		return nil, err
+	}
+	unavailable := make(map[entity.PK]bool, len(unavailableStorages))
+	for _, st := range unavailableStorages {
+		unavailable[st.ID] = true
+	}
 
 	// map aggregation for speed up
 	storedDataByProductID := map[entity.PK][]*entity.StoredProduct{}
 	reservationDataByProductID := map[entity.PK][]*entity.ProductReservation{}
 	for _, stData := range storedData {
+		// на недоступных складах резервировать нельзя
+		if unavailable[stData.StorageID] {
+			continue
+		}
 		l := storedDataByProductID[stData.ProductID]
 		l = append(l, stData)
 		storedDataByProductID[stData.ProductID] = l
@@ -113,15 +221,30 @@ func (s *Service) getStorageDataWithReservation(ctx context.Context, productIDs
 	}, nil
 }
 
+func (s *Service) allocatorFor(req ReserveProductsReq) (Allocator, error) {
+	strategy := s.conf.DefaultStrategy
+	if req.Strategy != "" {
+		strategy = req.Strategy
+	}
+	priority := s.conf.StoragePriority
+	if len(req.StoragePriority) > 0 {
+		priority = algo.Map(req.StoragePriority, func(id uint, _ int) entity.PK {
+			return entity.PK(id)
+		})
+	}
+	return NewAllocator(strategy, priority)
+}
+
 // according to user request data and stored products data determine how to reserve the products
-// (cpu only computations + greedy algorithm)
+// (cpu only computations, the split between storages is decided by allocator)
 func (s *Service) getReservationsToAdd(
 	req ReserveProductsReq,
+	allocator Allocator,
 	storedDataByProductID map[entity.PK][]*entity.StoredProduct,
 	reservationDataByProductID map[entity.PK][]*entity.ProductReservation,
 ) ([]*entity.ProductReservation, error) {
 	// check if we can reserve the needed amount of products
-	productIDs := algo.Map(req, func(r ReserveProductsReqItem, _ int) entity.PK {
+	productIDs := algo.Map(req.Products, func(r ReserveProductsReqItem, _ int) entity.PK {
 		return entity.PK(r.ID)
 	})
 	unreservedProducts, err := s.getFreeReservations(storedDataByProductID, reservationDataByProductID, productIDs...)
@@ -134,53 +257,51 @@ func (s *Service) getReservationsToAdd(
 		for _, p := range unreservedProducts {
 			unreservedByProductID[p.productID] += p.amount
 		}
-		for _, reqItem := range req {
+		for _, reqItem := range req.Products {
 			pID := entity.PK(reqItem.ID)
-			unreservedAmount, ok := unreservedByProductID[pID]
-			if !ok {
-				return nil, errors.New("unreserved not found by product_id %d (debug)")
-			}
+			// товара может не быть ни на одном доступном складе
+			unreservedAmount := unreservedByProductID[pID]
 			if unreservedAmount < reqItem.Amount {
-				return nil, fmt.Errorf(
+				return nil, errs.Newf(
+					errs.CodeInsufficientStock,
 					"cannot reserve more than %d for product with id %d (tried to reserve %d)",
 					unreservedAmount,
 					reqItem.ID,
 					reqItem.Amount,
-				)
+				).
+					WithDetail("product_id", reqItem.ID).
+					WithDetail("available", unreservedAmount).
+					WithDetail("requested", reqItem.Amount)
 			}
 		}
 	}
 
-	// descending order by free space available for reservation
-	sort.Slice(unreservedProducts, func(i, j int) bool {
-		return unreservedProducts[i].amount > unreservedProducts[j].amount
-	})
-
-	// reserve the products in determined order
-	// something like the greedy algorithm
 	addedReservations := []*entity.ProductReservation{}
-	for _, r := range req {
-		unreserved := algo.Filter(unreservedProducts, func(u *unreservedProduct, _ int) bool {
-			return u.productID == entity.PK(r.ID)
-		})
-		amount := r.Amount
-		for _, u := range unreserved {
-			min := algo.Min(amount, u.amount)
-			amount -= min
-			u.amount -= min
+	for _, r := range req.Products {
+		productID := entity.PK(r.ID)
+		free := algo.Map(
+			algo.Filter(unreservedProducts, func(u *unreservedProduct, _ int) bool {
+				return u.productID == productID
+			}),
+			func(u *unreservedProduct, _ int) FreeStock {
+				return FreeStock{StorageID: u.storageID, Amount: u.amount}
+			},
+		)
+		for _, a := range allocator.Allocate(r.Amount, free) {
 			addedReservations = append(addedReservations, &entity.ProductReservation{
-				ProductID: u.productID,
-				StorageID: u.storageID,
-				Amount:    min,
+				ProductID: productID,
+				StorageID: a.StorageID,
+				Amount:    a.Amount,
 			})
-			if amount == 0 {
-				break
-			}
 		}
 	}
 	return addedReservations, nil
 }
 
+type storageProductKey struct {
+	storageID, productID entity.PK
+}
+
 type unreservedProduct struct {
 	storageID, productID entity.PK
 	amount               uint
@@ -193,10 +314,7 @@ func (s *Service) getFreeReservations(
 ) ([]*unreservedProduct, error) {
 	result := []*unreservedProduct{}
 	for _, productID := range productIDs {
-		stData, ok := storedDataByProductID[productID]
-		if !ok {
-			return nil, errors.New("storage data not found (debug)")
-		}
+		stData := storedDataByProductID[productID]
 		resData := reservationDataByProductID[productID]
 
 		// determine amount which can be reserved for each product by storage id
@@ -217,99 +335,235 @@ func (s *Service) getFreeReservations(
 	return result, nil
 }
 
-func (s *Service) UndoReserve(ctx context.Context, req UndoReservationReq) error {
-	productIDs := algo.Map(req, func(r UndoReservationReqItem, _ int) entity.PK {
-		return entity.PK(r.ID)
+func (s *Service) UndoReserve(ctx context.Context, req UndoReservationReq) (err error) {
+	ctx, span := tracing.Start(ctx, "reservation.UndoReserve")
+	defer tracing.End(span, &err)
+	return s.repo.RunInTransaction(ctx, func(ctx database.TxContext, repo repository.IRepository) error {
+		order, err := repo.LockReservationOrder(ctx, entity.PK(req.ID))
+		if err != nil {
+			return err
+		}
+		if order.Status != entity.ReservationStatusActive {
+			return errs.Newf(errs.CodeConflict, "reservation %d is already %s", order.ID, order.Status).
+				WithDetail("reservation_id", order.ID).
+				WithDetail("status", order.Status)
+		}
+		return s.releaseOrders(ctx, repo, entity.ReservationStatusCancelled, order)
+	})
+}
+
+// списывает зарезервированные заказом остатки со складов и записывает отгрузку
+func (s *Service) ConfirmReservation(ctx context.Context, req ConfirmReservationReq) (_ *ShipmentResp, err error) {
+	ctx, span := tracing.Start(ctx, "reservation.ConfirmReservation")
+	defer tracing.End(span, &err)
+	var result *ShipmentResp
+	err = s.repo.RunInTransaction(ctx, func(ctx database.TxContext, repo repository.IRepository) error {
+		order, err := repo.LockReservationOrder(ctx, entity.PK(req.ID))
+		if err != nil {
+			return err
+		}
+		if order.Status != entity.ReservationStatusActive {
+			return errs.Newf(errs.CodeConflict, "reservation %d is already %s", order.ID, order.Status).
+				WithDetail("reservation_id", order.ID).
+				WithDetail("status", order.Status)
+		}
+		// истекший заказ, который reaper еще не снял, подтвердить нельзя
+		if order.ExpiresAt != nil && !order.ExpiresAt.After(time.Now()) {
+			return errs.Newf(errs.CodeConflict, "reservation %d is already %s", order.ID, entity.ReservationStatusExpired).
+				WithDetail("reservation_id", order.ID).
+				WithDetail("status", entity.ReservationStatusExpired)
+		}
+		items, err := repo.GetReservationOrderItems(ctx, order.ID)
+		if err != nil {
+			return err
+		}
+		// резерв снимается так же, как при отмене, остатки блокируются там же
+		if err := s.releaseOrders(ctx, repo, entity.ReservationStatusConfirmed, order); err != nil {
+			return err
+		}
+		if err := s.withdrawStock(ctx, repo, items); err != nil {
+			return err
+		}
+
+		shipments, err := repo.CreateShipment(ctx, &entity.Shipment{ReservationID: order.ID})
+		if err != nil {
+			return err
+		}
+		shipment := shipments[0]
+		shipmentItems := algo.Map(items, func(i *entity.ReservationOrderItem, _ int) *entity.ShipmentItem {
+			return &entity.ShipmentItem{
+				ShipmentID: shipment.ID,
+				StorageID:  i.StorageID,
+				ProductID:  i.ProductID,
+				Amount:     i.Amount,
+			}
+		})
+		if len(shipmentItems) > 0 {
+			if shipmentItems, err = repo.CreateShipmentItem(ctx, shipmentItems...); err != nil {
+				return err
+			}
+		}
+		result = newShipmentResp(shipment, shipmentItems)
+		return nil
 	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
 
-	stResData, err := s.getStorageDataWithReservation(ctx, productIDs...)
+// уменьшает stored_products.amount на количества позиций заказа
+func (s *Service) withdrawStock(ctx database.TxContext, repo repository.IRepository, items []*entity.ReservationOrderItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+	productIDs := algo.Map(
+		algo.UniqBy(items, func(i *entity.ReservationOrderItem) entity.PK {
+			return i.ProductID
+		}),
+		func(i *entity.ReservationOrderItem, _ int) entity.PK {
+			return i.ProductID
+		},
+	)
+	storedData, err := repo.LockStorageDataByProduct(ctx, productIDs...)
 	if err != nil {
 		return err
 	}
+	updated := make([]*entity.StoredProduct, 0, len(items))
+	for _, i := range items {
+		st, ok := algo.Find(storedData, func(st *entity.StoredProduct) bool {
+			return st.StorageID == i.StorageID && st.ProductID == i.ProductID
+		})
+		if !ok {
+			return errs.Newf(errs.CodeInsufficientStock, "product %d is not stored in storage %d", i.ProductID, i.StorageID).
+				WithDetail("storage_id", i.StorageID).
+				WithDetail("product_id", i.ProductID).
+				WithDetail("available", 0).
+				WithDetail("requested", i.Amount)
+		}
+		if st.Amount < i.Amount {
+			return errs.Newf(
+				errs.CodeInsufficientStock,
+				"cannot withdraw %d of product %d from storage %d (stored %d)",
+				i.Amount,
+				i.ProductID,
+				i.StorageID,
+				st.Amount,
+			).
+				WithDetail("storage_id", i.StorageID).
+				WithDetail("product_id", i.ProductID).
+				WithDetail("available", st.Amount).
+				WithDetail("requested", i.Amount)
+		}
+		// позиции заказа уникальны по складу и товару, строка обновляется один раз
+		e := *st
+		e.Amount -= i.Amount
+		updated = append(updated, &e)
+	}
+	_, err = repo.UpdateStorageData(ctx, updated...)
+	return err
+}
 
-	freedReservations, err := s.getReservationsToFree(req, stResData.storeData, stResData.reservations)
+// снимает резервы заказов из агрегата product_reservations и переводит заказы в status.
+// заказы должны быть заблокированы в текущей транзакции
+func (s *Service) releaseOrders(
+	ctx database.TxContext,
+	repo repository.IRepository,
+	status string,
+	orders ...*entity.ReservationOrder,
+) error {
+	orderIDs := algo.Map(orders, func(o *entity.ReservationOrder, _ int) entity.PK {
+		return o.ID
+	})
+	items, err := repo.GetReservationOrderItems(ctx, orderIDs...)
 	if err != nil {
 		return err
 	}
+	if len(items) > 0 {
+		productIDs := algo.Map(
+			algo.UniqBy(items, func(i *entity.ReservationOrderItem) entity.PK {
+				return i.ProductID
+			}),
+			func(i *entity.ReservationOrderItem, _ int) entity.PK {
+				return i.ProductID
+			},
+		)
+		// блокировка та же, что при резерве, иначе агрегат может потерять обновление
+		if _, err := repo.LockStorageDataByProduct(ctx, productIDs...); err != nil {
+			return err
+		}
+		reservations, err := repo.GetReservationByProduct(ctx, productIDs...)
+		if err != nil {
+			return err
+		}
+		if err := s.subtractFromAggregate(ctx, repo, reservations, items); err != nil {
+			return err
+		}
+	}
+	for _, o := range orders {
+		o.Status = status
+	}
+	_, err = repo.UpdateReservationOrder(ctx, orders...)
+	return err
+}
+
+func (s *Service) subtractFromAggregate(
+	ctx database.TxContext,
+	repo repository.IRepository,
+	reservations []*entity.ProductReservation,
+	items []*entity.ReservationOrderItem,
+) error {
+	freedByKey := map[storageProductKey]uint{}
+	for _, i := range items {
+		freedByKey[storageProductKey{i.StorageID, i.ProductID}] += i.Amount
+	}
 
 	updated := []*entity.ProductReservation{}
 	deletedIDs := []entity.PK{}
-	for _, freedRes := range freedReservations {
-		reservations, ok := stResData.reservations[freedRes.ProductID]
+	for _, res := range reservations {
+		freed, ok := freedByKey[storageProductKey{res.StorageID, res.ProductID}]
 		if !ok {
-			return errors.New("UndoReserve: reservation not found (debug)")
+			continue
 		}
-		res, ok := algo.Find(reservations, func(st *entity.ProductReservation) bool {
-			return st.StorageID == freedRes.StorageID
-		})
-		if !ok {
-			return errors.New("UndoReserve: reservation not found by storage id (debug)")
-		}
-		if res.Amount != freedRes.Amount {
+		if res.Amount > freed {
 			e := *res
-			e.Amount -= freedRes.Amount
+			e.Amount -= freed
 			updated = append(updated, &e)
 		} else {
 			deletedIDs = append(deletedIDs, res.ID)
 		}
 	}
 
-	return s.repo.RunInTransaction(ctx, func(ctx database.TxContext, repo repository.IRepository) error {
+	if len(updated) > 0 {
 		if _, err := repo.UpdateReservation(ctx, updated...); err != nil {
 			return err
 		}
-		return repo.DeleteReservation(ctx, deletedIDs...)
-	})
-}
-
-// according to user request data and stored products data determine how to undo the reservation of products
-// (cpu only computations + greedy algorithm)
-func (s *Service) getReservationsToFree(
-	req UndoReservationReq,
-	storedDataByProductID map[entity.PK][]*entity.StoredProduct,
-	reservationDataByProductID map[entity.PK][]*entity.ProductReservation,
-) ([]*entity.ProductReservation, error) {
-	productIDs := algo.Map(req, func(r UndoReservationReqItem, _ int) entity.PK {
-		return entity.PK(r.ID)
-	})
-	unreservedProducts, err := s.getFreeReservations(storedDataByProductID, reservationDataByProductID, productIDs...)
-	if err != nil {
-		return nil, err
 	}
+	if len(deletedIDs) == 0 {
+		return nil
+	}
+	return repo.DeleteReservation(ctx, deletedIDs...)
+}
 
-	sort.Slice(unreservedProducts, func(i, j int) bool {
-		return unreservedProducts[i].amount < unreservedProducts[j].amount
-	})
-
-	freedReservations := []*entity.ProductReservation{}
-	for _, r := range req {
-		unreserved := algo.Filter(unreservedProducts, func(u *unreservedProduct, _ int) bool {
-			return u.productID == entity.PK(r.ID)
-		})
-		amount := r.Amount
-		storeData, ok := storedDataByProductID[entity.PK(r.ID)]
-		if !ok {
-			return nil, errors.New("getReservationsToFree: reservation not found (debug)")
+// снимает до limit истекших заказов, возвращает количество снятых.
+// резервы снимаются той же логикой, что и UndoReserve
+func (s *Service) ReleaseExpiredReservations(ctx context.Context, now time.Time, limit uint) (_ int, err error) {
+	ctx, span := tracing.Start(ctx, "reservation.ReleaseExpiredReservations")
+	defer tracing.End(span, &err)
+	var released int
+	err = s.repo.RunInTransaction(ctx, func(ctx database.TxContext, repo repository.IRepository) error {
+		orders, err := repo.LockExpiredReservationOrders(ctx, now, limit)
+		if err != nil {
+			return err
 		}
-		for _, u := range unreserved {
-			st, ok := algo.Find(storeData, func(r *entity.StoredProduct) bool {
-				return r.StorageID == u.storageID
-			})
-			if !ok {
-				return nil, errors.New("getReservationsToFree: reservation by storage id not found (debug)")
-			}
-			min := algo.Min(amount, st.Amount-u.amount)
-			amount -= min
-			u.amount += min
-			freedReservations = append(freedReservations, &entity.ProductReservation{
-				ProductID: u.productID,
-				StorageID: u.storageID,
-				Amount:    min,
-			})
-			if amount == 0 {
-				break
-			}
+		if len(orders) == 0 {
+			return nil
 		}
+		released = len(orders)
+		return s.releaseOrders(ctx, repo, entity.ReservationStatusExpired, orders...)
+	})
+	if err != nil {
+		return 0, err
 	}
-	return freedReservations, nil
+	return released, nil
 }
@@ -0,0 +1,350 @@
+package reservation
+
+import (
+	"context"
+	"errors"
+	"storageapi/internal/database"
+	"storageapi/internal/entity"
+	"storageapi/internal/repository"
+	"storageapi/internal/repository/memory"
+	"storageapi/internal/test/testdb"
+	"storageapi/pkg/errs"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// параллельные клиенты не должны зарезервировать больше, чем лежит на складах
+func TestReserveProductsNoOversell(t *testing.T) {
+	db, err := database.NewDBWithPgx(testdb.New(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	log := zap.NewNop().Sugar()
+	repo := repository.NewRepository(db, log)
+	ctx := context.Background()
+
+	const (
+		storagesCount = 3
+		perStorage    = 10
+		clients       = 16
+		perClient     = 5
+	)
+	var productID entity.PK
+	err = repo.RunInTransaction(ctx, func(ctx database.TxContext, repo repository.IRepository) error {
+		storages := make([]*entity.Storage, 0, storagesCount)
+		for i := 0; i < storagesCount; i++ {
+			storages = append(storages, &entity.Storage{IsAvailable: true})
+		}
+		storages, err := repo.CreateStorage(ctx, storages...)
+		if err != nil {
+			return err
+		}
+		products, err := repo.CreateProduct(ctx, &entity.Product{Name: "stress", Vendor: "stress-1", Size: "m"})
+		if err != nil {
+			return err
+		}
+		productID = products[0].ID
+		data := make([]*entity.StoredProduct, 0, len(storages))
+		for _, s := range storages {
+			data = append(data, &entity.StoredProduct{StorageID: s.ID, ProductID: productID, Amount: perStorage})
+		}
+		_, err = repo.CreateStorageData(ctx, data...)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	service := NewService(repo, log, ServiceConf{DefaultStrategy: StrategyLargestFirst})
+	var (
+		reserved uint64
+		wg       sync.WaitGroup
+	)
+	for i := 0; i < clients; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < perClient; j++ {
+				_, err := service.ReserveProducts(ctx, ReserveProductsReq{
+					Products: []ReserveProductsReqItem{{ID: productID.ToUint(), Amount: 1}},
+				})
+				if err == nil {
+					atomic.AddUint64(&reserved, 1)
+					continue
+				}
+				if errs.CodeOf(err) != errs.CodeInsufficientStock {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	// спрос больше остатка, поэтому должен быть зарезервирован весь остаток и не больше
+	if reserved != storagesCount*perStorage {
+		t.Errorf("reserved %d units, want %d", reserved, storagesCount*perStorage)
+	}
+	reservations, err := repo.GetReservationByProduct(ctx, productID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var total uint
+	for _, r := range reservations {
+		if r.Amount > perStorage {
+			t.Errorf("storage %d: reserved %d, stored %d", r.StorageID, r.Amount, perStorage)
+		}
+		total += r.Amount
+	}
+	if uint64(total) != reserved {
+		t.Errorf("product_reservations total %d, successful reservations %d", total, reserved)
+	}
+}
+
+// сервис поверх хранилища в памяти и один товар, по amounts[i] на складе storages[i]
+func newMemoryService(t *testing.T, amounts ...uint) (*Service, repository.IRepository, []entity.PK, entity.PK) {
+	t.Helper()
+	repo := memory.NewRepository()
+	ctx := context.Background()
+	storages := make([]*entity.Storage, 0, len(amounts))
+	for range amounts {
+		storages = append(storages, &entity.Storage{IsAvailable: true})
+	}
+	storages, err := repo.CreateStorage(ctx, storages...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	products, err := repo.CreateProduct(ctx, &entity.Product{Name: "shirt", Vendor: "shirt-1", Size: "m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	storageIDs := make([]entity.PK, 0, len(storages))
+	for i, st := range storages {
+		storageIDs = append(storageIDs, st.ID)
+		if _, err := repo.CreateStorageData(ctx, &entity.StoredProduct{
+			StorageID: st.ID,
+			ProductID: products[0].ID,
+			Amount:    amounts[i],
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	service := NewService(repo, zap.NewNop().Sugar(), ServiceConf{DefaultStrategy: StrategyLargestFirst})
+	return service, repo, storageIDs, products[0].ID
+}
+
+// сколько товара зарезервировано по product_reservations
+func reservedAmount(t *testing.T, repo repository.IRepository, productID entity.PK) uint {
+	t.Helper()
+	reservations, err := repo.GetReservationByProduct(context.Background(), productID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var total uint
+	for _, r := range reservations {
+		total += r.Amount
+	}
+	return total
+}
+
+func TestReservationOrder(t *testing.T) {
+	service, repo, storages, productID := newMemoryService(t, 3, 2)
+	ctx := context.Background()
+
+	created, err := service.ReserveProducts(ctx, ReserveProductsReq{
+		Products: []ReserveProductsReqItem{{ID: productID.ToUint(), Amount: 4}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.ID == 0 || created.Status != entity.ReservationStatusActive || created.ExpiresAt != nil {
+		t.Fatalf("unexpected order: %+v", created)
+	}
+	// largest_first берет сначала со склада, где товара больше
+	want := map[uint]uint{storages[0].ToUint(): 3, storages[1].ToUint(): 1}
+	if len(created.Items) != len(want) {
+		t.Fatalf("expected items %v, got %+v", want, created.Items)
+	}
+	for _, i := range created.Items {
+		if i.ProductID != productID.ToUint() || want[i.StorageID] != i.Amount {
+			t.Fatalf("expected items %v, got %+v", want, created.Items)
+		}
+	}
+	if got := reservedAmount(t, repo, productID); got != 4 {
+		t.Fatalf("expected 4 reserved, got %d", got)
+	}
+
+	got, err := service.GetReservation(ctx, GetReservationReq{ID: created.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ID != created.ID || got.Status != created.Status || !got.CreatedAt.Equal(created.CreatedAt) || len(got.Items) != len(created.Items) {
+		t.Fatalf("GetReservation: got %+v, want %+v", got, created)
+	}
+
+	if err := service.UndoReserve(ctx, UndoReservationReq{ID: created.ID}); err != nil {
+		t.Fatal(err)
+	}
+	if got := reservedAmount(t, repo, productID); got != 0 {
+		t.Fatalf("undo must release the reservation, %d reserved", got)
+	}
+	got, err = service.GetReservation(ctx, GetReservationReq{ID: created.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// позиции остаются в заказе для истории
+	if got.Status != entity.ReservationStatusCancelled || len(got.Items) != len(created.Items) {
+		t.Fatalf("expected cancelled order with items, got %+v", got)
+	}
+
+	// повторная отмена не снимает резерв второй раз
+	err = service.UndoReserve(ctx, UndoReservationReq{ID: created.ID})
+	if errs.CodeOf(err) != errs.CodeConflict {
+		t.Fatalf("expected conflict for cancelled order, got %v", err)
+	}
+	if got := reservedAmount(t, repo, productID); got != 0 {
+		t.Fatalf("repeated undo changed reservations: %d reserved", got)
+	}
+
+	if _, err := service.GetReservation(ctx, GetReservationReq{ID: created.ID + 100}); errs.CodeOf(err) != errs.CodeNotFound {
+		t.Fatalf("expected not found, got %v", err)
+	}
+	if err := service.UndoReserve(ctx, UndoReservationReq{ID: created.ID + 100}); errs.CodeOf(err) != errs.CodeNotFound {
+		t.Fatalf("expected not found, got %v", err)
+	}
+}
+
+// отмена одного заказа не трогает резерв другого на тех же складах
+func TestUndoReserveKeepsOtherOrders(t *testing.T) {
+	service, repo, _, productID := newMemoryService(t, 5)
+	ctx := context.Background()
+	req := ReserveProductsReq{Products: []ReserveProductsReqItem{{ID: productID.ToUint(), Amount: 2}}}
+	first, err := service.ReserveProducts(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := service.ReserveProducts(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := service.UndoReserve(ctx, UndoReservationReq{ID: first.ID}); err != nil {
+		t.Fatal(err)
+	}
+	if got := reservedAmount(t, repo, productID); got != 2 {
+		t.Fatalf("expected second order to keep 2 reserved, got %d", got)
+	}
+	got, err := service.GetReservation(ctx, GetReservationReq{ID: second.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != entity.ReservationStatusActive {
+		t.Fatalf("expected second order to stay active, got %+v", got)
+	}
+}
+
+// сколько товара лежит на складе по stored_products
+func storedAmount(t *testing.T, repo repository.IRepository, storageID, productID entity.PK) uint {
+	t.Helper()
+	stored, err := repo.GetStorageDataByProduct(context.Background(), productID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, st := range stored {
+		if st.StorageID == storageID {
+			return st.Amount
+		}
+	}
+	t.Fatalf("product %d is not stored in storage %d", productID, storageID)
+	return 0
+}
+
+func TestConfirmReservation(t *testing.T) {
+	service, repo, storages, productID := newMemoryService(t, 3, 2)
+	ctx := context.Background()
+	created, err := service.ReserveProducts(ctx, ReserveProductsReq{
+		Products: []ReserveProductsReqItem{{ID: productID.ToUint(), Amount: 4}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	shipment, err := service.ConfirmReservation(ctx, ConfirmReservationReq{ID: created.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// отгрузка повторяет позиции заказа
+	want := map[uint]uint{storages[0].ToUint(): 3, storages[1].ToUint(): 1}
+	if shipment.ID == 0 || shipment.ReservationID != created.ID || len(shipment.Items) != len(want) {
+		t.Fatalf("unexpected shipment %+v", shipment)
+	}
+	for _, i := range shipment.Items {
+		if i.ProductID != productID.ToUint() || want[i.StorageID] != i.Amount {
+			t.Fatalf("expected shipment items %v, got %+v", want, shipment.Items)
+		}
+	}
+	// остатки списаны, резерв снят
+	if got := storedAmount(t, repo, storages[0], productID); got != 0 {
+		t.Fatalf("expected 0 left in storage %d, got %d", storages[0], got)
+	}
+	if got := storedAmount(t, repo, storages[1], productID); got != 1 {
+		t.Fatalf("expected 1 left in storage %d, got %d", storages[1], got)
+	}
+	if got := reservedAmount(t, repo, productID); got != 0 {
+		t.Fatalf("confirm must release the reservation, %d reserved", got)
+	}
+	got, err := service.GetReservation(ctx, GetReservationReq{ID: created.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != entity.ReservationStatusConfirmed {
+		t.Fatalf("expected confirmed order, got %+v", got)
+	}
+
+	// подтвержденный заказ нельзя ни подтвердить повторно, ни отменить
+	if _, err := service.ConfirmReservation(ctx, ConfirmReservationReq{ID: created.ID}); errs.CodeOf(err) != errs.CodeConflict {
+		t.Fatalf("expected conflict for confirmed order, got %v", err)
+	}
+	if err := service.UndoReserve(ctx, UndoReservationReq{ID: created.ID}); errs.CodeOf(err) != errs.CodeConflict {
+		t.Fatalf("expected conflict for confirmed order, got %v", err)
+	}
+	if got := storedAmount(t, repo, storages[1], productID); got != 1 {
+		t.Fatalf("repeated confirm changed stock: %d left", got)
+	}
+	if _, err := service.ConfirmReservation(ctx, ConfirmReservationReq{ID: created.ID + 100}); errs.CodeOf(err) != errs.CodeNotFound {
+		t.Fatalf("expected not found, got %v", err)
+	}
+}
+
+// заказ с прошедшим expires_at нельзя подтвердить, даже если reaper его еще не снял
+func TestConfirmExpiredReservation(t *testing.T) {
+	service, repo, storages, productID := newMemoryService(t, 5)
+	ctx := context.Background()
+	id := createExpiredOrder(t, repo, storages[0], productID, 2, time.Now().Add(-time.Minute))
+	if _, err := repo.CreateReservation(ctx, &entity.ProductReservation{
+		StorageID: storages[0],
+		ProductID: productID,
+		Amount:    2,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := service.ConfirmReservation(ctx, ConfirmReservationReq{ID: id.ToUint()})
+	if errs.CodeOf(err) != errs.CodeConflict {
+		t.Fatalf("expected conflict for expired order, got %v", err)
+	}
+	var e *errs.Error
+	if !errors.As(err, &e) || e.Details["status"] != entity.ReservationStatusExpired {
+		t.Fatalf("expected expired status in details, got %v", err)
+	}
+	// ни остатки, ни резерв не изменились
+	if got := storedAmount(t, repo, storages[0], productID); got != 5 {
+		t.Fatalf("expired confirm withdrew stock: %d left", got)
+	}
+	if got := reservedAmount(t, repo, productID); got != 2 {
+		t.Fatalf("expired confirm changed reservations: %d reserved", got)
+	}
+}
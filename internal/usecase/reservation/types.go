@@ -1,45 +1,50 @@
 package reservation
 
 import (
-	"errors"
+	"storageapi/internal/entity"
+	"storageapi/pkg/algo"
+	"storageapi/pkg/model"
 )
 
-// create reservation request
-
-type ReserveProductsReq []ReserveProductsReqItem
+// типы запросов и ответов публичные, их использует и клиент pkg/client
+type (
+	ReserveProductsReq     = model.ReserveProductsReq
+	ReserveProductsReqItem = model.ReserveProductsReqItem
+	ReservationResp        = model.ReservationResp
+	ReservationRespItem    = model.ReservationRespItem
+	GetReservationReq      = model.GetReservationReq
+	UndoReservationReq     = model.UndoReservationReq
+	ConfirmReservationReq  = model.ConfirmReservationReq
+	ShipmentResp           = model.ShipmentResp
+)
 
-func (req ReserveProductsReq) Validate() error {
-	for _, r := range req {
-		if err := r.Validate(); err != nil {
-			return err
-		}
+func newReservationResp(order *entity.ReservationOrder, items []*entity.ReservationOrderItem) *ReservationResp {
+	return &ReservationResp{
+		ID:        order.ID.ToUint(),
+		Status:    order.Status,
+		CreatedAt: order.CreatedAt,
+		ExpiresAt: order.ExpiresAt,
+		Items: algo.Map(items, func(i *entity.ReservationOrderItem, _ int) ReservationRespItem {
+			return ReservationRespItem{
+				StorageID: i.StorageID.ToUint(),
+				ProductID: i.ProductID.ToUint(),
+				Amount:    i.Amount,
+			}
+		}),
 	}
-	{
-		m := map[uint]struct{}{}
-		for _, r := range req {
-			m[r.ID] = struct{}{}
-		}
-		if len(m) != len(req) {
-			return errors.New("all the product ids must be unique")
-		}
-	}
-	return nil
-}
-
-type ReserveProductsReqItem struct {
-	ID     uint `json:"id"`
-	Amount uint `json:"amount"`
 }
 
-func (req ReserveProductsReqItem) Validate() error {
-	if req.Amount == 0 {
-		return errors.New("amount for product reservation cannot be nil")
+func newShipmentResp(shipment *entity.Shipment, items []*entity.ShipmentItem) *ShipmentResp {
+	return &ShipmentResp{
+		ID:            shipment.ID.ToUint(),
+		ReservationID: shipment.ReservationID.ToUint(),
+		CreatedAt:     shipment.CreatedAt,
+		Items: algo.Map(items, func(i *entity.ShipmentItem, _ int) ReservationRespItem {
+			return ReservationRespItem{
+				StorageID: i.StorageID.ToUint(),
+				ProductID: i.ProductID.ToUint(),
+				Amount:    i.Amount,
+			}
+		}),
 	}
-	return nil
 }
-
-// undo reservation
-
-type UndoReservationReq []UndoReservationReqItem
-
-type UndoReservationReqItem ReserveProductsReqItem
@@ -1,7 +1,24 @@
 package storage
 
-import "storageapi/internal/repository"
+import (
+	"storageapi/internal/database"
+	"storageapi/internal/entity"
+	"storageapi/internal/repository"
+	"storageapi/internal/usecase/reservation"
+)
 
 type Repository interface {
 	repository.IRepoMixin
 }
+
+// переносит резервы с выключаемого склада, реализуется сервисом резервов
+type Evacuator interface {
+	EvacuateStorage(
+		ctx database.TxContext,
+		repo repository.IRepository,
+		storageID entity.PK,
+		strategy string,
+	) (*reservation.EvacuationResult, error)
+}
+
+var _ Evacuator = (*reservation.Service)(nil)
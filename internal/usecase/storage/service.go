@@ -6,122 +6,237 @@ import (
 	"storageapi/internal/database"
 	"storageapi/internal/entity"
 	"storageapi/internal/repository"
+	"storageapi/internal/tracing"
+	"storageapi/internal/usecase/idempotency"
+	"storageapi/internal/usecase/reservation"
 	"storageapi/pkg/algo"
+	"storageapi/pkg/errs"
 	"storageapi/pkg/stack"
 
 	"go.uber.org/zap"
 )
 
 type Service struct {
-	repo Repository
-	log  *zap.SugaredLogger
+	repo      Repository
+	log       *zap.SugaredLogger
+	evacuator Evacuator
 }
 
-func NewService(r repository.IRepository, log *zap.SugaredLogger) *Service {
+func NewService(r repository.IRepository, log *zap.SugaredLogger, evacuator Evacuator) *Service {
 	return &Service{
-		repo: r,
-		log:  log,
+		repo:      r,
+		log:       log,
+		evacuator: evacuator,
 	}
 }
 
-func (s *Service) DefineStorageSchema(ctx context.Context, req StorageSchemaReq) (StorageSchemaResp, error) {
+func (s *Service) DefineStorageSchema(ctx context.Context, req StorageSchemaReq) (_ StorageSchemaResp, err error) {
+	ctx, span := tracing.Start(ctx, "storage.DefineStorageSchema")
+	defer tracing.End(span, &err)
 	var result StorageSchemaResp
-	err := s.repo.RunInTransaction(ctx, func(ctx database.TxContext, repo repository.IRepository) error {
-		// create all storages
-		storages := algo.Map(req, func(r StorageSchemaReqItem, _ int) *entity.Storage {
-			return &entity.Storage{
-				IsAvailable: r.IsAvailable,
+	err = s.repo.RunInTransaction(ctx, func(ctx database.TxContext, repo repository.IRepository) (err error) {
+		result, err = idempotency.Do(ctx, repo, "Storage.DefineStorageSchema", req.IdempotencyKey, req, func() (StorageSchemaResp, error) {
+			return s.defineStorageSchema(ctx, repo, req)
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *Service) defineStorageSchema(ctx database.TxContext, repo repository.IRepository, req StorageSchemaReq) (StorageSchemaResp, error) {
+	var result StorageSchemaResp
+	// create all storages
+	storages := algo.Map(req.Storages, func(r StorageSchemaReqItem, _ int) *entity.Storage {
+		return &entity.Storage{
+			IsAvailable: r.IsAvailable,
+		}
+	})
+	storages, err := repo.CreateStorage(ctx, storages...)
+	if err != nil {
+		return nil, err
+	}
+	// insert all products
+	products := algo.FlatMap(req.Storages, func(r StorageSchemaReqItem, _ int) []*entity.Product {
+		return algo.Map(r.Products, func(p StorageSchemaReqProduct, _ int) *entity.Product {
+			return &entity.Product{
+				Name:   p.Name,
+				Vendor: p.Vendor,
+				Size:   p.Size,
 			}
 		})
-		storages, err := repo.CreateStorage(ctx, storages...)
-		if err != nil {
-			return err
+	})
+	products, err = repo.CreateProduct(ctx, products...)
+	if err != nil {
+		return nil, err
+	}
+	// create relations
+	productsByVendor := map[string]*entity.Product{}
+	for _, p := range products {
+		productsByVendor[p.Vendor] = p
+	}
+	available, notAvailable := stack.New[*entity.Storage](), stack.New[*entity.Storage]()
+	for _, s := range storages {
+		if s.IsAvailable {
+			available.Push(s)
+			continue
+		}
+		notAvailable.Push(s)
+	}
+	relations := make([]*entity.StoredProduct, 0, len(products))
+	for _, r := range req.Storages {
+		var store *entity.Storage
+		if r.IsAvailable {
+			store, _ = available.Pop()
+		} else {
+			store, _ = notAvailable.Pop()
+		}
+		storeResp := StorageSchemaRespItem{
+			ID:          store.ID.ToUint(),
+			IsAvailable: r.IsAvailable,
 		}
-		// insert all products
-		products := algo.FlatMap(req, func(r StorageSchemaReqItem, _ int) []*entity.Product {
-			return algo.Map(r.Products, func(p StorageSchemaReqProduct, _ int) *entity.Product {
-				return &entity.Product{
-					Name:   p.Name,
-					Vendor: p.Vendor,
-					Size:   p.Size,
-				}
+		for _, p := range r.Products {
+			product, ok := productsByVendor[p.Vendor]
+			if !ok {
+				return nil, errors.New("not found product by vendor (debug)")
+			}
+			relations = append(relations, &entity.StoredProduct{
+				StorageID: store.ID,
+				ProductID: product.ID,
+				Amount:    p.Amount,
 			})
+			storeResp.Products = append(storeResp.Products, StorageSchemaRespProduct{
+				ID:     product.ID.ToUint(),
+				Name:   p.Name,
+				Vendor: p.Vendor,
+				Size:   p.Size,
+				Amount: p.Amount,
+			})
+		}
+		result = append(result, storeResp)
+	}
+	if _, err = repo.CreateStorageData(ctx, relations...); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *Service) GetStorageSchema(ctx context.Context, req GetStorageSchemaReq) (_ GetStorageSchemaResp, err error) {
+	ctx, span := tracing.Start(ctx, "storage.GetStorageSchema")
+	defer tracing.End(span, &err)
+	var (
+		storages     []*entity.Storage
+		reservations []*entity.ProductReservation
+		storageData  []*entity.StoredProduct
+		productData  []*entity.Product
+	)
+	err = s.repo.RunInTransaction(ctx, func(ctx database.TxContext, repo repository.IRepository) (err error) {
+		storages, err = repo.ListStorages(ctx, &repository.ListStorageFilter{
+			IDs: algo.Map(req.IDs, func(id uint, _ int) entity.PK {
+				return entity.PK(id)
+			}),
+			IsAvailable: req.IsAvailable,
+			Limit:       req.Limit,
+			Offset:      req.Offset,
 		})
-		products, err = repo.CreateProduct(ctx, products...)
 		if err != nil {
 			return err
 		}
-		// create relations
-		productsByVendor := map[string]*entity.Product{}
-		for _, p := range products {
-			productsByVendor[p.Vendor] = p
+		if len(storages) == 0 {
+			return nil
 		}
-		available, notAvailable := stack.New[*entity.Storage](), stack.New[*entity.Storage]()
-		for _, s := range storages {
-			if s.IsAvailable {
-				available.Push(s)
-				continue
-			}
-			notAvailable.Push(s)
-		}
-		relations := make([]*entity.StoredProduct, 0, len(products))
-		for _, r := range req {
-			var store *entity.Storage
-			if r.IsAvailable {
-				store, _ = available.Pop()
-			} else {
-				store, _ = notAvailable.Pop()
-			}
-			storeResp := StorageSchemaRespItem{
-				ID:          store.ID.ToUint(),
-				IsAvailable: r.IsAvailable,
-			}
-			for _, p := range r.Products {
-				product, ok := productsByVendor[p.Vendor]
-				if !ok {
-					return errors.New("not found product by vendor (debug)")
-				}
-				relations = append(relations, &entity.StoredProduct{
-					StorageID: store.ID,
-					ProductID: product.ID,
-					Amount:    p.Amount,
-				})
-				storeResp.Products = append(storeResp.Products, StorageSchemaRespProduct{
-					ID:     product.ID.ToUint(),
-					Name:   p.Name,
-					Vendor: p.Vendor,
-					Size:   p.Size,
-					Amount: p.Amount,
-				})
-			}
-			result = append(result, storeResp)
+		storageIDs := algo.Map(storages, func(s *entity.Storage, _ int) entity.PK {
+			return s.ID
+		})
+		if storageData, err = repo.GetStorageDataByStorage(ctx, storageIDs...); err != nil {
+			return err
 		}
-		_, err = repo.CreateStorageData(ctx, relations...)
+		if reservations, err = repo.GetReservationByStorage(ctx, storageIDs...); err != nil {
+			return err
+		}
+		productIDs := algo.Map(
+			algo.UniqBy(
+				storageData,
+				func(sd *entity.StoredProduct) entity.PK {
+					return sd.ProductID
+				},
+			),
+			func(sd *entity.StoredProduct, _ int) entity.PK {
+				return sd.ProductID
+			})
+		if len(productIDs) == 0 {
+			return nil
+		}
+		productData, err = repo.GetProducts(ctx, productIDs...)
 		return err
 	})
 	if err != nil {
 		return nil, err
 	}
-	return result, nil
-}
 
-func (s *Service) GetStorageSchema(ctx context.Context) (StorageSchemaResp, error) {
-	return nil, errors.New("not implemented")
+	type storageProductKey struct {
+		storageID, productID entity.PK
+	}
+	reservedByKey := map[storageProductKey]uint{}
+	for _, r := range reservations {
+		reservedByKey[storageProductKey{r.StorageID, r.ProductID}] += r.Amount
+	}
+	productByID := map[entity.PK]*entity.Product{}
+	for _, p := range productData {
+		productByID[p.ID] = p
+	}
+	storageDataByStorageID := map[entity.PK][]*entity.StoredProduct{}
+	for _, st := range storageData {
+		storageDataByStorageID[st.StorageID] = append(storageDataByStorageID[st.StorageID], st)
+	}
+
+	result := make(GetStorageSchemaResp, 0, len(storages))
+	for _, storage := range storages {
+		item := GetStorageSchemaRespItem{
+			ID:          storage.ID.ToUint(),
+			IsAvailable: storage.IsAvailable,
+			Products:    []GetStorageSchemaRespProduct{},
+		}
+		for _, st := range storageDataByStorageID[storage.ID] {
+			product, ok := productByID[st.ProductID]
+			if !ok {
+				return nil, errors.New("product by id not found (debug)")
+			}
+			reserved := reservedByKey[storageProductKey{st.StorageID, st.ProductID}]
+			item.Products = append(item.Products, GetStorageSchemaRespProduct{
+				StorageSchemaRespProduct: StorageSchemaRespProduct{
+					ID:     product.ID.ToUint(),
+					Name:   product.Name,
+					Vendor: product.Vendor,
+					Size:   product.Size,
+					Amount: st.Amount,
+				},
+				Reserved: reserved,
+				Free:     st.Amount - algo.Min(reserved, st.Amount),
+			})
+		}
+		result = append(result, item)
+	}
+	return result, nil
 }
 
-func (s *Service) GetUnreservedStorage(ctx context.Context, storageID entity.PK) (*StorageSchemaRespItem, error) {
+func (s *Service) GetUnreservedStorage(ctx context.Context, storageID entity.PK) (_ *StorageSchemaRespItem, err error) {
+	ctx, span := tracing.Start(ctx, "storage.GetUnreservedStorage")
+	defer tracing.End(span, &err)
 	var (
 		reservations []*entity.ProductReservation
 		storageData  []*entity.StoredProduct
 		productData  []*entity.Product
 	)
-	err := s.repo.RunInTransaction(ctx, func(ctx database.TxContext, repo repository.IRepository) error {
+	err = s.repo.RunInTransaction(ctx, func(ctx database.TxContext, repo repository.IRepository) error {
 		storage, err := repo.GetStorage(ctx, storageID)
 		if err != nil {
 			return err
 		}
 		if !storage.IsAvailable {
-			return errors.New("storage is not available")
+			return errs.New(errs.CodeUnavailable, "storage is not available").WithDetail("storage_id", storageID)
 		}
 		if storageData, err = repo.GetStorageDataByStorage(ctx, storageID); err != nil {
 			return err
@@ -186,3 +301,282 @@ func (s *Service) GetUnreservedStorage(ctx context.Context, storageID entity.PK)
 	}
 	return result, nil
 }
+
+func (s *Service) ReceiveStock(ctx context.Context, req ReceiveStockReq) (_ *StockResp, err error) {
+	ctx, span := tracing.Start(ctx, "storage.ReceiveStock")
+	defer tracing.End(span, &err)
+	var result *StockResp
+	err = s.repo.RunInTransaction(ctx, func(ctx database.TxContext, repo repository.IRepository) (err error) {
+		result, err = idempotency.Do(ctx, repo, "Storage.ReceiveStock", req.IdempotencyKey, req, func() (*StockResp, error) {
+			return s.receiveStock(ctx, repo, req)
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *Service) receiveStock(ctx database.TxContext, repo repository.IRepository, req ReceiveStockReq) (*StockResp, error) {
+	storageID := entity.PK(req.StorageID)
+	productIDs := algo.Map(req.Products, func(p ReceiveStockReqProduct, _ int) entity.PK {
+		return entity.PK(p.ProductID)
+	})
+	if err := s.checkStockTarget(ctx, repo, storageID, productIDs...); err != nil {
+		return nil, err
+	}
+	// та же блокировка, что при резерве
+	if _, err := repo.LockStorageDataByProduct(ctx, productIDs...); err != nil {
+		return nil, err
+	}
+	data, err := repo.UpsertStorageData(ctx, algo.Map(req.Products, func(p ReceiveStockReqProduct, _ int) *entity.StoredProduct {
+		return &entity.StoredProduct{
+			StorageID: storageID,
+			ProductID: entity.PK(p.ProductID),
+			Amount:    p.Amount,
+		}
+	})...)
+	if err != nil {
+		return nil, err
+	}
+	_, err = repo.CreateStockAdjustment(ctx, algo.Map(req.Products, func(p ReceiveStockReqProduct, _ int) *entity.StockAdjustment {
+		return &entity.StockAdjustment{
+			StorageID: storageID,
+			ProductID: entity.PK(p.ProductID),
+			Delta:     int(p.Amount),
+			Reason:    entity.StockReasonReceipt,
+			Comment:   req.Comment,
+		}
+	})...)
+	if err != nil {
+		return nil, err
+	}
+	reservations, err := repo.GetReservationByStorage(ctx, storageID)
+	if err != nil {
+		return nil, err
+	}
+	return newStockResp(storageID, data, reservations), nil
+}
+
+// списание или исправление остатков, остаток не может стать меньше зарезервированного
+func (s *Service) AdjustStock(ctx context.Context, req AdjustStockReq) (_ *StockResp, err error) {
+	ctx, span := tracing.Start(ctx, "storage.AdjustStock")
+	defer tracing.End(span, &err)
+	var result *StockResp
+	err = s.repo.RunInTransaction(ctx, func(ctx database.TxContext, repo repository.IRepository) (err error) {
+		result, err = idempotency.Do(ctx, repo, "Storage.AdjustStock", req.IdempotencyKey, req, func() (*StockResp, error) {
+			return s.adjustStock(ctx, repo, req)
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *Service) adjustStock(ctx database.TxContext, repo repository.IRepository, req AdjustStockReq) (*StockResp, error) {
+	storageID := entity.PK(req.StorageID)
+	productIDs := algo.Map(req.Products, func(p AdjustStockReqProduct, _ int) entity.PK {
+		return entity.PK(p.ProductID)
+	})
+	if err := s.checkStockTarget(ctx, repo, storageID, productIDs...); err != nil {
+		return nil, err
+	}
+	storedData, err := repo.LockStorageDataByProduct(ctx, productIDs...)
+	if err != nil {
+		return nil, err
+	}
+	reservations, err := repo.GetReservationByStorage(ctx, storageID)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		updated     []*entity.StoredProduct
+		created     []*entity.StoredProduct
+		unchanged   []*entity.StoredProduct
+		adjustments []*entity.StockAdjustment
+	)
+	for _, p := range req.Products {
+		productID := entity.PK(p.ProductID)
+		st, stored := algo.Find(storedData, func(st *entity.StoredProduct) bool {
+			return st.StorageID == storageID && st.ProductID == productID
+		})
+		var current uint
+		if stored {
+			current = st.Amount
+		}
+		newAmount := current
+		if p.Amount != nil {
+			newAmount = *p.Amount
+		} else if amount := int(current) + p.Delta; amount >= 0 {
+			newAmount = uint(amount)
+		} else {
+			return nil, errs.Newf(
+				errs.CodeInsufficientStock,
+				"cannot write off %d of product %d from storage %d (stored %d)",
+				-p.Delta,
+				productID,
+				storageID,
+				current,
+			).
+				WithDetail("storage_id", storageID).
+				WithDetail("product_id", productID).
+				WithDetail("available", current).
+				WithDetail("requested", -p.Delta)
+		}
+		var reserved uint
+		if res, ok := algo.Find(reservations, func(r *entity.ProductReservation) bool {
+			return r.ProductID == productID
+		}); ok {
+			reserved = res.Amount
+		}
+		if newAmount < reserved {
+			return nil, errs.Newf(
+				errs.CodeConflict,
+				"cannot set amount of product %d in storage %d to %d, %d are reserved",
+				productID,
+				storageID,
+				newAmount,
+				reserved,
+			).
+				WithDetail("storage_id", storageID).
+				WithDetail("product_id", productID).
+				WithDetail("reserved", reserved)
+		}
+
+		e := &entity.StoredProduct{StorageID: storageID, ProductID: productID, Amount: newAmount}
+		switch {
+		case newAmount == current:
+			unchanged = append(unchanged, e)
+			continue
+		case stored:
+			e.ID = st.ID
+			updated = append(updated, e)
+		default:
+			created = append(created, e)
+		}
+		adjustments = append(adjustments, &entity.StockAdjustment{
+			StorageID: storageID,
+			ProductID: productID,
+			Delta:     int(newAmount) - int(current),
+			Reason:    req.Reason,
+			Comment:   req.Comment,
+		})
+	}
+
+	if len(updated) > 0 {
+		if updated, err = repo.UpdateStorageData(ctx, updated...); err != nil {
+			return nil, err
+		}
+	}
+	if len(created) > 0 {
+		if created, err = repo.CreateStorageData(ctx, created...); err != nil {
+			return nil, err
+		}
+	}
+	if len(adjustments) > 0 {
+		if _, err := repo.CreateStockAdjustment(ctx, adjustments...); err != nil {
+			return nil, err
+		}
+	}
+	data := append(append(updated, created...), unchanged...)
+	return newStockResp(storageID, data, reservations), nil
+}
+
+// включает или выключает склад. при выключении резервы склада
+// можно перенести на другие доступные склады
+func (s *Service) SetStorageAvailability(ctx context.Context, req SetStorageAvailabilityReq) (_ *SetStorageAvailabilityResp, err error) {
+	ctx, span := tracing.Start(ctx, "storage.SetStorageAvailability")
+	defer tracing.End(span, &err)
+	var result *SetStorageAvailabilityResp
+	err = s.repo.RunInTransaction(ctx, func(ctx database.TxContext, repo repository.IRepository) error {
+		storage, err := repo.LockStorage(ctx, entity.PK(req.StorageID))
+		if err != nil {
+			return err
+		}
+		if storage.IsAvailable != req.IsAvailable {
+			storage.IsAvailable = req.IsAvailable
+			if _, err := repo.UpdateStorage(ctx, storage); err != nil {
+				return err
+			}
+		}
+		result = &SetStorageAvailabilityResp{
+			StorageID:            storage.ID.ToUint(),
+			IsAvailable:          storage.IsAvailable,
+			MovedReservations:    []uint{},
+			NotMovedReservations: []NotMovedReservation{},
+		}
+		if !req.EvacuateReservations {
+			return nil
+		}
+		// повторный вызов на уже выключенном складе тоже переносит оставшиеся резервы
+		evacuated, err := s.evacuator.EvacuateStorage(ctx, repo, storage.ID, req.Strategy)
+		if err != nil {
+			return err
+		}
+		result.MovedReservations = algo.Map(evacuated.Moved, func(id entity.PK, _ int) uint {
+			return id.ToUint()
+		})
+		result.NotMovedReservations = algo.Map(evacuated.NotMoved, func(r reservation.NotMovedReservation, _ int) NotMovedReservation {
+			return NotMovedReservation{
+				ReservationID: r.ReservationID.ToUint(),
+				ProductID:     r.ProductID.ToUint(),
+				Reason:        r.Reason,
+			}
+		})
+		if len(evacuated.NotMoved) > 0 {
+			s.log.Warnw(
+				"some reservations were left on unavailable storage",
+				"storage_id", storage.ID,
+				"not_moved", len(evacuated.NotMoved),
+			)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// склад и товары должны существовать
+func (s *Service) checkStockTarget(ctx database.TxContext, repo repository.IRepository, storageID entity.PK, productIDs ...entity.PK) error {
+	if _, err := repo.GetStorage(ctx, storageID); err != nil {
+		return err
+	}
+	products, err := repo.GetProducts(ctx, productIDs...)
+	if err != nil {
+		return err
+	}
+	for _, id := range productIDs {
+		if _, ok := algo.Find(products, func(p *entity.Product) bool {
+			return p.ID == id
+		}); !ok {
+			return errs.Newf(errs.CodeNotFound, "cannot find product by id %d", id).WithDetail("product_id", id)
+		}
+	}
+	return nil
+}
+
+func newStockResp(storageID entity.PK, data []*entity.StoredProduct, reservations []*entity.ProductReservation) *StockResp {
+	result := &StockResp{
+		StorageID: storageID.ToUint(),
+		Products:  make([]StockRespProduct, 0, len(data)),
+	}
+	for _, st := range data {
+		p := StockRespProduct{
+			ProductID: st.ProductID.ToUint(),
+			Amount:    st.Amount,
+		}
+		if res, ok := algo.Find(reservations, func(r *entity.ProductReservation) bool {
+			return r.ProductID == st.ProductID
+		}); ok {
+			p.Reserved = res.Amount
+		}
+		result.Products = append(result.Products, p)
+	}
+	return result
+}
@@ -0,0 +1,301 @@
+package storage
+
+import (
+	"context"
+	"storageapi/internal/database"
+	"storageapi/internal/entity"
+	"storageapi/internal/repository"
+	"storageapi/internal/repository/memory"
+	"storageapi/internal/test/testdb"
+	"storageapi/internal/usecase/reservation"
+	"storageapi/pkg/errs"
+	"sync"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// склад с двумя товарами: первого лежит stored, из них reserved в резерве, второго нет совсем
+func newMemoryService(t *testing.T, stored, reserved uint) (*Service, repository.IRepository, entity.PK, []entity.PK) {
+	t.Helper()
+	repo := memory.NewRepository()
+	ctx := context.Background()
+	storages, err := repo.CreateStorage(ctx, &entity.Storage{IsAvailable: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	products, err := repo.CreateProduct(ctx,
+		&entity.Product{Name: "shirt", Vendor: "shirt-1", Size: "m"},
+		&entity.Product{Name: "cap", Vendor: "cap-1", Size: "l"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	storageID := storages[0].ID
+	if _, err := repo.CreateStorageData(ctx, &entity.StoredProduct{StorageID: storageID, ProductID: products[0].ID, Amount: stored}); err != nil {
+		t.Fatal(err)
+	}
+	if reserved > 0 {
+		if _, err := repo.CreateReservation(ctx, &entity.ProductReservation{StorageID: storageID, ProductID: products[0].ID, Amount: reserved}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	log := zap.NewNop().Sugar()
+	evacuator := reservation.NewService(repo, log, reservation.ServiceConf{DefaultStrategy: reservation.StrategyLargestFirst})
+	return NewService(repo, log, evacuator), repo, storageID, []entity.PK{products[0].ID, products[1].ID}
+}
+
+// остатки склада по товарам
+func storedAmounts(t *testing.T, repo repository.IRepository, storageID entity.PK) map[entity.PK]uint {
+	t.Helper()
+	stored, err := repo.GetStorageDataByStorage(context.Background(), storageID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := map[entity.PK]uint{}
+	for _, st := range stored {
+		result[st.ProductID] = st.Amount
+	}
+	return result
+}
+
+func journal(t *testing.T, repo repository.IRepository, storageID entity.PK) []*entity.StockAdjustment {
+	t.Helper()
+	adjustments, err := repo.GetStockAdjustmentsByStorage(context.Background(), storageID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return adjustments
+}
+
+func checkJournal(t *testing.T, got []*entity.StockAdjustment, want ...entity.StockAdjustment) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("expected %d journal rows, got %+v", len(want), got)
+	}
+	for i, w := range want {
+		g := got[i]
+		if g.ProductID != w.ProductID || g.Delta != w.Delta || g.Reason != w.Reason || g.Comment != w.Comment {
+			t.Fatalf("journal row %d: expected %+v, got %+v", i, w, *g)
+		}
+	}
+}
+
+func TestReceiveStock(t *testing.T) {
+	service, repo, storageID, products := newMemoryService(t, 5, 3)
+	ctx := context.Background()
+
+	// к существующей строке остаток добавляется, для нового товара строка создается
+	resp, err := service.ReceiveStock(ctx, ReceiveStockReq{
+		StorageID: storageID.ToUint(),
+		Comment:   "supply 1",
+		Products: []ReceiveStockReqProduct{
+			{ProductID: products[0].ToUint(), Amount: 2},
+			{ProductID: products[1].ToUint(), Amount: 4},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[uint]StockRespProduct{
+		products[0].ToUint(): {ProductID: products[0].ToUint(), Amount: 7, Reserved: 3},
+		products[1].ToUint(): {ProductID: products[1].ToUint(), Amount: 4},
+	}
+	if resp.StorageID != storageID.ToUint() || len(resp.Products) != len(want) {
+		t.Fatalf("unexpected response %+v", resp)
+	}
+	for _, p := range resp.Products {
+		if want[p.ProductID] != p {
+			t.Fatalf("expected %v, got %+v", want, resp.Products)
+		}
+	}
+
+	// повтор с ключом не принимает товар второй раз
+	req := ReceiveStockReq{
+		IdempotencyKey: "supply-2",
+		StorageID:      storageID.ToUint(),
+		Products:       []ReceiveStockReqProduct{{ProductID: products[1].ToUint(), Amount: 1}},
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := service.ReceiveStock(ctx, req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := storedAmounts(t, repo, storageID); got[products[0]] != 7 || got[products[1]] != 5 {
+		t.Fatalf("unexpected stock %v", got)
+	}
+	checkJournal(t, journal(t, repo, storageID),
+		entity.StockAdjustment{ProductID: products[0], Delta: 2, Reason: entity.StockReasonReceipt, Comment: "supply 1"},
+		entity.StockAdjustment{ProductID: products[1], Delta: 4, Reason: entity.StockReasonReceipt, Comment: "supply 1"},
+		entity.StockAdjustment{ProductID: products[1], Delta: 1, Reason: entity.StockReasonReceipt},
+	)
+
+	// склад и товары должны существовать
+	_, err = service.ReceiveStock(ctx, ReceiveStockReq{
+		StorageID: storageID.ToUint() + 100,
+		Products:  []ReceiveStockReqProduct{{ProductID: products[0].ToUint(), Amount: 1}},
+	})
+	if errs.CodeOf(err) != errs.CodeNotFound {
+		t.Fatalf("expected not found for missing storage, got %v", err)
+	}
+	_, err = service.ReceiveStock(ctx, ReceiveStockReq{
+		StorageID: storageID.ToUint(),
+		Products:  []ReceiveStockReqProduct{{ProductID: products[1].ToUint() + 100, Amount: 1}},
+	})
+	if errs.CodeOf(err) != errs.CodeNotFound {
+		t.Fatalf("expected not found for missing product, got %v", err)
+	}
+	if got := journal(t, repo, storageID); len(got) != 3 {
+		t.Fatalf("failed receipts must not be journaled, got %+v", got)
+	}
+}
+
+func TestAdjustStock(t *testing.T) {
+	service, repo, storageID, products := newMemoryService(t, 5, 3)
+	ctx := context.Background()
+	adjust := func(reason string, p AdjustStockReqProduct) (*StockResp, error) {
+		return service.AdjustStock(ctx, AdjustStockReq{
+			StorageID: storageID.ToUint(),
+			Reason:    reason,
+			Comment:   reason + " comment",
+			Products:  []AdjustStockReqProduct{p},
+		})
+	}
+	amount := func(v uint) *uint {
+		return &v
+	}
+
+	// списание до уровня резерва разрешено
+	resp, err := adjust(entity.StockReasonDamage, AdjustStockReqProduct{ProductID: products[0].ToUint(), Delta: -2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Products) != 1 || resp.Products[0].Amount != 3 || resp.Products[0].Reserved != 3 {
+		t.Fatalf("unexpected response %+v", resp)
+	}
+	// ниже резерва - конфликт, в том числе при установке остатка целиком
+	if _, err := adjust(entity.StockReasonLoss, AdjustStockReqProduct{ProductID: products[0].ToUint(), Delta: -1}); errs.CodeOf(err) != errs.CodeConflict {
+		t.Fatalf("expected conflict below reserved amount, got %v", err)
+	}
+	if _, err := adjust(entity.StockReasonRecount, AdjustStockReqProduct{ProductID: products[0].ToUint(), Amount: amount(2)}); errs.CodeOf(err) != errs.CodeConflict {
+		t.Fatalf("expected conflict below reserved amount, got %v", err)
+	}
+	// больше, чем лежит на складе, списать нельзя
+	if _, err := adjust(entity.StockReasonLoss, AdjustStockReqProduct{ProductID: products[0].ToUint(), Delta: -10}); errs.CodeOf(err) != errs.CodeInsufficientStock {
+		t.Fatalf("expected insufficient stock, got %v", err)
+	}
+
+	// пересчет без изменения остатка не пишется в журнал
+	if _, err := adjust(entity.StockReasonRecount, AdjustStockReqProduct{ProductID: products[0].ToUint(), Amount: amount(3)}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := adjust(entity.StockReasonRecount, AdjustStockReqProduct{ProductID: products[0].ToUint(), Amount: amount(6)}); err != nil {
+		t.Fatal(err)
+	}
+	// исправление создает строку для товара, которого на складе не было
+	if _, err := adjust(entity.StockReasonCorrection, AdjustStockReqProduct{ProductID: products[1].ToUint(), Delta: 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := storedAmounts(t, repo, storageID); got[products[0]] != 6 || got[products[1]] != 2 {
+		t.Fatalf("unexpected stock %v", got)
+	}
+	checkJournal(t, journal(t, repo, storageID),
+		entity.StockAdjustment{ProductID: products[0], Delta: -2, Reason: entity.StockReasonDamage, Comment: "damage comment"},
+		entity.StockAdjustment{ProductID: products[0], Delta: 3, Reason: entity.StockReasonRecount, Comment: "recount comment"},
+		entity.StockAdjustment{ProductID: products[1], Delta: 2, Reason: entity.StockReasonCorrection, Comment: "correction comment"},
+	)
+}
+
+// резервы, идущие одновременно с выключением склада, либо не попадают на него,
+// либо переносятся или попадают в NotMovedReservations
+func TestEvacuationConcurrentReserve(t *testing.T) {
+	db, err := database.NewDBWithPgx(testdb.New(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	log := zap.NewNop().Sugar()
+	repo := repository.NewRepository(db, log)
+	ctx := context.Background()
+
+	const (
+		rounds  = 10
+		clients = 8
+	)
+	storages, err := repo.CreateStorage(ctx, &entity.Storage{IsAvailable: true}, &entity.Storage{IsAvailable: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	products, err := repo.CreateProduct(ctx, &entity.Product{Name: "shirt", Vendor: "shirt-1", Size: "m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	offline, productID := storages[0].ID, products[0].ID
+	// на выключаемом складе товара больше, largest_first резервирует сначала на нем
+	if _, err := repo.CreateStorageData(ctx,
+		&entity.StoredProduct{StorageID: offline, ProductID: productID, Amount: 1000000},
+		&entity.StoredProduct{StorageID: storages[1].ID, ProductID: productID, Amount: 1000},
+	); err != nil {
+		t.Fatal(err)
+	}
+	reservationSvc := reservation.NewService(repo, log, reservation.ServiceConf{DefaultStrategy: reservation.StrategyLargestFirst})
+	service := NewService(repo, log, reservationSvc)
+
+	for round := 0; round < rounds; round++ {
+		if _, err := service.SetStorageAvailability(ctx, SetStorageAvailabilityReq{StorageID: offline.ToUint(), IsAvailable: true}); err != nil {
+			t.Fatal(err)
+		}
+		var (
+			wg      sync.WaitGroup
+			toggled *SetStorageAvailabilityResp
+		)
+		start := make(chan struct{})
+		for i := 0; i < clients; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				<-start
+				_, err := reservationSvc.ReserveProducts(ctx, reservation.ReserveProductsReq{
+					Products: []reservation.ReserveProductsReqItem{{ID: productID.ToUint(), Amount: 1}},
+				})
+				if err != nil {
+					t.Error(err)
+				}
+			}()
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			var err error
+			toggled, err = service.SetStorageAvailability(ctx, SetStorageAvailabilityReq{
+				StorageID:            offline.ToUint(),
+				IsAvailable:          false,
+				EvacuateReservations: true,
+			})
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+		close(start)
+		wg.Wait()
+		if t.Failed() {
+			t.FailNow()
+		}
+
+		notMoved := map[uint]bool{}
+		for _, r := range toggled.NotMovedReservations {
+			notMoved[r.ReservationID] = true
+		}
+		orders, err := repo.LockReservationOrdersByStorage(ctx, offline, entity.ReservationStatusActive)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, o := range orders {
+			if !notMoved[o.ID.ToUint()] {
+				t.Fatalf("round %d: order %d stayed on unavailable storage and was not reported", round, o.ID)
+			}
+		}
+	}
+}
@@ -0,0 +1,7 @@
+package transfer
+
+import "storageapi/internal/repository"
+
+type Repository interface {
+	repository.IRepoMixin
+}
@@ -0,0 +1,258 @@
+package transfer
+
+import (
+	"context"
+	"fmt"
+	"storageapi/internal/database"
+	"storageapi/internal/entity"
+	"storageapi/internal/repository"
+	"storageapi/internal/tracing"
+	"storageapi/internal/usecase/idempotency"
+	"storageapi/pkg/algo"
+	"storageapi/pkg/errs"
+
+	"go.uber.org/zap"
+)
+
+type Service struct {
+	repo Repository
+	log  *zap.SugaredLogger
+}
+
+func NewService(r repository.IRepository, log *zap.SugaredLogger) *Service {
+	return &Service{
+		repo: r,
+		log:  log,
+	}
+}
+
+// списывает товары со склада-источника, до приемки они числятся в пути
+func (s *Service) CreateTransfer(ctx context.Context, req CreateTransferReq) (_ *TransferResp, err error) {
+	ctx, span := tracing.Start(ctx, "transfer.CreateTransfer")
+	defer tracing.End(span, &err)
+	var result *TransferResp
+	err = s.repo.RunInTransaction(ctx, func(ctx database.TxContext, repo repository.IRepository) (err error) {
+		result, err = idempotency.Do(ctx, repo, "Transfer.CreateTransfer", req.IdempotencyKey, req, func() (*TransferResp, error) {
+			return s.createTransfer(ctx, repo, req)
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *Service) createTransfer(ctx database.TxContext, repo repository.IRepository, req CreateTransferReq) (*TransferResp, error) {
+	sourceID, destinationID := entity.PK(req.SourceStorageID), entity.PK(req.DestinationStorageID)
+	for _, id := range []entity.PK{sourceID, destinationID} {
+		if err := s.checkAvailable(ctx, repo, id); err != nil {
+			return nil, err
+		}
+	}
+	productIDs := algo.Map(req.Products, func(p CreateTransferReqProduct, _ int) entity.PK {
+		return entity.PK(p.ProductID)
+	})
+	// та же блокировка, что при резерве, зарезервированное перемещать нельзя
+	storedData, err := repo.LockStorageDataByProduct(ctx, productIDs...)
+	if err != nil {
+		return nil, err
+	}
+	reservations, err := repo.GetReservationByStorage(ctx, sourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	updated := make([]*entity.StoredProduct, 0, len(req.Products))
+	for _, p := range req.Products {
+		productID := entity.PK(p.ProductID)
+		st, ok := algo.Find(storedData, func(st *entity.StoredProduct) bool {
+			return st.StorageID == sourceID && st.ProductID == productID
+		})
+		if !ok {
+			return nil, errs.Newf(errs.CodeInsufficientStock, "product %d is not stored in storage %d", productID, sourceID).
+				WithDetail("storage_id", sourceID).
+				WithDetail("product_id", productID).
+				WithDetail("available", 0).
+				WithDetail("requested", p.Amount)
+		}
+		var reserved uint
+		if res, ok := algo.Find(reservations, func(r *entity.ProductReservation) bool {
+			return r.ProductID == productID
+		}); ok {
+			reserved = res.Amount
+		}
+		if free := st.Amount - algo.Min(reserved, st.Amount); free < p.Amount {
+			return nil, errs.Newf(
+				errs.CodeInsufficientStock,
+				"cannot transfer more than %d of product %d from storage %d (tried to transfer %d)",
+				free,
+				productID,
+				sourceID,
+				p.Amount,
+			).
+				WithDetail("storage_id", sourceID).
+				WithDetail("product_id", productID).
+				WithDetail("available", free).
+				WithDetail("requested", p.Amount)
+		}
+		e := *st
+		e.Amount -= p.Amount
+		updated = append(updated, &e)
+	}
+	if _, err := repo.UpdateStorageData(ctx, updated...); err != nil {
+		return nil, err
+	}
+
+	transfers, err := repo.CreateTransfer(ctx, &entity.Transfer{
+		SourceStorageID:      sourceID,
+		DestinationStorageID: destinationID,
+		Status:               entity.TransferStatusInTransit,
+	})
+	if err != nil {
+		return nil, err
+	}
+	t := transfers[0]
+	items, err := repo.CreateTransferItem(ctx, algo.Map(req.Products, func(p CreateTransferReqProduct, _ int) *entity.TransferItem {
+		return &entity.TransferItem{
+			TransferID: t.ID,
+			ProductID:  entity.PK(p.ProductID),
+			Amount:     p.Amount,
+		}
+	})...)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.journal(ctx, repo, t, sourceID, items, -1, entity.StockReasonTransferOut); err != nil {
+		return nil, err
+	}
+	return newTransferResp(t, items), nil
+}
+
+func (s *Service) GetTransfer(ctx context.Context, req GetTransferReq) (_ *TransferResp, err error) {
+	ctx, span := tracing.Start(ctx, "transfer.GetTransfer")
+	defer tracing.End(span, &err)
+	var result *TransferResp
+	err = s.repo.RunInTransaction(ctx, func(ctx database.TxContext, repo repository.IRepository) error {
+		t, err := repo.GetTransfer(ctx, entity.PK(req.ID))
+		if err != nil {
+			return err
+		}
+		items, err := repo.GetTransferItems(ctx, t.ID)
+		if err != nil {
+			return err
+		}
+		result = newTransferResp(t, items)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// приемка на складе-получателе, склад должен быть доступен
+func (s *Service) ReceiveTransfer(ctx context.Context, req ReceiveTransferReq) (_ *TransferResp, err error) {
+	ctx, span := tracing.Start(ctx, "transfer.ReceiveTransfer")
+	defer tracing.End(span, &err)
+	return s.finishTransfer(ctx, entity.PK(req.ID), entity.TransferStatusReceived)
+}
+
+// отмена перемещения, товары возвращаются на склад-источник
+func (s *Service) CancelTransfer(ctx context.Context, req CancelTransferReq) (_ *TransferResp, err error) {
+	ctx, span := tracing.Start(ctx, "transfer.CancelTransfer")
+	defer tracing.End(span, &err)
+	return s.finishTransfer(ctx, entity.PK(req.ID), entity.TransferStatusCancelled)
+}
+
+func (s *Service) finishTransfer(ctx context.Context, id entity.PK, status string) (*TransferResp, error) {
+	var result *TransferResp
+	err := s.repo.RunInTransaction(ctx, func(ctx database.TxContext, repo repository.IRepository) error {
+		t, err := repo.LockTransfer(ctx, id)
+		if err != nil {
+			return err
+		}
+		if t.Status != entity.TransferStatusInTransit {
+			return errs.Newf(errs.CodeConflict, "transfer %d is already %s", t.ID, t.Status).
+				WithDetail("transfer_id", t.ID).
+				WithDetail("status", t.Status)
+		}
+		storageID, reason := t.DestinationStorageID, entity.StockReasonTransferIn
+		if status == entity.TransferStatusCancelled {
+			// на недоступный склад-источник товары тоже возвращаются,
+			// для резерва они станут доступны вместе со складом
+			storageID, reason = t.SourceStorageID, entity.StockReasonTransferCancel
+		} else if err := s.checkAvailable(ctx, repo, storageID); err != nil {
+			return err
+		}
+
+		items, err := repo.GetTransferItems(ctx, t.ID)
+		if err != nil {
+			return err
+		}
+		productIDs := algo.Map(items, func(i *entity.TransferItem, _ int) entity.PK {
+			return i.ProductID
+		})
+		if _, err := repo.LockStorageDataByProduct(ctx, productIDs...); err != nil {
+			return err
+		}
+		_, err = repo.UpsertStorageData(ctx, algo.Map(items, func(i *entity.TransferItem, _ int) *entity.StoredProduct {
+			return &entity.StoredProduct{
+				StorageID: storageID,
+				ProductID: i.ProductID,
+				Amount:    i.Amount,
+			}
+		})...)
+		if err != nil {
+			return err
+		}
+		if err := s.journal(ctx, repo, t, storageID, items, 1, reason); err != nil {
+			return err
+		}
+
+		t.Status = status
+		transfers, err := repo.UpdateTransfer(ctx, t)
+		if err != nil {
+			return err
+		}
+		result = newTransferResp(transfers[0], items)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *Service) checkAvailable(ctx database.TxContext, repo repository.IRepository, storageID entity.PK) error {
+	storage, err := repo.GetStorage(ctx, storageID)
+	if err != nil {
+		return err
+	}
+	if !storage.IsAvailable {
+		return errs.Newf(errs.CodeUnavailable, "storage %d is not available", storageID).WithDetail("storage_id", storageID)
+	}
+	return nil
+}
+
+// записывает движение товаров перемещения в журнал остатков, sign - знак изменения
+func (s *Service) journal(
+	ctx database.TxContext,
+	repo repository.IRepository,
+	t *entity.Transfer,
+	storageID entity.PK,
+	items []*entity.TransferItem,
+	sign int,
+	reason string,
+) error {
+	_, err := repo.CreateStockAdjustment(ctx, algo.Map(items, func(i *entity.TransferItem, _ int) *entity.StockAdjustment {
+		return &entity.StockAdjustment{
+			StorageID: storageID,
+			ProductID: i.ProductID,
+			Delta:     sign * int(i.Amount),
+			Reason:    reason,
+			Comment:   fmt.Sprintf("transfer %d", t.ID),
+		}
+	})...)
+	return err
+}
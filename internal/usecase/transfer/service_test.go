@@ -0,0 +1,207 @@
+package transfer
+
+import (
+	"context"
+	"storageapi/internal/entity"
+	"storageapi/internal/repository"
+	"storageapi/internal/repository/memory"
+	"storageapi/pkg/errs"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// два доступных склада, на первом лежит stored товара, из них reserved в резерве
+func newMemoryService(t *testing.T, stored, reserved uint) (*Service, repository.IRepository, entity.PK, entity.PK, entity.PK) {
+	t.Helper()
+	repo := memory.NewRepository()
+	ctx := context.Background()
+	storages, err := repo.CreateStorage(ctx, &entity.Storage{IsAvailable: true}, &entity.Storage{IsAvailable: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	products, err := repo.CreateProduct(ctx, &entity.Product{Name: "shirt", Vendor: "shirt-1", Size: "m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	source, destination, productID := storages[0].ID, storages[1].ID, products[0].ID
+	if _, err := repo.CreateStorageData(ctx, &entity.StoredProduct{StorageID: source, ProductID: productID, Amount: stored}); err != nil {
+		t.Fatal(err)
+	}
+	if reserved > 0 {
+		if _, err := repo.CreateReservation(ctx, &entity.ProductReservation{StorageID: source, ProductID: productID, Amount: reserved}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return NewService(repo, zap.NewNop().Sugar()), repo, source, destination, productID
+}
+
+// сколько товара лежит на складе, 0 - если строки нет
+func storedAmount(t *testing.T, repo repository.IRepository, storageID, productID entity.PK) uint {
+	t.Helper()
+	stored, err := repo.GetStorageDataByStorage(context.Background(), storageID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, st := range stored {
+		if st.ProductID == productID {
+			return st.Amount
+		}
+	}
+	return 0
+}
+
+func setAvailable(t *testing.T, repo repository.IRepository, storageID entity.PK, available bool) {
+	t.Helper()
+	storage, err := repo.GetStorage(context.Background(), storageID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	storage.IsAvailable = available
+	if _, err := repo.UpdateStorage(context.Background(), storage); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTransferLifecycle(t *testing.T) {
+	service, repo, source, destination, productID := newMemoryService(t, 10, 4)
+	ctx := context.Background()
+	create := func(amount uint) (*TransferResp, error) {
+		return service.CreateTransfer(ctx, CreateTransferReq{
+			SourceStorageID:      source.ToUint(),
+			DestinationStorageID: destination.ToUint(),
+			Products:             []CreateTransferReqProduct{{ProductID: productID.ToUint(), Amount: amount}},
+		})
+	}
+
+	// зарезервированное перемещать нельзя: свободно 6 из 10
+	if _, err := create(7); errs.CodeOf(err) != errs.CodeInsufficientStock {
+		t.Fatalf("expected insufficient stock for reserved products, got %v", err)
+	}
+	received, err := create(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cancelled, err := create(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if received.Status != entity.TransferStatusInTransit || received.SourceStorageID != source.ToUint() ||
+		received.DestinationStorageID != destination.ToUint() || len(received.Products) != 1 ||
+		received.Products[0] != (TransferRespProduct{ProductID: productID.ToUint(), Amount: 3}) {
+		t.Fatalf("unexpected transfer %+v", received)
+	}
+	// в пути товары не числятся ни на одном складе
+	if got := storedAmount(t, repo, source, productID); got != 5 {
+		t.Fatalf("expected 5 left in source, got %d", got)
+	}
+	if got := storedAmount(t, repo, destination, productID); got != 0 {
+		t.Fatalf("expected nothing in destination before receipt, got %d", got)
+	}
+
+	// получатель должен быть доступен
+	setAvailable(t, repo, destination, false)
+	if _, err := service.ReceiveTransfer(ctx, ReceiveTransferReq{ID: received.ID}); errs.CodeOf(err) != errs.CodeUnavailable {
+		t.Fatalf("expected unavailable destination, got %v", err)
+	}
+	setAvailable(t, repo, destination, true)
+	resp, err := service.ReceiveTransfer(ctx, ReceiveTransferReq{ID: received.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Status != entity.TransferStatusReceived {
+		t.Fatalf("expected received transfer, got %+v", resp)
+	}
+
+	// отмена возвращает товары даже на недоступный источник
+	setAvailable(t, repo, source, false)
+	resp, err = service.CancelTransfer(ctx, CancelTransferReq{ID: cancelled.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Status != entity.TransferStatusCancelled {
+		t.Fatalf("expected cancelled transfer, got %+v", resp)
+	}
+	if got := storedAmount(t, repo, source, productID); got != 7 {
+		t.Fatalf("expected 7 in source after cancel, got %d", got)
+	}
+	if got := storedAmount(t, repo, destination, productID); got != 3 {
+		t.Fatalf("expected 3 in destination after receipt, got %d", got)
+	}
+	got, err := service.GetTransfer(ctx, GetTransferReq{ID: received.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != entity.TransferStatusReceived || len(got.Products) != 1 {
+		t.Fatalf("unexpected transfer %+v", got)
+	}
+
+	// завершенное перемещение нельзя ни принять, ни отменить повторно
+	for _, id := range []uint{received.ID, cancelled.ID} {
+		if _, err := service.ReceiveTransfer(ctx, ReceiveTransferReq{ID: id}); errs.CodeOf(err) != errs.CodeConflict {
+			t.Fatalf("expected conflict receiving transfer %d, got %v", id, err)
+		}
+		if _, err := service.CancelTransfer(ctx, CancelTransferReq{ID: id}); errs.CodeOf(err) != errs.CodeConflict {
+			t.Fatalf("expected conflict cancelling transfer %d, got %v", id, err)
+		}
+	}
+	if got := storedAmount(t, repo, source, productID) + storedAmount(t, repo, destination, productID); got != 10 {
+		t.Fatalf("repeated transitions changed stock: %d in total", got)
+	}
+	if _, err := service.GetTransfer(ctx, GetTransferReq{ID: received.ID + 100}); errs.CodeOf(err) != errs.CodeNotFound {
+		t.Fatalf("expected not found, got %v", err)
+	}
+
+	// движения по складам записаны в журнал
+	adjustments, err := repo.GetStockAdjustmentsByStorage(ctx, source, destination)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		storageID entity.PK
+		delta     int
+		reason    string
+	}{
+		{source, -3, entity.StockReasonTransferOut},
+		{source, -2, entity.StockReasonTransferOut},
+		{destination, 3, entity.StockReasonTransferIn},
+		{source, 2, entity.StockReasonTransferCancel},
+	}
+	if len(adjustments) != len(want) {
+		t.Fatalf("expected %d journal rows, got %+v", len(want), adjustments)
+	}
+	for i, w := range want {
+		a := adjustments[i]
+		if a.StorageID != w.storageID || a.ProductID != productID || a.Delta != w.delta || a.Reason != w.reason {
+			t.Fatalf("journal row %d: expected %+v, got %+v", i, w, *a)
+		}
+	}
+}
+
+// перемещение со склада или на склад, который выключен или не существует
+func TestCreateTransferStorages(t *testing.T) {
+	service, repo, source, destination, productID := newMemoryService(t, 10, 0)
+	ctx := context.Background()
+	req := CreateTransferReq{
+		SourceStorageID:      source.ToUint(),
+		DestinationStorageID: destination.ToUint(),
+		Products:             []CreateTransferReqProduct{{ProductID: productID.ToUint(), Amount: 1}},
+	}
+	setAvailable(t, repo, destination, false)
+	if _, err := service.CreateTransfer(ctx, req); errs.CodeOf(err) != errs.CodeUnavailable {
+		t.Fatalf("expected unavailable destination, got %v", err)
+	}
+	req.DestinationStorageID += 100
+	if _, err := service.CreateTransfer(ctx, req); errs.CodeOf(err) != errs.CodeNotFound {
+		t.Fatalf("expected not found destination, got %v", err)
+	}
+	// товара нет на складе-источнике
+	req.SourceStorageID, req.DestinationStorageID = destination.ToUint(), source.ToUint()
+	setAvailable(t, repo, destination, true)
+	if _, err := service.CreateTransfer(ctx, req); errs.CodeOf(err) != errs.CodeInsufficientStock {
+		t.Fatalf("expected insufficient stock, got %v", err)
+	}
+	if got := storedAmount(t, repo, source, productID); got != 10 {
+		t.Fatalf("failed transfers changed stock: %d left", got)
+	}
+}
@@ -0,0 +1,36 @@
+package transfer
+
+import (
+	"storageapi/internal/entity"
+	"storageapi/pkg/algo"
+	"storageapi/pkg/model"
+)
+
+// типы запросов и ответов публичные, их использует и клиент pkg/client
+type (
+	CreateTransferReq        = model.CreateTransferReq
+	CreateTransferReqProduct = model.CreateTransferReqProduct
+	TransferIDReq            = model.TransferIDReq
+	GetTransferReq           = model.GetTransferReq
+	ReceiveTransferReq       = model.ReceiveTransferReq
+	CancelTransferReq        = model.CancelTransferReq
+	TransferResp             = model.TransferResp
+	TransferRespProduct      = model.TransferRespProduct
+)
+
+func newTransferResp(t *entity.Transfer, items []*entity.TransferItem) *TransferResp {
+	return &TransferResp{
+		ID:                   t.ID.ToUint(),
+		SourceStorageID:      t.SourceStorageID.ToUint(),
+		DestinationStorageID: t.DestinationStorageID.ToUint(),
+		Status:               t.Status,
+		CreatedAt:            t.CreatedAt,
+		UpdatedAt:            t.UpdatedAt,
+		Products: algo.Map(items, func(i *entity.TransferItem, _ int) TransferRespProduct {
+			return TransferRespProduct{
+				ProductID: i.ProductID.ToUint(),
+				Amount:    i.Amount,
+			}
+		}),
+	}
+}
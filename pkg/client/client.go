@@ -0,0 +1,198 @@
+// Package client - типизированный клиент JSON-RPC api storageapi (TCP транспорт)
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"storageapi/pkg/errs"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var ErrClosed = errors.New("client is closed")
+
+type Options struct {
+	// число TCP соединений в пуле, по умолчанию 4
+	PoolSize int
+	// таймаут установки соединения, по умолчанию 5s
+	DialTimeout time.Duration
+	// сколько раз повторять запрос при обрыве соединения, по умолчанию 3,
+	// отрицательное значение отключает повторы.
+	// повторяются только чтения и изменения с ключом идемпотентности
+	MaxRetries int
+	// пауза перед первым повтором, дальше удваивается, по умолчанию 100ms
+	RetryBackoff time.Duration
+}
+
+func (o Options) withDefaults() Options {
+	if o.PoolSize <= 0 {
+		o.PoolSize = 4
+	}
+	if o.DialTimeout <= 0 {
+		o.DialTimeout = 5 * time.Second
+	}
+	if o.MaxRetries < 0 {
+		o.MaxRetries = 0
+	} else if o.MaxRetries == 0 {
+		o.MaxRetries = 3
+	}
+	if o.RetryBackoff <= 0 {
+		o.RetryBackoff = 100 * time.Millisecond
+	}
+	return o
+}
+
+// Client безопасен для использования из нескольких горутин.
+// Соединения устанавливаются лениво и переустанавливаются после обрыва
+type Client struct {
+	addr  string
+	opts  Options
+	next  uint32
+	conns []*conn
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+type conn struct {
+	mu  sync.Mutex
+	rpc *rpc.Client
+}
+
+// New не устанавливает соединение, ошибки сети вернутся из вызовов методов
+func New(addr string, opts Options) *Client {
+	opts = opts.withDefaults()
+	conns := make([]*conn, opts.PoolSize)
+	for i := range conns {
+		conns[i] = &conn{}
+	}
+	return &Client{
+		addr:  addr,
+		opts:  opts,
+		conns: conns,
+	}
+}
+
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	var result error
+	for _, cn := range c.conns {
+		cn.mu.Lock()
+		if cn.rpc != nil {
+			if err := cn.rpc.Close(); err != nil && result == nil {
+				result = err
+			}
+			cn.rpc = nil
+		}
+		cn.mu.Unlock()
+	}
+	return result
+}
+
+// соединения из пула выдаются по кругу, net/rpc сам мультиплексирует запросы в одном соединении
+func (c *Client) acquire(ctx context.Context) (*conn, *rpc.Client, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.closed {
+		return nil, nil, ErrClosed
+	}
+	cn := c.conns[atomic.AddUint32(&c.next, 1)%uint32(len(c.conns))]
+	cn.mu.Lock()
+	defer cn.mu.Unlock()
+	if cn.rpc == nil {
+		dialer := net.Dialer{Timeout: c.opts.DialTimeout}
+		nc, err := dialer.DialContext(ctx, "tcp", c.addr)
+		if err != nil {
+			return nil, nil, err
+		}
+		cn.rpc = jsonrpc.NewClient(nc)
+	}
+	return cn, cn.rpc, nil
+}
+
+// закрывает оборванное соединение, следующий запрос через этот слот пула установит новое
+func (c *Client) invalidate(cn *conn, rc *rpc.Client) {
+	cn.mu.Lock()
+	defer cn.mu.Unlock()
+	if cn.rpc == rc {
+		rc.Close()
+		cn.rpc = nil
+	}
+}
+
+// resp заполняется только при успешном ответе. при отмене ctx ответ
+// может прийти позже, поэтому resp нельзя читать после ошибки
+func (c *Client) call(ctx context.Context, method string, req, resp interface{}) error {
+	cn, rc, err := c.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	call := rc.Go(method, req, resp, make(chan *rpc.Call, 1))
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-call.Done:
+	}
+	if isConnError(call.Error) {
+		c.invalidate(cn, rc)
+	}
+	// сервер передает ошибку JSON строкой, см. errs.Marshal
+	var serverErr rpc.ServerError
+	if errors.As(call.Error, &serverErr) {
+		return errs.Unmarshal(string(serverErr))
+	}
+	return call.Error
+}
+
+// повторяет запрос при обрыве соединения, для безопасных к повтору методов
+func (c *Client) callWithRetry(ctx context.Context, method string, req, resp interface{}) error {
+	backoff := c.opts.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		err := c.call(ctx, method, req, resp)
+		if err == nil || !isConnError(err) || attempt >= c.opts.MaxRetries {
+			return err
+		}
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		backoff *= 2
+	}
+}
+
+// изменение можно повторить, только если сервер узнает повтор по ключу
+func (c *Client) callMutation(ctx context.Context, idempotencyKey, method string, req, resp interface{}) error {
+	if idempotencyKey == "" {
+		return c.call(ctx, method, req, resp)
+	}
+	return c.callWithRetry(ctx, method, req, resp)
+}
+
+// ошибка сети, а не ответ сервера
+func isConnError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var serverErr rpc.ServerError
+	if errors.As(err, &serverErr) {
+		return false
+	}
+	var netErr net.Error
+	return errors.Is(err, rpc.ErrShutdown) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.As(err, &netErr)
+}
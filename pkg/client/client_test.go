@@ -0,0 +1,117 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"storageapi/pkg/errs"
+	"storageapi/pkg/model"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeStorage struct{}
+
+func (fakeStorage) GetUnreservedStorage(request *model.GetUnreservedStorageReq, response *model.StorageSchemaRespItem) error {
+	if request.StorageID == 0 {
+		return errors.New(errs.Marshal(errs.New(errs.CodeNotFound, "cannot find storage by id").WithDetail("storage_id", 0)))
+	}
+	*response = model.StorageSchemaRespItem{ID: request.StorageID, IsAvailable: true}
+	return nil
+}
+
+type fakeReservation struct{}
+
+func (fakeReservation) GetReservation(request *model.GetReservationReq, response *model.ReservationResp) error {
+	time.Sleep(200 * time.Millisecond)
+	response.ID = request.ID
+	return nil
+}
+
+// tcp сервер, который умеет обрывать все открытые соединения
+type testServer struct {
+	listener net.Listener
+
+	mu    sync.Mutex
+	conns []net.Conn
+}
+
+func newTestServer(t *testing.T) *testServer {
+	server := rpc.NewServer()
+	if err := server.RegisterName("Storage", fakeStorage{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.RegisterName("Reservation", fakeReservation{}); err != nil {
+		t.Fatal(err)
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &testServer{listener: listener}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.conns = append(s.conns, conn)
+			s.mu.Unlock()
+			go server.ServeCodec(jsonrpc.NewServerCodec(conn))
+		}
+	}()
+	return s
+}
+
+func (s *testServer) dropConnections() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, c := range s.conns {
+		c.Close()
+	}
+	s.conns = nil
+}
+
+func TestClient(t *testing.T) {
+	server := newTestServer(t)
+	c := New(server.listener.Addr().String(), Options{PoolSize: 1, RetryBackoff: time.Millisecond})
+	defer c.Close()
+	ctx := context.Background()
+
+	resp, err := c.GetUnreservedStorage(ctx, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.ID != 7 || !resp.IsAvailable {
+		t.Errorf("unexpected response %+v", resp)
+	}
+
+	// ошибка сервера не повторяется и возвращается с кодом и деталями
+	_, err = c.GetUnreservedStorage(ctx, 0)
+	var serverErr *errs.Error
+	if !errors.As(err, &serverErr) || serverErr.Code != errs.CodeNotFound || serverErr.Details["storage_id"] != float64(0) {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	// после обрыва соединения чтение повторяется через новое соединение
+	server.dropConnections()
+	if _, err := c.GetUnreservedStorage(ctx, 8); err != nil {
+		t.Errorf("read after reconnect: %v", err)
+	}
+
+	deadlineCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, err := c.GetReservation(deadlineCtx, 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+
+	c.Close()
+	if _, err := c.GetUnreservedStorage(ctx, 7); !errors.Is(err, ErrClosed) {
+		t.Errorf("expected ErrClosed, got %v", err)
+	}
+}
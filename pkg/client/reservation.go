@@ -0,0 +1,35 @@
+package client
+
+import (
+	"context"
+	"storageapi/pkg/model"
+)
+
+func (c *Client) CreateReservation(ctx context.Context, req model.ReserveProductsReq) (*model.ReservationResp, error) {
+	var resp model.ReservationResp
+	if err := c.callMutation(ctx, req.IdempotencyKey, "Reservation.CreateReservation", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *Client) GetReservation(ctx context.Context, id uint) (*model.ReservationResp, error) {
+	var resp model.ReservationResp
+	if err := c.callWithRetry(ctx, "Reservation.GetReservation", model.GetReservationReq{ID: id}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *Client) UndoReservation(ctx context.Context, id uint) error {
+	var resp struct{}
+	return c.call(ctx, "Reservation.UndoReservation", model.UndoReservationReq{ID: id}, &resp)
+}
+
+func (c *Client) ConfirmReservation(ctx context.Context, id uint) (*model.ShipmentResp, error) {
+	var resp model.ShipmentResp
+	if err := c.call(ctx, "Reservation.ConfirmReservation", model.ConfirmReservationReq{ID: id}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
@@ -0,0 +1,55 @@
+package client
+
+import (
+	"context"
+	"storageapi/pkg/model"
+)
+
+func (c *Client) DefineStorageSchema(ctx context.Context, req model.StorageSchemaReq) (model.StorageSchemaResp, error) {
+	var resp model.StorageSchemaResp
+	if err := c.callMutation(ctx, req.IdempotencyKey, "Storage.DefineStorageSchema", req, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *Client) GetStorageSchema(ctx context.Context, req model.GetStorageSchemaReq) (model.GetStorageSchemaResp, error) {
+	var resp model.GetStorageSchemaResp
+	if err := c.callWithRetry(ctx, "Storage.GetStorageSchema", req, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *Client) GetUnreservedStorage(ctx context.Context, storageID uint) (*model.StorageSchemaRespItem, error) {
+	var resp model.StorageSchemaRespItem
+	req := model.GetUnreservedStorageReq{StorageID: storageID}
+	if err := c.callWithRetry(ctx, "Storage.GetUnreservedStorage", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *Client) ReceiveStock(ctx context.Context, req model.ReceiveStockReq) (*model.StockResp, error) {
+	var resp model.StockResp
+	if err := c.callMutation(ctx, req.IdempotencyKey, "Storage.ReceiveStock", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *Client) AdjustStock(ctx context.Context, req model.AdjustStockReq) (*model.StockResp, error) {
+	var resp model.StockResp
+	if err := c.callMutation(ctx, req.IdempotencyKey, "Storage.AdjustStock", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *Client) SetStorageAvailability(ctx context.Context, req model.SetStorageAvailabilityReq) (*model.SetStorageAvailabilityResp, error) {
+	var resp model.SetStorageAvailabilityResp
+	if err := c.call(ctx, "Storage.SetStorageAvailability", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
@@ -0,0 +1,38 @@
+package client
+
+import (
+	"context"
+	"storageapi/pkg/model"
+)
+
+func (c *Client) CreateTransfer(ctx context.Context, req model.CreateTransferReq) (*model.TransferResp, error) {
+	var resp model.TransferResp
+	if err := c.callMutation(ctx, req.IdempotencyKey, "Transfer.CreateTransfer", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *Client) GetTransfer(ctx context.Context, id uint) (*model.TransferResp, error) {
+	var resp model.TransferResp
+	if err := c.callWithRetry(ctx, "Transfer.GetTransfer", model.GetTransferReq{ID: id}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *Client) ReceiveTransfer(ctx context.Context, id uint) (*model.TransferResp, error) {
+	var resp model.TransferResp
+	if err := c.call(ctx, "Transfer.ReceiveTransfer", model.ReceiveTransferReq{ID: id}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *Client) CancelTransfer(ctx context.Context, id uint) (*model.TransferResp, error) {
+	var resp model.TransferResp
+	if err := c.call(ctx, "Transfer.CancelTransfer", model.CancelTransferReq{ID: id}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
@@ -0,0 +1,119 @@
+// Package errs - ошибки api с машиночитаемым кодом и деталями.
+// Use case возвращают *Error, транспорты сериализуют их в свой формат
+// (JSON-RPC error, REST тело ответа, grpc status), клиент разбирает обратно
+package errs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+type Code string
+
+const (
+	CodeNotFound          Code = "not_found"
+	CodeUnavailable       Code = "unavailable"        // склад недоступен
+	CodeInsufficientStock Code = "insufficient_stock" // не хватает свободного остатка
+	CodeValidation        Code = "validation"
+	CodeConflict          Code = "conflict" // состояние не позволяет выполнить запрос
+	CodeInternal          Code = "internal"
+)
+
+// ошибка одного поля запроса, Path вида storages[2].products[0].vendor
+type FieldError struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+type Error struct {
+	Code    Code                   `json:"code"`
+	Message string                 `json:"message"`
+	Details map[string]interface{} `json:"details,omitempty"`
+	// ошибки полей запроса, только для CodeValidation
+	Fields []FieldError `json:"fields,omitempty"`
+
+	// исходная ошибка, не сериализуется
+	cause error
+}
+
+func New(code Code, message string) *Error {
+	return &Error{
+		Code:    code,
+		Message: message,
+	}
+}
+
+func Newf(code Code, format string, args ...interface{}) *Error {
+	return New(code, fmt.Sprintf(format, args...))
+}
+
+// Wrap сохраняет err как причину, errors.Is/As видят ее через Unwrap
+func Wrap(code Code, err error, message string) *Error {
+	e := New(code, message)
+	e.cause = err
+	return e
+}
+
+func (e *Error) Error() string {
+	if e.cause != nil {
+		return e.Message + ": " + e.cause.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// WithDetail добавляет деталь (id товара, доступное количество и т.д.), возвращает e
+func (e *Error) WithDetail(key string, value interface{}) *Error {
+	if e.Details == nil {
+		e.Details = map[string]interface{}{}
+	}
+	e.Details[key] = value
+	return e
+}
+
+// From возвращает *Error из цепочки err, остальные ошибки считаются внутренними
+func From(err error) *Error {
+	if err == nil {
+		return nil
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	return Wrap(CodeInternal, err, "internal error")
+}
+
+// CodeOf - код ошибки, для ошибок не из этого пакета - CodeInternal
+func CodeOf(err error) Code {
+	return From(err).Code
+}
+
+// Marshal - JSON представление ошибки, используется там, где транспорт
+// передает ошибку строкой (net/rpc)
+func Marshal(err error) string {
+	e := From(err)
+	// причина передается в тексте сообщения
+	payload := *e
+	if e.cause != nil {
+		payload.Message = e.Error()
+	}
+	b, jsonErr := json.Marshal(payload)
+	if jsonErr != nil {
+		return e.Error()
+	}
+	return string(b)
+}
+
+// Unmarshal разбирает строку, записанную Marshal. строки в другом формате
+// превращаются во внутреннюю ошибку с этим текстом
+func Unmarshal(s string) *Error {
+	var e Error
+	if err := json.Unmarshal([]byte(s), &e); err != nil || e.Code == "" {
+		return New(CodeInternal, s)
+	}
+	return &e
+}
@@ -0,0 +1,32 @@
+package errs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestMarshalRoundTrip(t *testing.T) {
+	err := fmt.Errorf("reserve: %w", New(CodeInsufficientStock, "not enough").WithDetail("product_id", 3))
+	e := Unmarshal(Marshal(err))
+	if e.Code != CodeInsufficientStock || e.Message != "not enough" || e.Details["product_id"] != float64(3) {
+		t.Fatalf("unexpected error %+v", e)
+	}
+
+	// ошибки без кода - внутренние, причина остается в сообщении
+	e = Unmarshal(Marshal(context.DeadlineExceeded))
+	if e.Code != CodeInternal || e.Message != "internal error: context deadline exceeded" {
+		t.Fatalf("unexpected error %+v", e)
+	}
+	if e := Unmarshal("plain text"); e.Code != CodeInternal || e.Message != "plain text" {
+		t.Fatalf("unexpected error %+v", e)
+	}
+}
+
+func TestWrap(t *testing.T) {
+	err := Wrap(CodeConflict, context.Canceled, "request canceled")
+	if !errors.Is(err, context.Canceled) || CodeOf(err) != CodeConflict {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
@@ -0,0 +1,55 @@
+// Package model содержит типы запросов и ответов storage api.
+// Их используют и сервер (use case), и клиент pkg/client
+package model
+
+import (
+	"bytes"
+	"storageapi/internal/entity"
+	"storageapi/pkg/errs"
+)
+
+const MaxIdempotencyKeyLength = 255
+
+// запросы, которые раньше были массивом, принимаются и в старом виде,
+// чтобы клиенты старых версий продолжали работать
+func isJSONArray(data []byte) bool {
+	data = bytes.TrimLeft(data, " \t\r\n")
+	return len(data) > 0 && data[0] == '['
+}
+
+func ValidateIdempotencyKey(key string) error {
+	if len(key) > MaxIdempotencyKeyLength {
+		return errs.Newf(errs.CodeValidation, "idempotency key length too big (max %d)", MaxIdempotencyKeyLength)
+	}
+	return nil
+}
+
+// стратегии распределения позиции резерва по складам
+const (
+	// сначала склады с наибольшим свободным остатком
+	StrategyLargestFirst = "largest_first"
+	// минимум затронутых складов, остаток берется со склада, где его хватает впритык
+	StrategyFewestStorages = "fewest_storages"
+	// один склад, на котором хватает всей позиции, иначе как fewest_storages
+	StrategySingleStorage = "single_storage"
+	// поровну со всех складов, где есть свободный остаток
+	StrategyEvenSpread = "even_spread"
+	// склады в заданном порядке приоритета, остальные как largest_first
+	StrategyPriority = "priority"
+)
+
+func ValidateStrategy(strategy string) error {
+	switch strategy {
+	case StrategyLargestFirst, StrategyFewestStorages, StrategySingleStorage, StrategyEvenSpread, StrategyPriority:
+		return nil
+	}
+	return errs.Newf(errs.CodeValidation, "unknown allocation strategy %q", strategy)
+}
+
+// причины ручной корректировки остатков (AdjustStockReq.Reason)
+const (
+	StockReasonDamage     = entity.StockReasonDamage
+	StockReasonLoss       = entity.StockReasonLoss
+	StockReasonRecount    = entity.StockReasonRecount
+	StockReasonCorrection = entity.StockReasonCorrection
+)
@@ -0,0 +1,42 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestReserveProductsReqLegacyArray(t *testing.T) {
+	var legacy ReserveProductsReq
+	if err := json.Unmarshal([]byte(` [{"id": 1, "amount": 2}, {"id": 3, "amount": 4}]`), &legacy); err != nil {
+		t.Fatal(err)
+	}
+	if len(legacy.Products) != 2 || legacy.Products[1] != (ReserveProductsReqItem{ID: 3, Amount: 4}) || legacy.TTLSeconds != 0 {
+		t.Fatalf("unexpected request %+v", legacy)
+	}
+
+	var req ReserveProductsReq
+	if err := json.Unmarshal([]byte(`{"products": [{"id": 1, "amount": 2}], "ttl_seconds": 60, "strategy": "priority"}`), &req); err != nil {
+		t.Fatal(err)
+	}
+	if len(req.Products) != 1 || req.TTLSeconds != 60 || req.Strategy != StrategyPriority {
+		t.Fatalf("unexpected request %+v", req)
+	}
+}
+
+func TestStorageSchemaReqLegacyArray(t *testing.T) {
+	var legacy StorageSchemaReq
+	if err := json.Unmarshal([]byte(`[{"is_available": true, "products": [{"vendor": "a", "amount": 1}]}]`), &legacy); err != nil {
+		t.Fatal(err)
+	}
+	if len(legacy.Storages) != 1 || !legacy.Storages[0].IsAvailable || legacy.Storages[0].Products[0].Vendor != "a" {
+		t.Fatalf("unexpected request %+v", legacy)
+	}
+
+	var req StorageSchemaReq
+	if err := json.Unmarshal([]byte(`{"idempotency_key": "k", "storages": [{"is_available": false}]}`), &req); err != nil {
+		t.Fatal(err)
+	}
+	if req.IdempotencyKey != "k" || len(req.Storages) != 1 || req.Storages[0].IsAvailable {
+		t.Fatalf("unexpected request %+v", req)
+	}
+}
@@ -0,0 +1,125 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// create reservation request
+
+type ReserveProductsReq struct {
+	// повтор запроса с тем же ключом вернет ответ первого запроса
+	IdempotencyKey string                   `json:"idempotency_key,omitempty"`
+	Products       []ReserveProductsReqItem `json:"products"`
+	// время жизни резерва в секундах, 0 - бессрочный резерв
+	TTLSeconds uint `json:"ttl_seconds,omitempty"`
+	// стратегия распределения по складам, пусто - стратегия сервера по умолчанию
+	Strategy string `json:"strategy,omitempty"`
+	// порядок складов для стратегии priority
+	StoragePriority []uint `json:"storage_priority,omitempty"`
+}
+
+// до заказов запрос был массивом позиций: [{"id": 1, "amount": 2}]
+func (req *ReserveProductsReq) UnmarshalJSON(data []byte) error {
+	if isJSONArray(data) {
+		*req = ReserveProductsReq{}
+		return json.Unmarshal(data, &req.Products)
+	}
+	type plain ReserveProductsReq
+	return json.Unmarshal(data, (*plain)(req))
+}
+
+func (req ReserveProductsReq) TTL() time.Duration {
+	return time.Duration(req.TTLSeconds) * time.Second
+}
+
+func (req ReserveProductsReq) Validate() error {
+	return validate(func(v validator) {
+		v.idempotencyKey(req.IdempotencyKey)
+		v.field("strategy").strategy(req.Strategy)
+		for i, id := range req.StoragePriority {
+			v.field("storage_priority").index(i).check(id != 0, "id must be positive")
+		}
+		pv := v.field("products")
+		pv.check(len(req.Products) != 0, "no products to reserve")
+		ids := make([]uint, len(req.Products))
+		for i, r := range req.Products {
+			r.validate(pv.index(i))
+			ids[i] = r.ID
+		}
+		pv.uniqueProductIDs(ids, "id")
+	})
+}
+
+type ReserveProductsReqItem struct {
+	ID     uint `json:"id"`
+	Amount uint `json:"amount"`
+}
+
+func (req ReserveProductsReqItem) Validate() error {
+	return validate(req.validate)
+}
+
+func (req ReserveProductsReqItem) validate(v validator) {
+	v.field("id").id(req.ID)
+	v.field("amount").check(req.Amount != 0, "amount for product reservation cannot be zero")
+}
+
+// reservation response, returned by create and lookup
+
+type ReservationResp struct {
+	ID        uint                  `json:"id"`
+	Status    string                `json:"status"`
+	CreatedAt time.Time             `json:"created_at"`
+	ExpiresAt *time.Time            `json:"expires_at,omitempty"`
+	Items     []ReservationRespItem `json:"items"`
+}
+
+type ReservationRespItem struct {
+	StorageID uint `json:"storage_id"`
+	ProductID uint `json:"product_id"`
+	Amount    uint `json:"amount"`
+}
+
+// reservation lookup
+
+type GetReservationReq struct {
+	ID uint `json:"id"`
+}
+
+func (req GetReservationReq) Validate() error {
+	return validate(func(v validator) {
+		v.field("id").id(req.ID)
+	})
+}
+
+// undo reservation
+
+type UndoReservationReq struct {
+	ID uint `json:"id"`
+}
+
+func (req UndoReservationReq) Validate() error {
+	return validate(func(v validator) {
+		v.field("id").id(req.ID)
+	})
+}
+
+// confirm reservation (shipment)
+
+type ConfirmReservationReq struct {
+	ID uint `json:"id"`
+}
+
+func (req ConfirmReservationReq) Validate() error {
+	return validate(func(v validator) {
+		v.field("id").id(req.ID)
+	})
+}
+
+type ShipmentResp struct {
+	ID            uint                  `json:"id"`
+	ReservationID uint                  `json:"reservation_id"`
+	CreatedAt     time.Time             `json:"created_at"`
+	Items         []ReservationRespItem `json:"items"`
+}
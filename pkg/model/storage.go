@@ -0,0 +1,258 @@
+package model
+
+import "encoding/json"
+
+// storage schema definition types
+
+type StorageSchemaReq struct {
+	// повтор запроса с тем же ключом вернет ответ первого запроса
+	IdempotencyKey string                 `json:"idempotency_key,omitempty"`
+	Storages       []StorageSchemaReqItem `json:"storages"`
+}
+
+// до ключей идемпотентности запрос был массивом складов: [{"is_available": true, "products": [...]}]
+func (req *StorageSchemaReq) UnmarshalJSON(data []byte) error {
+	if isJSONArray(data) {
+		*req = StorageSchemaReq{}
+		return json.Unmarshal(data, &req.Storages)
+	}
+	type plain StorageSchemaReq
+	return json.Unmarshal(data, (*plain)(req))
+}
+
+func (req StorageSchemaReq) Validate() error {
+	return validate(req.validate)
+}
+
+func (req StorageSchemaReq) validate(v validator) {
+	v.idempotencyKey(req.IdempotencyKey)
+	// vendor уникален во всей схеме, а не только на одном складе
+	vendors := map[string]struct{}{}
+	for i, r := range req.Storages {
+		r.validate(v.field("storages").index(i), vendors)
+	}
+}
+
+type StorageSchemaReqItem struct {
+	IsAvailable bool                      `json:"is_available"`
+	Products    []StorageSchemaReqProduct `json:"products"`
+}
+
+func (i StorageSchemaReqItem) Validate() error {
+	return validate(func(v validator) {
+		i.validate(v, map[string]struct{}{})
+	})
+}
+
+// vendors - уже встреченные в запросе вендоры
+func (i StorageSchemaReqItem) validate(v validator, vendors map[string]struct{}) {
+	for k, p := range i.Products {
+		pv := v.field("products").index(k)
+		p.validate(pv)
+		_, seen := vendors[p.Vendor]
+		pv.field("vendor").check(!seen, "vendor is not unique")
+		vendors[p.Vendor] = struct{}{}
+	}
+}
+
+type StorageSchemaReqProduct struct {
+	Vendor string `json:"vendor"`
+	Name   string `json:"name"`
+	Size   string `json:"size"`
+	Amount uint   `json:"amount"`
+}
+
+func (p StorageSchemaReqProduct) Validate() error {
+	return validate(p.validate)
+}
+
+func (p StorageSchemaReqProduct) validate(v validator) {
+	v.field("vendor").check(len(p.Vendor) <= 20, "vendor length too big")
+	v.field("amount").check(p.Amount != 0, "added product amount cannot be zero")
+}
+
+type StorageSchemaResp []StorageSchemaRespItem
+
+type StorageSchemaRespItem struct {
+	ID          uint                       `json:"id"`
+	IsAvailable bool                       `json:"is_available"`
+	Products    []StorageSchemaRespProduct `json:"products"`
+}
+
+type StorageSchemaRespProduct struct {
+	ID     uint   `json:"id"`
+	Name   string `json:"name"`
+	Vendor string `json:"vendor"`
+	Size   string `json:"size"`
+	Amount uint   `json:"amount"`
+}
+
+// storage schema read types
+
+type GetStorageSchemaReq struct {
+	IDs         []uint `json:"ids,omitempty"`          // пусто - все склады
+	IsAvailable *bool  `json:"is_available,omitempty"` // nil - все склады
+	Limit       uint   `json:"limit"`                  // 0 - без ограничения
+	Offset      uint   `json:"offset"`
+}
+
+func (req GetStorageSchemaReq) Validate() error {
+	return validate(func(v validator) {
+		for i, id := range req.IDs {
+			v.field("ids").index(i).check(id != 0, "id must be positive")
+		}
+	})
+}
+
+type GetStorageSchemaResp []GetStorageSchemaRespItem
+
+type GetStorageSchemaRespItem struct {
+	ID          uint                          `json:"id"`
+	IsAvailable bool                          `json:"is_available"`
+	Products    []GetStorageSchemaRespProduct `json:"products"`
+}
+
+// Amount - общее количество товара на складе
+type GetStorageSchemaRespProduct struct {
+	StorageSchemaRespProduct
+	Reserved uint `json:"reserved"`
+	Free     uint `json:"free"`
+}
+
+// stock receipt and adjustment types
+
+type ReceiveStockReq struct {
+	// повтор запроса с тем же ключом вернет ответ первого запроса
+	IdempotencyKey string                   `json:"idempotency_key,omitempty"`
+	StorageID      uint                     `json:"storage_id"`
+	Products       []ReceiveStockReqProduct `json:"products"`
+	Comment        string                   `json:"comment,omitempty"`
+}
+
+func (req ReceiveStockReq) Validate() error {
+	return validate(func(v validator) {
+		v.idempotencyKey(req.IdempotencyKey)
+		v.field("storage_id").id(req.StorageID)
+		pv := v.field("products")
+		pv.check(len(req.Products) != 0, "no products to receive")
+		ids := make([]uint, len(req.Products))
+		for i, p := range req.Products {
+			pv.index(i).field("product_id").id(p.ProductID)
+			pv.index(i).field("amount").check(p.Amount != 0, "received product amount cannot be zero")
+			ids[i] = p.ProductID
+		}
+		pv.uniqueProductIDs(ids, "product_id")
+	})
+}
+
+type ReceiveStockReqProduct struct {
+	ProductID uint `json:"product_id"`
+	Amount    uint `json:"amount"`
+}
+
+type AdjustStockReq struct {
+	// повтор запроса с тем же ключом вернет ответ первого запроса
+	IdempotencyKey string                  `json:"idempotency_key,omitempty"`
+	StorageID      uint                    `json:"storage_id"`
+	Reason         string                  `json:"reason"` // damage, loss, recount, correction
+	Comment        string                  `json:"comment,omitempty"`
+	Products       []AdjustStockReqProduct `json:"products"`
+}
+
+func (req AdjustStockReq) Validate() error {
+	return validate(func(v validator) {
+		v.idempotencyKey(req.IdempotencyKey)
+		v.field("storage_id").id(req.StorageID)
+		switch req.Reason {
+		case StockReasonDamage, StockReasonLoss, StockReasonRecount, StockReasonCorrection:
+		default:
+			v.field("reason").check(false, "unknown adjustment reason")
+		}
+		pv := v.field("products")
+		pv.check(len(req.Products) != 0, "no products to adjust")
+		ids := make([]uint, len(req.Products))
+		for i, p := range req.Products {
+			p.validate(pv.index(i))
+			ids[i] = p.ProductID
+		}
+		pv.uniqueProductIDs(ids, "product_id")
+	})
+}
+
+type AdjustStockReqProduct struct {
+	ProductID uint `json:"product_id"`
+	// изменение остатка, отрицательное при списании
+	Delta int `json:"delta,omitempty"`
+	// новый остаток целиком (например, после пересчета), указывается вместо delta
+	Amount *uint `json:"amount,omitempty"`
+}
+
+func (p AdjustStockReqProduct) Validate() error {
+	return validate(p.validate)
+}
+
+func (p AdjustStockReqProduct) validate(v validator) {
+	v.field("product_id").id(p.ProductID)
+	v.check(p.Amount == nil || p.Delta == 0, "only one of delta and amount can be set")
+	v.check(p.Amount != nil || p.Delta != 0, "either delta or amount must be set")
+}
+
+type StockResp struct {
+	StorageID uint               `json:"storage_id"`
+	Products  []StockRespProduct `json:"products"`
+}
+
+type StockRespProduct struct {
+	ProductID uint `json:"product_id"`
+	Amount    uint `json:"amount"`
+	Reserved  uint `json:"reserved"`
+}
+
+// set storage availability request
+
+type SetStorageAvailabilityReq struct {
+	StorageID   uint `json:"storage_id"`
+	IsAvailable bool `json:"is_available"`
+	// при выключении склада перенести его активные резервы на другие доступные склады
+	EvacuateReservations bool `json:"evacuate_reservations,omitempty"`
+	// стратегия распределения переносимых резервов, пусто - стратегия сервера по умолчанию
+	Strategy string `json:"strategy,omitempty"`
+}
+
+func (req SetStorageAvailabilityReq) Validate() error {
+	return validate(func(v validator) {
+		v.field("storage_id").id(req.StorageID)
+		v.field("evacuate_reservations").check(
+			!req.IsAvailable || (!req.EvacuateReservations && req.Strategy == ""),
+			"reservations can be evacuated only when storage is taken offline",
+		)
+		v.field("strategy").strategy(req.Strategy)
+	})
+}
+
+type SetStorageAvailabilityResp struct {
+	StorageID   uint `json:"storage_id"`
+	IsAvailable bool `json:"is_available"`
+	// заказы, перенесенные на другие склады
+	MovedReservations []uint `json:"moved_reservations"`
+	// заказы, оставшиеся на складе: на доступных складах не хватило свободного остатка
+	NotMovedReservations []NotMovedReservation `json:"not_moved_reservations"`
+}
+
+type NotMovedReservation struct {
+	ReservationID uint   `json:"reservation_id"`
+	ProductID     uint   `json:"product_id"`
+	Reason        string `json:"reason"`
+}
+
+// unreserved storage lookup
+
+type GetUnreservedStorageReq struct {
+	StorageID uint `json:"storage_id"`
+}
+
+func (req GetUnreservedStorageReq) Validate() error {
+	return validate(func(v validator) {
+		v.field("storage_id").id(req.StorageID)
+	})
+}
@@ -0,0 +1,86 @@
+package model
+
+import "time"
+
+// create transfer request
+
+type CreateTransferReq struct {
+	// повтор запроса с тем же ключом вернет ответ первого запроса
+	IdempotencyKey       string                     `json:"idempotency_key,omitempty"`
+	SourceStorageID      uint                       `json:"source_storage_id"`
+	DestinationStorageID uint                       `json:"destination_storage_id"`
+	Products             []CreateTransferReqProduct `json:"products"`
+}
+
+func (req CreateTransferReq) Validate() error {
+	return validate(func(v validator) {
+		v.idempotencyKey(req.IdempotencyKey)
+		v.field("source_storage_id").id(req.SourceStorageID)
+		v.field("destination_storage_id").id(req.DestinationStorageID)
+		v.field("destination_storage_id").check(
+			req.SourceStorageID == 0 || req.SourceStorageID != req.DestinationStorageID,
+			"source and destination storages must differ",
+		)
+		pv := v.field("products")
+		pv.check(len(req.Products) != 0, "no products to transfer")
+		ids := make([]uint, len(req.Products))
+		for i, p := range req.Products {
+			pv.index(i).field("product_id").id(p.ProductID)
+			pv.index(i).field("amount").check(p.Amount != 0, "transferred product amount cannot be zero")
+			ids[i] = p.ProductID
+		}
+		pv.uniqueProductIDs(ids, "product_id")
+	})
+}
+
+type CreateTransferReqProduct struct {
+	ProductID uint `json:"product_id"`
+	Amount    uint `json:"amount"`
+}
+
+// transfer lookup, receive and cancel requests
+
+type TransferIDReq struct {
+	ID uint `json:"id"`
+}
+
+type GetTransferReq TransferIDReq
+
+type ReceiveTransferReq TransferIDReq
+
+type CancelTransferReq TransferIDReq
+
+func (req TransferIDReq) Validate() error {
+	return validate(func(v validator) {
+		v.field("id").id(req.ID)
+	})
+}
+
+func (req GetTransferReq) Validate() error {
+	return TransferIDReq(req).Validate()
+}
+
+func (req ReceiveTransferReq) Validate() error {
+	return TransferIDReq(req).Validate()
+}
+
+func (req CancelTransferReq) Validate() error {
+	return TransferIDReq(req).Validate()
+}
+
+// transfer response
+
+type TransferResp struct {
+	ID                   uint                  `json:"id"`
+	SourceStorageID      uint                  `json:"source_storage_id"`
+	DestinationStorageID uint                  `json:"destination_storage_id"`
+	Status               string                `json:"status"`
+	CreatedAt            time.Time             `json:"created_at"`
+	UpdatedAt            time.Time             `json:"updated_at"`
+	Products             []TransferRespProduct `json:"products"`
+}
+
+type TransferRespProduct struct {
+	ProductID uint `json:"product_id"`
+	Amount    uint `json:"amount"`
+}
@@ -0,0 +1,90 @@
+package model
+
+import (
+	"fmt"
+	"storageapi/pkg/errs"
+	"strings"
+)
+
+// validator собирает ошибки всех полей запроса, а не только первую.
+// path - путь до текущего поля вида storages[2].products[0].vendor
+type validator struct {
+	path   string
+	fields *[]errs.FieldError
+}
+
+func newValidator() validator {
+	return validator{fields: &[]errs.FieldError{}}
+}
+
+func (v validator) field(name string) validator {
+	if v.path != "" {
+		name = v.path + "." + name
+	}
+	return validator{path: name, fields: v.fields}
+}
+
+func (v validator) index(i int) validator {
+	return validator{path: fmt.Sprintf("%s[%d]", v.path, i), fields: v.fields}
+}
+
+// check добавляет ошибку текущего поля, если ok ложно
+func (v validator) check(ok bool, message string) {
+	if !ok {
+		*v.fields = append(*v.fields, errs.FieldError{Path: v.path, Message: message})
+	}
+}
+
+func (v validator) err() error {
+	fields := *v.fields
+	if len(fields) == 0 {
+		return nil
+	}
+	messages := make([]string, 0, len(fields))
+	for _, f := range fields {
+		if f.Path == "" {
+			messages = append(messages, f.Message)
+			continue
+		}
+		messages = append(messages, f.Path+": "+f.Message)
+	}
+	e := errs.New(errs.CodeValidation, strings.Join(messages, "; "))
+	e.Fields = fields
+	return e
+}
+
+// validate запускает fn на новом validator и возвращает все найденные ошибки
+func validate(fn func(v validator)) error {
+	v := newValidator()
+	fn(v)
+	return v.err()
+}
+
+func (v validator) idempotencyKey(key string) {
+	v.field("idempotency_key").check(
+		len(key) <= MaxIdempotencyKeyLength,
+		fmt.Sprintf("idempotency key length too big (max %d)", MaxIdempotencyKeyLength),
+	)
+}
+
+func (v validator) strategy(strategy string) {
+	if strategy == "" {
+		return
+	}
+	v.check(ValidateStrategy(strategy) == nil, fmt.Sprintf("unknown allocation strategy %q", strategy))
+}
+
+func (v validator) id(id uint) {
+	v.check(id != 0, "id is required")
+}
+
+// id товаров должны быть уникальны, повтор отмечается по пути [i].name
+func (v validator) uniqueProductIDs(ids []uint, name string) {
+	seen := make(map[uint]struct{}, len(ids))
+	for i, id := range ids {
+		if _, ok := seen[id]; ok {
+			v.index(i).field(name).check(false, "duplicate product id")
+		}
+		seen[id] = struct{}{}
+	}
+}
@@ -0,0 +1,53 @@
+package model
+
+import (
+	"errors"
+	"storageapi/pkg/errs"
+	"strings"
+	"testing"
+)
+
+func TestValidateCollectsAllFields(t *testing.T) {
+	req := StorageSchemaReq{
+		Storages: []StorageSchemaReqItem{
+			{Products: []StorageSchemaReqProduct{{Vendor: "a", Amount: 1}}},
+			{Products: []StorageSchemaReqProduct{{Vendor: "b", Amount: 1}}},
+			{Products: []StorageSchemaReqProduct{
+				{Vendor: "a", Amount: 1},
+				{Vendor: strings.Repeat("x", 21), Amount: 0},
+			}},
+		},
+	}
+	var e *errs.Error
+	if err := req.Validate(); !errors.As(err, &e) || e.Code != errs.CodeValidation {
+		t.Fatalf("unexpected error %v", err)
+	}
+	want := []string{
+		"storages[2].products[0].vendor",
+		"storages[2].products[1].vendor",
+		"storages[2].products[1].amount",
+	}
+	if len(e.Fields) != len(want) {
+		t.Fatalf("fields %v, want paths %v", e.Fields, want)
+	}
+	for i, f := range e.Fields {
+		if f.Path != want[i] {
+			t.Errorf("field %d path %q, want %q", i, f.Path, want[i])
+		}
+	}
+}
+
+func TestValidateRequestIDs(t *testing.T) {
+	if err := (UndoReservationReq{}).Validate(); errs.CodeOf(err) != errs.CodeValidation {
+		t.Errorf("undo without id: %v", err)
+	}
+	if err := (UndoReservationReq{ID: 1}).Validate(); err != nil {
+		t.Errorf("undo with id: %v", err)
+	}
+	err := ReserveProductsReq{Products: []ReserveProductsReqItem{{ID: 1, Amount: 1}, {ID: 1}}}.Validate()
+	var e *errs.Error
+	if !errors.As(err, &e) || len(e.Fields) != 2 ||
+		e.Fields[0].Path != "products[1].amount" || e.Fields[1].Path != "products[1].id" {
+		t.Errorf("unexpected error %v", err)
+	}
+}